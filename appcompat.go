@@ -0,0 +1,533 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AppCompatCache（Shimcache）记录系统检查过兼容性的程序，只在关机时写入注册表
+const (
+	appCompatCachePath  = `SYSTEM\CurrentControlSet\Control\Session Manager\AppCompatCache`
+	appCompatCacheValue = "AppCompatCache"
+)
+
+// AppCompatCache的格式
+const (
+	shimcacheWin7Magic  = 0xbadc0fee
+	shimcacheWin7Header = 128
+	shimcacheWin8Header = 0x80
+	shimcacheWin8Sig    = "00ts"
+	shimcacheWin81Sig   = "10ts" // Windows 8.1和Windows 10/11的条目签名相同
+	shimcacheWin10      = 0x30
+	shimcacheWin10v2    = 0x34 // 1703起的文件头多4字节
+	shimcacheExecuted   = 0x2  // Win7/8插入标志中表示由CSRSS执行过
+)
+
+// Amcache.hve记录程序文件的SHA1、发布者等信息，InventoryApplicationFile为Windows 10 1709起的格式
+const (
+	amcacheFile          = "Amcache.hve"
+	amcacheInventoryPath = `Root\InventoryApplicationFile`
+)
+
+// shimcacheEntry AppCompatCache中的一个条目，Position为从1开始的位置，越小越新
+type shimcacheEntry struct {
+	Position int
+	Path     string
+	Modified time.Time // 文件的最后修改时间，不是运行时间
+	// Executed 执行标志，只有Win7/8记录，ExecKnown为false时未知
+	Executed  bool
+	ExecKnown bool
+}
+
+// amcacheEntry InventoryApplicationFile中的一个程序文件
+type amcacheEntry struct {
+	Path        string
+	SHA1        string
+	Name        string
+	Publisher   string
+	Version     string
+	ProductName string
+	Size        uint64
+	LinkDate    string
+	OSComponent bool
+	FirstSeen   time.Time // 键的最后写入时间，即首次记录该文件的时间
+}
+
+// parseAppCompatCache 解析AppCompatCache值，返回条目和格式名称
+func parseAppCompatCache(data []byte) ([]shimcacheEntry, string, error) {
+	if len(data) < 4 {
+		return nil, "", errors.New("AppCompatCache: value too short")
+	}
+	switch magic := binary.LittleEndian.Uint32(data); {
+	case magic == shimcacheWin7Magic:
+		return parseShimcacheWin7(data)
+	case magic == shimcacheWin8Header && len(data) >= shimcacheWin8Header+4:
+		switch string(data[shimcacheWin8Header : shimcacheWin8Header+4]) {
+		case shimcacheWin8Sig:
+			entries, err := parseShimcacheEntries(data, shimcacheWin8Header, shimcacheWin8Sig, shimcacheWin8Entry(false))
+			return entries, "Windows 8", err
+		case shimcacheWin81Sig:
+			entries, err := parseShimcacheEntries(data, shimcacheWin8Header, shimcacheWin81Sig, shimcacheWin8Entry(true))
+			return entries, "Windows 8.1", err
+		}
+	case magic == shimcacheWin10 || magic == shimcacheWin10v2:
+		entries, err := parseShimcacheEntries(data, int(magic), shimcacheWin81Sig, shimcacheWin10Entry)
+		return entries, "Windows 10/11", err
+	}
+	return nil, "", fmt.Errorf("AppCompatCache: unsupported format 0x%08x", binary.LittleEndian.Uint32(data))
+}
+
+// parseShimcacheWin7 Win7/2008 R2的条目为定长结构，路径保存在值中的其他位置。
+// 64位系统的条目为48字节，路径偏移前有4字节填充，据此区分32位和64位
+func parseShimcacheWin7(data []byte) ([]shimcacheEntry, string, error) {
+	if len(data) < shimcacheWin7Header+8 {
+		return nil, "", errors.New("AppCompatCache: truncated header")
+	}
+	count := int(binary.LittleEndian.Uint32(data[4:]))
+	x64 := binary.LittleEndian.Uint32(data[shimcacheWin7Header+4:]) == 0
+	format, size := "Windows 7 x86", 32
+	if x64 {
+		format, size = "Windows 7 x64", 48
+	}
+
+	var entries []shimcacheEntry
+	for i := 0; i < count; i++ {
+		e := data[shimcacheWin7Header+i*size:]
+		if len(e) < size {
+			return entries, format, errors.New("AppCompatCache: truncated entry")
+		}
+		length := uint64(binary.LittleEndian.Uint16(e))
+		var offset, modified uint64
+		var flags uint32
+		if x64 {
+			offset, modified, flags = binary.LittleEndian.Uint64(e[8:]), binary.LittleEndian.Uint64(e[16:]), binary.LittleEndian.Uint32(e[24:])
+		} else {
+			offset, modified, flags = uint64(binary.LittleEndian.Uint32(e[4:])), binary.LittleEndian.Uint64(e[8:]), binary.LittleEndian.Uint32(e[16:])
+		}
+		if offset+length > uint64(len(data)) {
+			return entries, format, errors.New("AppCompatCache: path outside value")
+		}
+		entries = append(entries, shimcacheEntry{
+			Position:  i + 1,
+			Path:      shimcachePath(data[offset : offset+length]),
+			Modified:  filetimeToTime(modified),
+			Executed:  flags&shimcacheExecuted != 0,
+			ExecKnown: true,
+		})
+	}
+	return entries, format, nil
+}
+
+// parseShimcacheEntries 解析Win8起的变长条目：4字节签名、4字节未知、4字节数据长度，之后为条目数据
+func parseShimcacheEntries(data []byte, offset int, sig string, parse func([]byte) (shimcacheEntry, error)) ([]shimcacheEntry, error) {
+	var entries []shimcacheEntry
+	for offset+12 <= len(data) && string(data[offset:offset+4]) == sig {
+		size := int(binary.LittleEndian.Uint32(data[offset+8:]))
+		if offset+12+size > len(data) {
+			return entries, errors.New("AppCompatCache: truncated entry")
+		}
+		entry, err := parse(data[offset+12 : offset+12+size])
+		if err != nil {
+			return entries, err
+		}
+		entry.Position = len(entries) + 1
+		entries = append(entries, entry)
+		offset += 12 + size
+	}
+	return entries, nil
+}
+
+// shimcacheWin8Entry 路径之后依次为插入标志、shim标志和修改时间，8.1在路径之后还有程序包名
+func shimcacheWin8Entry(hasPackage bool) func([]byte) (shimcacheEntry, error) {
+	return func(b []byte) (shimcacheEntry, error) {
+		path, rest, err := shimcacheString(b)
+		if err != nil {
+			return shimcacheEntry{}, err
+		}
+		if hasPackage {
+			if _, rest, err = shimcacheString(rest); err != nil {
+				return shimcacheEntry{}, err
+			}
+		}
+		if len(rest) < 16 {
+			return shimcacheEntry{}, errors.New("AppCompatCache: truncated entry")
+		}
+		return shimcacheEntry{
+			Path:      path,
+			Modified:  filetimeToTime(binary.LittleEndian.Uint64(rest[8:])),
+			Executed:  binary.LittleEndian.Uint32(rest)&shimcacheExecuted != 0,
+			ExecKnown: true,
+		}, nil
+	}
+}
+
+// shimcacheWin10Entry 路径之后直接是修改时间，不再记录执行标志
+func shimcacheWin10Entry(b []byte) (shimcacheEntry, error) {
+	path, rest, err := shimcacheString(b)
+	if err != nil {
+		return shimcacheEntry{}, err
+	}
+	if len(rest) < 8 {
+		return shimcacheEntry{}, errors.New("AppCompatCache: truncated entry")
+	}
+	return shimcacheEntry{Path: path, Modified: filetimeToTime(binary.LittleEndian.Uint64(rest))}, nil
+}
+
+// shimcacheString 读取2字节长度（字节数）开头的UTF-16字符串，返回其后的数据
+func shimcacheString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("AppCompatCache: truncated entry")
+	}
+	n := int(binary.LittleEndian.Uint16(b))
+	if 2+n > len(b) {
+		return "", nil, errors.New("AppCompatCache: truncated entry")
+	}
+	return shimcachePath(b[2 : 2+n]), b[2+n:], nil
+}
+
+// shimcachePath 去掉NT路径前缀\??\
+func shimcachePath(b []byte) string {
+	return strings.TrimPrefix(utf16String(b), `\??\`)
+}
+
+// readShimcache 从本机注册表或离线SYSTEM hive中读取AppCompatCache
+func readShimcache() ([]shimcacheEntry, string, error) {
+	key, err := openRegistryKey(hkeyLocalMachine, appCompatCachePath)
+	if err != nil {
+		return nil, "", err
+	}
+	defer key.Close()
+	_, data, err := key.GetValue(appCompatCacheValue)
+	if err != nil {
+		return nil, "", err
+	}
+	return parseAppCompatCache(data)
+}
+
+// openAmcache 打开Amcache.hve，离线分析时从 -hive-dir 中读取
+func openAmcache() (*regfHive, string, error) {
+	if registryHiveDir != "" {
+		hive, err := loadHive(amcacheFile)
+		return hive, filepath.Join(registryHiveDir, amcacheFile), err
+	}
+	path, err := liveAmcachePath()
+	if err != nil {
+		return nil, path, err
+	}
+	hive, err := openRegfHive(path)
+	return hive, path, err
+}
+
+// readAmcache 读取InventoryApplicationFile下的所有程序文件
+func readAmcache(ctx context.Context) ([]amcacheEntry, string, error) {
+	hive, path, err := openAmcache()
+	if err != nil {
+		return nil, path, err
+	}
+	root, err := hive.Root()
+	if err != nil {
+		return nil, path, err
+	}
+	inventory, err := root.OpenKey(amcacheInventoryPath)
+	if err != nil {
+		return nil, path, err
+	}
+	subkeys, err := inventory.Subkeys()
+	if err != nil {
+		return nil, path, err
+	}
+
+	var entries []amcacheEntry
+	for _, key := range subkeys {
+		if ctx.Err() != nil {
+			return entries, path, ctx.Err()
+		}
+		values, err := key.Values()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, newAmcacheEntry(key.LastWrite, values))
+	}
+	return entries, path, nil
+}
+
+// newAmcacheEntry 从键中的值提取文件信息。FileId为4个0加上文件的SHA1
+func newAmcacheEntry(written time.Time, values []regfValue) amcacheEntry {
+	byName := make(map[string]regfValue, len(values))
+	for _, value := range values {
+		byName[strings.ToLower(value.Name)] = value
+	}
+	str := func(name string) string {
+		value, ok := byName[strings.ToLower(name)]
+		if !ok || (value.Type != regSZ && value.Type != regExpandSZ) {
+			return ""
+		}
+		return registryString(value.Data)
+	}
+
+	entry := amcacheEntry{
+		Path:        str("LowerCaseLongPath"),
+		SHA1:        strings.ToLower(str("FileId")),
+		Name:        str("Name"),
+		Publisher:   str("Publisher"),
+		Version:     str("Version"),
+		ProductName: str("ProductName"),
+		LinkDate:    str("LinkDate"),
+		FirstSeen:   written,
+	}
+	if len(entry.SHA1) == 44 && strings.HasPrefix(entry.SHA1, "0000") {
+		entry.SHA1 = entry.SHA1[4:]
+	}
+	if value, ok := byName["size"]; ok && (value.Type == regDword || value.Type == regQword) {
+		entry.Size = leUint(value.Data)
+	}
+	if value, ok := byName["isoscomponent"]; ok && value.Type == regDword {
+		entry.OSComponent = leUint(value.Data) != 0
+	}
+	return entry
+}
+
+// checkShimcache 列出AppCompatCache中的程序，报告位于用户可写目录中的程序
+func checkShimcache(ctx context.Context, results *[]CheckResult) {
+	category := tr("Shimcache检查")
+
+	entries, format, err := readShimcache()
+	if err != nil && len(entries) == 0 {
+		addErrorResult(results, category, tr("读取AppCompatCache失败"), err)
+		return
+	}
+
+	var listing []string
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return
+		}
+		listing = append(listing, entry.summary())
+		if !isUserWritablePath(entry.Path) {
+			continue
+		}
+		details := fmt.Sprintf(tr("位置: %d（越小越新）\n"), entry.Position)
+		details += fmt.Sprintf(tr("文件修改时间: %s\n"), formatReportTime(entry.Modified))
+		if entry.ExecKnown {
+			details += fmt.Sprintf(tr("执行标志: %v\n"), entry.Executed)
+		}
+		addCheckResult(results, category, fmt.Sprintf(tr("Shimcache中用户可写目录中的程序: %s"), entry.Path),
+			SeverityWarning, StatusAbnormal, details)
+	}
+
+	details := fmt.Sprintf(tr("格式: %s\n"), format) +
+		tr("AppCompatCache只在关机时写入注册表，本次开机后运行的程序不在其中；条目存在不代表程序一定运行过\n")
+	addCheckResult(results, category, fmt.Sprintf(tr("Shimcache条目数: %d"), len(entries)), SeverityInfo, StatusOK, details, listing...)
+	if err != nil {
+		addErrorResult(results, category, tr("AppCompatCache中的部分条目损坏"), err)
+	}
+}
+
+// summary 条目列表中的一行
+func (e shimcacheEntry) summary() string {
+	line := fmt.Sprintf("%4d %s %s", e.Position, formatReportTime(e.Modified), e.Path)
+	if e.ExecKnown && e.Executed {
+		line += tr(" [已执行]")
+	}
+	return line
+}
+
+// checkAmcache 列出Amcache.hve中记录的程序文件，报告位于用户可写目录中的程序
+func checkAmcache(ctx context.Context, results *[]CheckResult) {
+	category := tr("Amcache检查")
+
+	entries, path, err := readAmcache(ctx)
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取Amcache失败: %s（文件被系统占用时可复制出后用 -hive-dir 分析）"), path), err)
+		return
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].FirstSeen.After(entries[j].FirstSeen) })
+
+	var listing []string
+	components := 0
+	for _, entry := range entries {
+		if entry.OSComponent {
+			components++
+			continue
+		}
+		listing = append(listing, entry.summary())
+		if isUserWritablePath(entry.Path) {
+			addCheckResult(results, category, fmt.Sprintf(tr("Amcache中用户可写目录中的程序: %s"), entry.Path),
+				SeverityWarning, StatusAbnormal, entry.details())
+		}
+	}
+
+	details := fmt.Sprintf(tr("Amcache文件: %s\n"), path) + fmt.Sprintf(tr("系统组件: %d 个（未列出）\n"), components)
+	addCheckResult(results, category, fmt.Sprintf(tr("Amcache中的程序文件: %d 个"), len(entries)), SeverityInfo, StatusOK, details, listing...)
+}
+
+// summary 程序列表中的一行
+func (e amcacheEntry) summary() string {
+	line := fmt.Sprintf("%s %s SHA1=%s", formatReportTime(e.FirstSeen), e.Path, e.SHA1)
+	if e.Publisher != "" {
+		line += " (" + e.Publisher + ")"
+	}
+	return line
+}
+
+// details 文件的哈希、发布者和版本信息
+func (e amcacheEntry) details() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("首次记录时间: %s\n"), formatReportTime(e.FirstSeen))
+	fmt.Fprintf(&b, "SHA1: %s\n", e.SHA1)
+	publisher := e.Publisher
+	if publisher == "" {
+		publisher = tr("(无)")
+	}
+	fmt.Fprintf(&b, tr("发布者: %s\n"), publisher)
+	if e.ProductName != "" || e.Version != "" {
+		fmt.Fprintf(&b, tr("产品: %s %s\n"), e.ProductName, e.Version)
+	}
+	if e.Size > 0 {
+		fmt.Fprintf(&b, tr("大小: %d 字节\n"), e.Size)
+	}
+	if e.LinkDate != "" {
+		fmt.Fprintf(&b, tr("链接时间: %s\n"), e.LinkDate)
+	}
+	return b.String()
+}
+
+// BAM/DAM（后台/桌面活动调节器）按用户SID记录程序的最后运行时间，Windows 10 1709起存在，1809起移到State子键下
+var bamKeyPaths = []struct {
+	source string
+	path   string
+}{
+	{artifactBAM, `SYSTEM\CurrentControlSet\Services\bam\State\UserSettings`},
+	{artifactBAM, `SYSTEM\CurrentControlSet\Services\bam\UserSettings`},
+	{artifactDAM, `SYSTEM\CurrentControlSet\Services\dam\State\UserSettings`},
+	{artifactDAM, `SYSTEM\CurrentControlSet\Services\dam\UserSettings`},
+}
+
+// bamEntry BAM或DAM中的一个程序
+type bamEntry struct {
+	Source  string
+	SID     string
+	Path    string
+	LastRun time.Time
+}
+
+// readBAM 读取BAM和DAM中所有用户的程序记录，值名为\Device\HarddiskVolumeN形式的路径，数据开头为FILETIME
+func readBAM(ctx context.Context) ([]bamEntry, error) {
+	var entries []bamEntry
+	found := false
+	for _, k := range bamKeyPaths {
+		key, err := openRegistryKey(hkeyLocalMachine, k.path)
+		if err != nil {
+			continue
+		}
+		found = true
+		sids, err := key.ReadSubKeyNames()
+		if err != nil {
+			key.Close()
+			return entries, err
+		}
+		for _, sid := range sids {
+			if ctx.Err() != nil {
+				key.Close()
+				return entries, ctx.Err()
+			}
+			user, err := key.OpenKey(sid)
+			if err != nil {
+				continue
+			}
+			names, _ := user.ReadValueNames()
+			for _, name := range names {
+				// 其余的值为Version、SequenceNumber，或UWP应用的包名
+				typ, data, err := user.GetValue(name)
+				if err != nil || typ != regBinary || len(data) < 8 || !strings.Contains(name, `\`) {
+					continue
+				}
+				entries = append(entries, bamEntry{Source: k.source, SID: sid, Path: name, LastRun: filetimeToTime(binary.LittleEndian.Uint64(data))})
+			}
+			user.Close()
+		}
+		key.Close()
+	}
+	if !found {
+		return nil, errors.New(tr("BAM/DAM注册表项不存在（Windows 10 1709之前的系统没有）"))
+	}
+	return entries, nil
+}
+
+// UserAssist记录当前用户通过资源管理器启动的程序，值名经过ROT13编码
+const userAssistPath = `Software\Microsoft\Windows\CurrentVersion\Explorer\UserAssist\{CEBFF5CD-ACE2-4F4F-9178-9926F41749EA}\Count`
+
+// UserAssist路径中代替常见目录的已知文件夹GUID
+var knownFolderPaths = map[string]string{
+	"{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}": `C:\Windows\System32`,
+	"{D65231B0-B2F1-4857-A4CE-A8E7C6EA7D27}": `C:\Windows\SysWOW64`,
+	"{F38BF404-1D43-42F2-9305-67DE0B28FC23}": `C:\Windows`,
+	"{6D809377-6AF0-444B-8957-A3773F02200E}": `C:\Program Files`,
+	"{905E63B6-C1BF-494E-B29C-65B732D3D21A}": `C:\Program Files`,
+	"{7C5A40EF-A0FB-4BFC-874A-C0F2E0B9FA8E}": `C:\Program Files (x86)`,
+}
+
+// userAssistEntry UserAssist中的一个程序
+type userAssistEntry struct {
+	Path     string
+	RunCount uint32
+	LastRun  time.Time
+}
+
+// readUserAssist 读取当前用户（离线分析时为NTUSER.DAT）的UserAssist记录。
+// Windows 7起每个值72字节，运行次数位于偏移4，最后运行时间位于偏移60
+func readUserAssist() ([]userAssistEntry, error) {
+	key, err := openRegistryKey(hkeyCurrentUser, userAssistPath)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	names, err := key.ReadValueNames()
+	if err != nil {
+		return nil, err
+	}
+	var entries []userAssistEntry
+	for _, name := range names {
+		_, data, err := key.GetValue(name)
+		if err != nil || len(data) < 68 {
+			continue
+		}
+		path := rot13(name)
+		if strings.HasPrefix(path, "UEME_") {
+			continue
+		}
+		if i := strings.Index(path, `}\`); strings.HasPrefix(path, "{") && i > 0 {
+			if folder, ok := knownFolderPaths[strings.ToUpper(path[:i+1])]; ok {
+				path = folder + path[i+1:]
+			}
+		}
+		entries = append(entries, userAssistEntry{
+			Path:     path,
+			RunCount: binary.LittleEndian.Uint32(data[4:]),
+			LastRun:  filetimeToTime(binary.LittleEndian.Uint64(data[60:])),
+		})
+	}
+	return entries, nil
+}
+
+// rot13 解码UserAssist的值名，只变换英文字母
+func rot13(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, s)
+}
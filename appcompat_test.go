@@ -0,0 +1,194 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// shimcacheTestEntry 构造Win8起的变长条目，body为签名和长度之后的数据
+func shimcacheTestEntry(sig string, body []byte) []byte {
+	entry := append([]byte(sig), 0, 0, 0, 0)
+	entry = binary.LittleEndian.AppendUint32(entry, uint32(len(body)))
+	return append(entry, body...)
+}
+
+func shimcacheTestString(s string) []byte {
+	encoded := []byte(utf16LE(s))
+	return append(binary.LittleEndian.AppendUint16(nil, uint16(len(encoded))), encoded...)
+}
+
+// buildTestShimcache 按指定格式构造AppCompatCache值，flags只在Win7/8格式中保存
+func buildTestShimcache(format string, paths []string, modified time.Time, flags []uint32) []byte {
+	ft := timeToFiletime(modified)
+	switch format {
+	case "Windows 7 x64", "Windows 7 x86":
+		size := 48
+		if format == "Windows 7 x86" {
+			size = 32
+		}
+		data := make([]byte, shimcacheWin7Header+size*len(paths))
+		binary.LittleEndian.PutUint32(data, shimcacheWin7Magic)
+		binary.LittleEndian.PutUint32(data[4:], uint32(len(paths)))
+		for i, path := range paths {
+			encoded := []byte(utf16LE(`\??\` + path))
+			e := data[shimcacheWin7Header+i*size:]
+			binary.LittleEndian.PutUint16(e, uint16(len(encoded)))
+			binary.LittleEndian.PutUint16(e[2:], uint16(len(encoded)+2))
+			if size == 48 {
+				binary.LittleEndian.PutUint64(e[8:], uint64(len(data)))
+				binary.LittleEndian.PutUint64(e[16:], ft)
+				binary.LittleEndian.PutUint32(e[24:], flags[i])
+			} else {
+				binary.LittleEndian.PutUint32(e[4:], uint32(len(data)))
+				binary.LittleEndian.PutUint64(e[8:], ft)
+				binary.LittleEndian.PutUint32(e[16:], flags[i])
+			}
+			data = append(data, encoded...)
+		}
+		return data
+	case "Windows 8", "Windows 8.1":
+		data := make([]byte, shimcacheWin8Header)
+		binary.LittleEndian.PutUint32(data, shimcacheWin8Header)
+		sig := shimcacheWin8Sig
+		if format == "Windows 8.1" {
+			sig = shimcacheWin81Sig
+		}
+		for i, path := range paths {
+			body := shimcacheTestString(path)
+			if format == "Windows 8.1" {
+				body = append(body, shimcacheTestString("")...)
+			}
+			body = binary.LittleEndian.AppendUint32(body, flags[i])
+			body = binary.LittleEndian.AppendUint32(body, 0)
+			body = binary.LittleEndian.AppendUint64(body, ft)
+			body = binary.LittleEndian.AppendUint32(body, 0)
+			data = append(data, shimcacheTestEntry(sig, body)...)
+		}
+		return data
+	default:
+		data := make([]byte, shimcacheWin10v2)
+		binary.LittleEndian.PutUint32(data, shimcacheWin10v2)
+		for _, path := range paths {
+			body := shimcacheTestString(path)
+			body = binary.LittleEndian.AppendUint64(body, ft)
+			body = binary.LittleEndian.AppendUint32(body, 4)
+			body = append(body, 1, 0, 0, 0)
+			data = append(data, shimcacheTestEntry(shimcacheWin81Sig, body)...)
+		}
+		return data
+	}
+}
+
+func TestParseAppCompatCache(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	paths := []string{`C:\Users\bob\AppData\Local\Temp\evil.exe`, `C:\Windows\System32\cmd.exe`}
+	flags := []uint32{shimcacheExecuted, 0}
+
+	for _, format := range []string{"Windows 7 x64", "Windows 7 x86", "Windows 8", "Windows 8.1", "Windows 10/11"} {
+		entries, got, err := parseAppCompatCache(buildTestShimcache(format, paths, modified, flags))
+		if err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if got != format {
+			t.Errorf("format = %q, want %q", got, format)
+		}
+		execKnown := format != "Windows 10/11"
+		want := []shimcacheEntry{
+			{Position: 1, Path: paths[0], Modified: modified, Executed: execKnown, ExecKnown: execKnown},
+			{Position: 2, Path: paths[1], Modified: modified, ExecKnown: execKnown},
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Errorf("%s:\n%+v\nwant\n%+v", format, entries, want)
+		}
+	}
+
+	truncated := buildTestShimcache("Windows 10/11", paths, modified, flags)
+	if entries, _, err := parseAppCompatCache(truncated[:len(truncated)-3]); err == nil || len(entries) != 1 {
+		t.Errorf("truncated value: entries = %d, err = %v; want 1 entry and an error", len(entries), err)
+	}
+	if _, _, err := parseAppCompatCache([]byte{0xef, 0xbe, 0xad, 0xde}); err == nil {
+		t.Error("unknown format: expected error")
+	}
+}
+
+// writeTestHive 把hbin数据写成hive文件
+func writeTestHive(t *testing.T, path string, hbins []byte, root uint32, written time.Time) {
+	t.Helper()
+	data := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 1, 1, root, len(hbins), written), hbins...)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckShimcacheAndAmcache(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	dir := t.TempDir()
+
+	b := newRegfBuilder()
+	value := b.value(appCompatCacheValue, regBinary, buildTestShimcache("Windows 10/11",
+		[]string{`C:\ProgramData\svc\agent.exe`, `C:\Windows\System32\svchost.exe`}, written, nil))
+	key := b.leaf("AppCompatCache", written, value)
+	for _, name := range []string{"Session Manager", "Control", "ControlSet001"} {
+		key = b.key(name, written, b.list("lf", key), 1)
+	}
+	root := b.key("ROOT", written, b.list("lf", b.leaf("Select", written, b.value("Current", regDword, regfTestDword(1))), key), 2)
+	writeTestHive(t, filepath.Join(dir, "SYSTEM"), b.hbins(), root, written)
+
+	b = newRegfBuilder()
+	file := func(id, path, publisher string, component uint32) uint32 {
+		return b.leaf(id, written,
+			b.value("FileId", regSZ, regfTestString("0000"+id)),
+			b.value("LowerCaseLongPath", regSZ, regfTestString(path)),
+			b.value("Publisher", regSZ, regfTestString(publisher)),
+			b.value("Size", regQword, binary.LittleEndian.AppendUint64(nil, 73802)),
+			b.value("IsOsComponent", regDword, regfTestDword(component)))
+	}
+	inventory := b.key("InventoryApplicationFile", written, b.list("lf",
+		file("a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", `c:\users\bob\downloads\tool.exe`, "", 0),
+		file("0b0e4a0bf1b0d5bd6b1a1b5f0d0f7f1f2f3f4f5f", `c:\windows\system32\notepad.exe`, "microsoft corporation", 1),
+	), 2)
+	root = b.key("ROOT", written, b.list("lf", b.key("Root", written, b.list("lf", inventory), 1)), 1)
+	writeTestHive(t, filepath.Join(dir, "amcache.hve"), b.hbins(), root, written)
+
+	saved := registryHiveDir
+	registryHiveDir = dir
+	defer func() { registryHiveDir = saved }()
+
+	var results []CheckResult
+	checkShimcache(context.Background(), &results)
+	checkAmcache(context.Background(), &results)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Severity+" "+r.Description)
+	}
+	want := []string{
+		`warning Shimcache中用户可写目录中的程序: C:\ProgramData\svc\agent.exe`,
+		"info Shimcache条目数: 2",
+		`warning Amcache中用户可写目录中的程序: c:\users\bob\downloads\tool.exe`,
+		"info Amcache中的程序文件: 2 个",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	details := results[2].Details
+	for _, s := range []string{"SHA1: a94a8fe5ccb19ba61c4c0873d391e987982fbbd3", "发布者: (无)", "大小: 73802 字节"} {
+		if !strings.Contains(details, s) {
+			t.Errorf("amcache details missing %q:\n%s", s, details)
+		}
+	}
+	// 系统组件不在列表中
+	if listing := results[3].Evidence; len(listing) != 1 || !strings.Contains(listing[0], "tool.exe") {
+		t.Errorf("amcache listing = %q", listing)
+	}
+}
@@ -0,0 +1,265 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 安全日志中的认证事件
+const (
+	eventLogonSuccess   = 4624
+	eventLogonFailure   = 4625
+	eventAccountLockout = 4740
+)
+
+// 登录类型
+const (
+	logonTypeService           = "5"
+	logonTypeRemoteInteractive = "10"
+)
+
+// authEvent 登录事件及从中提取的来源和账户
+type authEvent struct {
+	LogAnalysis
+	source  string // 来源IP，没有时为工作站名
+	account string // 域\用户名
+}
+
+func newAuthEvent(event LogAnalysis) authEvent {
+	// 4740中的TargetDomainName为发起登录的计算机
+	if event.EventID == eventAccountLockout {
+		source := event.Data["TargetDomainName"]
+		if source == "" {
+			source = "-"
+		}
+		return authEvent{LogAnalysis: event, source: source, account: event.Data["TargetUserName"]}
+	}
+
+	source := event.Data["IpAddress"]
+	if source == "" || source == "-" {
+		source = event.Data["WorkstationName"]
+	}
+	if source == "" {
+		source = "-"
+	}
+	account := event.Data["TargetUserName"]
+	if domain := event.Data["TargetDomainName"]; domain != "" && domain != "-" {
+		account = domain + "\\" + account
+	}
+	return authEvent{LogAnalysis: event, source: source, account: account}
+}
+
+// detectAuthAttacks 根据登录失败、登录成功和账户锁定事件检测暴力破解、密码喷洒等认证攻击
+func detectAuthAttacks(results *[]CheckResult, category string, events []LogAnalysis) {
+	var failures, successes, lockouts []authEvent
+	for _, event := range events {
+		switch event.EventID {
+		case eventLogonFailure:
+			failures = append(failures, newAuthEvent(event))
+		case eventLogonSuccess:
+			if event.Data["LogonType"] != logonTypeService {
+				successes = append(successes, newAuthEvent(event))
+			}
+		case eventAccountLockout:
+			lockouts = append(lockouts, newAuthEvent(event))
+		}
+	}
+	for _, list := range [][]authEvent{failures, successes, lockouts} {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].TimeStamp.Before(list[j].TimeStamp) })
+	}
+
+	before := len(*results)
+	detectBruteForce(results, category, failures)
+	detectPasswordSpray(results, category, failures)
+	detectSuccessAfterFailures(results, category, failures, successes)
+	detectUnusualRDP(results, category, successes)
+	detectLockouts(results, category, lockouts)
+	if len(*results) == before {
+		addCheckResult(results, category, tr("未发现认证攻击迹象"), SeverityInfo, StatusOK,
+			fmt.Sprintf(tr("登录失败: %d 次, 登录成功: %d 次, 账户锁定: %d 次"), len(failures), len(successes), len(lockouts)))
+	}
+}
+
+// detectBruteForce 同一来源在时间窗口内登录失败次数达到阈值
+func detectBruteForce(results *[]CheckResult, category string, failures []authEvent) {
+	window := config.Auth.Window
+	bySource := groupBy(failures, func(e authEvent) string { return e.source })
+	for _, source := range slices.Sorted(maps.Keys(bySource)) {
+		list := bySource[source]
+		start, end, count := densestWindow(list, window, nil)
+		if count < config.Auth.BruteForceThreshold {
+			continue
+		}
+		burst := list[start:end]
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("疑似暴力破解: 来源 %s 在%v内登录失败 %d 次"), source, window, count),
+			SeverityWarning, StatusAbnormal,
+			authDetails(burst, tr("账户"), func(e authEvent) string { return e.account }),
+			authTimeline(burst)...)
+	}
+}
+
+// detectPasswordSpray 时间窗口内登录失败涉及的不同账户数达到阈值
+func detectPasswordSpray(results *[]CheckResult, category string, failures []authEvent) {
+	window := config.Auth.Window
+	start, end, count := densestWindow(failures, window, func(e authEvent) string { return strings.ToLower(e.account) })
+	if count < config.Auth.SprayAccountThreshold {
+		return
+	}
+	burst := failures[start:end]
+	details := listValues(burst, tr("来源"), func(e authEvent) string { return e.source }) +
+		authDetails(burst, tr("账户"), func(e authEvent) string { return e.account })
+	addCheckResult(results, category,
+		fmt.Sprintf(tr("疑似密码喷洒: %v内对 %d 个账户登录失败"), window, count),
+		SeverityWarning, StatusAbnormal, details, authTimeline(burst)...)
+}
+
+// detectSuccessAfterFailures 同一账户或来源多次登录失败后登录成功，可能已被破解。
+// 每个账户和来源的组合只报告第一次
+func detectSuccessAfterFailures(results *[]CheckResult, category string, failures, successes []authEvent) {
+	window := config.Auth.Window
+	byAccount := groupBy(failures, func(e authEvent) string { return strings.ToLower(e.account) })
+	bySource := groupBy(failures, func(e authEvent) string { return e.source })
+
+	reported := make(map[string]bool)
+	for _, success := range successes {
+		key := strings.ToLower(success.account) + "|" + success.source
+		if reported[key] {
+			continue
+		}
+		preceding := failuresBefore(byAccount[strings.ToLower(success.account)], success.TimeStamp, window)
+		if success.source != "-" {
+			if other := failuresBefore(bySource[success.source], success.TimeStamp, window); len(other) > len(preceding) {
+				preceding = other
+			}
+		}
+		if len(preceding) < config.Auth.SuccessAfterFailures {
+			continue
+		}
+		reported[key] = true
+
+		timeline := authTimeline(append(append([]authEvent(nil), preceding...), success))
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("登录失败 %d 次后登录成功: %s 来自 %s"), len(preceding), success.account, success.source),
+			SeverityCritical, StatusAbnormal,
+			fmt.Sprintf(tr("登录类型: %s\n登录成功时间: %s\n"), success.Data["LogonType"], formatReportTime(success.TimeStamp)),
+			timeline...)
+	}
+}
+
+// failuresBefore 返回t之前时间窗口内的登录失败事件，events已按时间排序
+func failuresBefore(events []authEvent, t time.Time, window time.Duration) []authEvent {
+	end := sort.Search(len(events), func(i int) bool { return events[i].TimeStamp.After(t) })
+	start := sort.Search(end, func(i int) bool { return !events[i].TimeStamp.Before(t.Add(-window)) })
+	return events[start:end]
+}
+
+// detectUnusualRDP 来源不在常见网段中的远程桌面登录
+func detectUnusualRDP(results *[]CheckResult, category string, successes []authEvent) {
+	var trusted []*net.IPNet
+	for _, network := range config.Auth.RDPTrustedNetworks {
+		if n, err := parseNetwork(network); err == nil {
+			trusted = append(trusted, n)
+		}
+	}
+
+	var rdp []authEvent
+	for _, event := range successes {
+		if event.Data["LogonType"] != logonTypeRemoteInteractive {
+			continue
+		}
+		ip := net.ParseIP(event.source)
+		if ip == nil || containsIP(trusted, ip) {
+			continue
+		}
+		rdp = append(rdp, event)
+	}
+
+	bySource := groupBy(rdp, func(e authEvent) string { return e.source })
+	for _, source := range slices.Sorted(maps.Keys(bySource)) {
+		list := bySource[source]
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("来自非常见来源的RDP登录: %s (%d 次)"), source, len(list)),
+			SeverityWarning, StatusAbnormal,
+			authDetails(list, tr("账户"), func(e authEvent) string { return e.account }),
+			authTimeline(list)...)
+	}
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectLockouts 账户锁定事件
+func detectLockouts(results *[]CheckResult, category string, lockouts []authEvent) {
+	byAccount := groupBy(lockouts, func(e authEvent) string { return e.account })
+	for _, account := range slices.Sorted(maps.Keys(byAccount)) {
+		list := byAccount[account]
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("账户被锁定: %s (%d 次)"), account, len(list)),
+			SeverityWarning, StatusAbnormal,
+			authDetails(list, tr("调用方计算机"), func(e authEvent) string { return e.source }),
+			authTimeline(list)...)
+	}
+}
+
+// densestWindow 在按时间排序的事件中找出时间窗口内数量最多的区间[start, end)。
+// key为nil时统计事件数，否则统计不同key的数量
+func densestWindow(events []authEvent, window time.Duration, key func(authEvent) string) (int, int, int) {
+	counts := make(map[string]int)
+	bestStart, bestEnd, best := 0, 0, 0
+	start := 0
+	for end, event := range events {
+		if key != nil {
+			counts[key(event)]++
+		}
+		for event.TimeStamp.Sub(events[start].TimeStamp) > window {
+			if key != nil {
+				k := key(events[start])
+				if counts[k]--; counts[k] == 0 {
+					delete(counts, k)
+				}
+			}
+			start++
+		}
+		count := end + 1 - start
+		if key != nil {
+			count = len(counts)
+		}
+		if count > best {
+			bestStart, bestEnd, best = start, end+1, count
+		}
+	}
+	return bestStart, bestEnd, best
+}
+
+// authDetails 列出事件中出现的不同取值及时间范围
+func authDetails(events []authEvent, label string, value func(authEvent) string) string {
+	return listDetails(events, label, value, func(e authEvent) time.Time { return e.TimeStamp })
+}
+
+// authTimeline 按时间列出事件，事件较多时只保留开头和结尾
+func authTimeline(events []authEvent) []string {
+	timeline := make([]string, 0, len(events))
+	for _, event := range events {
+		line := fmt.Sprintf("%s [%d] %s <- %s", formatReportTime(event.TimeStamp), event.EventID, event.account, event.source)
+		if logonType := event.Data["LogonType"]; logonType != "" {
+			line += fmt.Sprintf(tr(" 登录类型 %s"), logonType)
+		}
+		timeline = append(timeline, line)
+	}
+	return elideLines(timeline, maxEventEvidence)
+}
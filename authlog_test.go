@@ -0,0 +1,103 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testLogon 构造登录事件。从多个.evtx合并时记录号会重复，这里统一为1
+func testLogon(id uint32, offset time.Duration, account, source, logonType string) LogAnalysis {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	data := map[string]string{"TargetUserName": account, "IpAddress": source, "LogonType": logonType}
+	if id == eventAccountLockout {
+		data = map[string]string{"TargetUserName": account, "TargetDomainName": source}
+	}
+	return LogAnalysis{EventID: id, TimeStamp: base.Add(offset), RecordID: 1, Data: data}
+}
+
+func testFailures(n int, step time.Duration, account func(int) string, source string) []LogAnalysis {
+	var events []LogAnalysis
+	for i := 0; i < n; i++ {
+		events = append(events, testLogon(eventLogonFailure, time.Duration(i)*step, account(i), source, "3"))
+	}
+	return events
+}
+
+func TestDetectAuthAttacks(t *testing.T) {
+	saved := config.Auth
+	config.Auth.Window = 10 * time.Minute
+	config.Auth.BruteForceThreshold = 5
+	config.Auth.SprayAccountThreshold = 3
+	config.Auth.SuccessAfterFailures = 3
+	config.Auth.RDPTrustedNetworks = []string{"10.0.0.0/8", "192.0.2.1"}
+	defer func() { config.Auth = saved }()
+
+	admin := func(int) string { return "admin" }
+	const none = "未发现认证攻击迹象"
+	tests := []struct {
+		name   string
+		events []LogAnalysis
+		want   []string
+	}{
+		{"brute force at threshold", testFailures(5, time.Second, admin, "203.0.113.5"),
+			[]string{"疑似暴力破解: 来源 203.0.113.5 在10m0s内登录失败 5 次"}},
+		{"brute force below threshold", testFailures(4, time.Second, admin, "203.0.113.5"),
+			[]string{none}},
+		{"brute force spanning exactly the window", testFailures(5, 150*time.Second, admin, "203.0.113.5"),
+			[]string{"疑似暴力破解: 来源 203.0.113.5 在10m0s内登录失败 5 次"}},
+		{"brute force spanning more than the window", testFailures(5, 151*time.Second, admin, "203.0.113.5"),
+			[]string{none}},
+		{"password spray", testFailures(3, time.Minute, func(i int) string { return []string{"alice", "bob", "carol"}[i] }, "203.0.113.5"),
+			[]string{"疑似密码喷洒: 10m0s内对 3 个账户登录失败"}},
+		{"password spray ignores account case", testFailures(3, time.Minute, func(i int) string { return []string{"alice", "ALICE", "bob"}[i] }, "203.0.113.5"),
+			[]string{none}},
+		{"success after failures",
+			append(testFailures(3, time.Second, admin, "203.0.113.5"), testLogon(eventLogonSuccess, time.Minute, "admin", "203.0.113.5", "3")),
+			[]string{"登录失败 3 次后登录成功: admin 来自 203.0.113.5"}},
+		{"success after failures outside the window",
+			append(testFailures(3, time.Second, admin, "203.0.113.5"), testLogon(eventLogonSuccess, 11*time.Minute, "admin", "203.0.113.5", "3")),
+			[]string{none}},
+		{"unusual RDP source", []LogAnalysis{
+			testLogon(eventLogonSuccess, 0, "admin", "198.51.100.7", logonTypeRemoteInteractive),
+			testLogon(eventLogonSuccess, time.Hour, "admin", "198.51.100.7", logonTypeRemoteInteractive),
+		}, []string{"来自非常见来源的RDP登录: 198.51.100.7 (2 次)"}},
+		{"RDP from trusted networks", []LogAnalysis{
+			testLogon(eventLogonSuccess, 0, "admin", "10.1.2.3", logonTypeRemoteInteractive),
+			testLogon(eventLogonSuccess, 0, "admin", "192.0.2.1", logonTypeRemoteInteractive),
+			testLogon(eventLogonSuccess, 0, "admin", "198.51.100.7", "3"),
+		}, []string{none}},
+		{"account lockout", []LogAnalysis{
+			testLogon(eventAccountLockout, 0, "bob", "WS01", ""),
+			testLogon(eventAccountLockout, time.Hour, "bob", "WS02", ""),
+		}, []string{"账户被锁定: bob (2 次)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []CheckResult
+			detectAuthAttacks(&results, "test", tt.events)
+			var got []string
+			for _, r := range results {
+				got = append(got, r.Description)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestDetectLockoutsDetails(t *testing.T) {
+	var results []CheckResult
+	detectAuthAttacks(&results, "test", []LogAnalysis{
+		testLogon(eventAccountLockout, 0, "bob", "WS01", ""),
+		testLogon(eventAccountLockout, time.Minute, "bob", "WS02", ""),
+	})
+	if len(results) != 1 || !strings.Contains(results[0].Details, "WS01, WS02") || len(results[0].Evidence) != 2 {
+		t.Fatalf("results = %+v", results)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// 检查项适用的平台
+const (
+	PlatformWindows = "windows"
+	PlatformLinux   = "linux"
+	PlatformAll     = "all"
+)
+
+// Privilege 检查项运行所需的权限
+type Privilege int
+
+const (
+	PrivilegeNone  Privilege = iota // 普通用户即可运行
+	PrivilegeAdmin                  // 需要管理员权限
+)
+
+func (p Privilege) String() string {
+	if p == PrivilegeAdmin {
+		return tr("管理员")
+	}
+	return tr("普通用户")
+}
+
+// Checker 单项检查。新增检查时实现该接口并在init中调用registerChecker注册，
+// 无需修改main函数
+type Checker interface {
+	// ID 检查项唯一标识，用于 -only/-skip 参数
+	ID() string
+	// Category 检查项所属分组，对应 -ir/-reg 等命令行参数
+	Category() string
+	// Platform 检查项适用的平台
+	Platform() string
+	// Privilege 检查项运行所需的权限
+	Privilege() Privilege
+	// Run 执行检查并返回发现的结果。ctx被取消时应尽快返回已收集的结果
+	Run(ctx context.Context) ([]CheckResult, error)
+}
+
+// checkFunc 检查函数，将发现的结果追加到results中
+type checkFunc func(ctx context.Context, results *[]CheckResult)
+
+// checkGroup 检查分组，每个分组对应一个命令行参数
+type checkGroup struct {
+	Name   string // 命令行参数名
+	Usage  string // 参数说明
+	Banner string // 开始执行该分组时的提示
+}
+
+// 已注册的检查项
+var checkers []Checker
+
+// registerChecker 注册检查项，ID重复时panic
+func registerChecker(c Checker) {
+	for _, existing := range checkers {
+		if existing.ID() == c.ID() {
+			panic(fmt.Sprintf("检查项ID重复: %s", c.ID()))
+		}
+	}
+	checkers = append(checkers, c)
+}
+
+// funcChecker 将现有的检查函数适配为Checker
+type funcChecker struct {
+	id        string
+	category  string
+	platform  string
+	privilege Privilege
+	run       checkFunc
+}
+
+func (c *funcChecker) ID() string           { return c.id }
+func (c *funcChecker) Category() string     { return c.category }
+func (c *funcChecker) Platform() string     { return c.platform }
+func (c *funcChecker) Privilege() Privilege { return c.privilege }
+
+func (c *funcChecker) Run(ctx context.Context) ([]CheckResult, error) {
+	var results []CheckResult
+	c.run(ctx, &results)
+	return results, ctx.Err()
+}
+
+// registerCheck 将当前平台的检查函数注册为检查项
+func registerCheck(id, category string, privilege Privilege, run checkFunc) {
+	registerChecker(&funcChecker{
+		id:        id,
+		category:  category,
+		platform:  runtime.GOOS,
+		privilege: privilege,
+		run:       run,
+	})
+}
+
+// registerCommonCheck 将各平台通用的检查函数注册为检查项
+func registerCommonCheck(id, category string, privilege Privilege, run checkFunc) {
+	registerChecker(&funcChecker{
+		id:        id,
+		category:  category,
+		platform:  PlatformAll,
+		privilege: privilege,
+		run:       run,
+	})
+}
+
+// groupIndex 返回分组的执行顺序，未知分组排在最后
+func groupIndex(category string) int {
+	for i, group := range checkGroups {
+		if group.Name == category {
+			return i
+		}
+	}
+	return len(checkGroups)
+}
+
+// availableCheckers 返回适用于当前平台的检查项，按分组顺序排列，组内保持注册顺序
+func availableCheckers() []Checker {
+	var list []Checker
+	for _, c := range checkers {
+		if c.Platform() == PlatformAll || c.Platform() == runtime.GOOS {
+			list = append(list, c)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return groupIndex(list[i].Category()) < groupIndex(list[j].Category())
+	})
+	return list
+}
+
+// parseCheckIDs 解析以逗号分隔的检查项ID或分组名
+func parseCheckIDs(value string) []string {
+	var ids []string
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// matchChecker 判断检查项是否匹配ID或分组名
+func matchChecker(c Checker, ids []string) bool {
+	for _, id := range ids {
+		if c.ID() == id || c.Category() == id {
+			return true
+		}
+	}
+	return false
+}
+
+// validateCheckIDs 确认每个ID都能匹配至少一个检查项
+func validateCheckIDs(list []Checker, ids []string) error {
+	for _, id := range ids {
+		found := false
+		for _, c := range list {
+			if matchChecker(c, []string{id}) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf(tr("未知的检查项: %s (使用 -list-checks 查看可用检查项)"), id)
+		}
+	}
+	return nil
+}
+
+// selectCheckers 根据分组参数和 -only/-skip 选择要执行的检查项。
+// 指定了only时忽略分组参数
+func selectCheckers(list []Checker, groups map[string]bool, only, skip []string) []Checker {
+	var selected []Checker
+	for _, c := range list {
+		if len(only) > 0 {
+			if !matchChecker(c, only) {
+				continue
+			}
+		} else if !groups[c.Category()] {
+			continue
+		}
+		if matchChecker(c, skip) {
+			continue
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// runChecker 在单项时间限制内执行检查项。检查因取消或超时而中断时，
+// 保留已收集的结果并追加一条未完成记录；检查panic时记为失败，不影响其他检查项
+func runChecker(ctx context.Context, c Checker, timeout time.Duration) []CheckResult {
+	checkCtx, cancel := ctx, context.CancelFunc(func() {})
+	if timeout > 0 {
+		checkCtx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	defer cancel()
+
+	results, err := runRecovered(checkCtx, c)
+	switch {
+	case ctx.Err() != nil:
+		addCheckResult(&results, c.ID(), tr("检查被中断，结果不完整"), SeverityInfo, StatusIncomplete, interruptReason(ctx))
+	case checkCtx.Err() != nil:
+		addCheckResult(&results, c.ID(), tr("检查超时，结果不完整"), SeverityInfo, StatusIncomplete,
+			fmt.Sprintf(tr("超过单项检查时间限制 (%v)"), timeout))
+	case err != nil:
+		addErrorResult(&results, c.ID(), tr("检查项执行失败"), err)
+	}
+	return results
+}
+
+// runRecovered 执行检查项，将panic转换为错误。解析损坏的取证文件时
+// 未预料到的越界不应使整个运行退出
+func runRecovered(ctx context.Context, c Checker) (results []CheckResult, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			results, err = nil, fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return c.Run(ctx)
+}
+
+// skippedChecker 返回因整体运行被中断而未执行的检查项记录
+func skippedChecker(ctx context.Context, c Checker) []CheckResult {
+	var results []CheckResult
+	addCheckResult(&results, c.ID(), tr("检查未执行"), SeverityInfo, StatusIncomplete, interruptReason(ctx))
+	return results
+}
+
+// skippedPrivilege 返回因权限不足而跳过的检查项记录
+func skippedPrivilege(c Checker) []CheckResult {
+	var results []CheckResult
+	addCheckResult(&results, c.ID(), tr("检查未执行"), SeverityInfo, StatusSkipped, tr(privilegeSkipReason))
+	return results
+}
+
+// interruptReason 返回整体运行被中断的原因
+func interruptReason(ctx context.Context) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return tr("超过整体运行时间限制")
+	}
+	return tr("运行被用户中断")
+}
+
+// listCheckers 输出检查项列表
+func listCheckers(w io.Writer, list []Checker) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, tr("ID\t分组\t平台\t权限"))
+	for _, c := range list {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", c.ID(), c.Category(), c.Platform(), c.Privilege())
+	}
+	tw.Flush()
+}
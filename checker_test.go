@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCheckers 两个分组中的四个检查项，不依赖实际注册的检查项
+func testCheckers() []Checker {
+	return []Checker{
+		&funcChecker{id: "ir.users", category: "ir", platform: "windows"},
+		&funcChecker{id: "ir.tasks", category: "ir", platform: "windows", privilege: PrivilegeAdmin},
+		&funcChecker{id: "reg.run", category: "reg", platform: "windows"},
+		&funcChecker{id: "net.ports", category: "net", platform: "windows"},
+	}
+}
+
+func checkerIDs(list []Checker) []string {
+	var ids []string
+	for _, c := range list {
+		ids = append(ids, c.ID())
+	}
+	return ids
+}
+
+func TestParseCheckIDs(t *testing.T) {
+	if got := parseCheckIDs(" ir.users, ,reg ,"); !reflect.DeepEqual(got, []string{"ir.users", "reg"}) {
+		t.Errorf("parseCheckIDs = %q", got)
+	}
+	if got := parseCheckIDs(""); got != nil {
+		t.Errorf("parseCheckIDs(\"\") = %q", got)
+	}
+}
+
+func TestSelectCheckers(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups map[string]bool
+		only   string
+		skip   string
+		want   []string
+	}{
+		{"no groups", nil, "", "", nil},
+		{"one group", map[string]bool{"ir": true}, "", "", []string{"ir.users", "ir.tasks"}},
+		{"two groups keep list order", map[string]bool{"net": true, "ir": true}, "", "", []string{"ir.users", "ir.tasks", "net.ports"}},
+		{"skip by ID", map[string]bool{"ir": true, "reg": true}, "", "ir.tasks", []string{"ir.users", "reg.run"}},
+		{"skip by group", map[string]bool{"ir": true, "reg": true}, "", "ir", []string{"reg.run"}},
+		{"only ignores groups", map[string]bool{"ir": true}, "reg.run", "", []string{"reg.run"}},
+		{"only expands a group", nil, "ir,net.ports", "", []string{"ir.users", "ir.tasks", "net.ports"}},
+		{"skip applies to only", nil, "ir", "ir.users", []string{"ir.tasks"}},
+	}
+	for _, tt := range tests {
+		got := checkerIDs(selectCheckers(testCheckers(), tt.groups, parseCheckIDs(tt.only), parseCheckIDs(tt.skip)))
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCheckIDs(t *testing.T) {
+	for _, ids := range [][]string{nil, {"ir.users"}, {"reg"}, {"ir", "net.ports"}} {
+		if err := validateCheckIDs(testCheckers(), ids); err != nil {
+			t.Errorf("validateCheckIDs(%q): %v", ids, err)
+		}
+	}
+	for _, ids := range [][]string{{"ir.user"}, {"ir", "mem"}, {"IR"}} {
+		err := validateCheckIDs(testCheckers(), ids)
+		if err == nil || !strings.Contains(err.Error(), ids[len(ids)-1]) {
+			t.Errorf("validateCheckIDs(%q) = %v, want unknown %q", ids, err, ids[len(ids)-1])
+		}
+	}
+}
+
+func TestListCheckers(t *testing.T) {
+	var out bytes.Buffer
+	listCheckers(&out, testCheckers())
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("output:\n%s", out.String())
+	}
+	if fields := strings.Fields(lines[0]); !reflect.DeepEqual(fields, []string{"ID", "分组", "平台", "权限"}) {
+		t.Errorf("header = %q", lines[0])
+	}
+	if fields := strings.Fields(lines[2]); !reflect.DeepEqual(fields, []string{"ir.tasks", "ir", "windows", "管理员"}) {
+		t.Errorf("row = %q", lines[2])
+	}
+	// 各列对齐
+	if strings.Index(lines[1], "ir ") != strings.Index(lines[4], "net ") {
+		t.Errorf("columns not aligned:\n%s", out.String())
+	}
+}
+
+// blockingChecker 记录一条结果后等待ctx结束，模拟耗时过长的检查
+func blockingChecker(id string) Checker {
+	return &funcChecker{id: id, run: func(ctx context.Context, results *[]CheckResult) {
+		addCheckResult(results, id, "partial", SeverityWarning, StatusAbnormal, "")
+		<-ctx.Done()
+	}}
+}
+
+func TestRunCheckerTimeout(t *testing.T) {
+	results := runChecker(context.Background(), blockingChecker("test.slow"), 10*time.Millisecond)
+	if len(results) != 2 || results[0].Description != "partial" {
+		t.Fatalf("results = %+v", results)
+	}
+	if r := results[1]; r.Status != StatusIncomplete || r.Description != "检查超时，结果不完整" || !strings.Contains(r.Details, "10ms") {
+		t.Errorf("incomplete result = %+v", r)
+	}
+}
+
+func TestRunCheckerInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	results := runChecker(ctx, blockingChecker("test.slow"), time.Minute)
+	if len(results) != 2 || results[1].Status != StatusIncomplete || results[1].Details != "运行被用户中断" {
+		t.Fatalf("results = %+v", results)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	results = runChecker(ctx, blockingChecker("test.slow"), time.Minute)
+	if len(results) != 2 || results[1].Description != "检查被中断，结果不完整" || results[1].Details != "超过整体运行时间限制" {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestRunCheckerRecoversPanic(t *testing.T) {
+	c := &funcChecker{id: "test.panic", run: func(ctx context.Context, results *[]CheckResult) {
+		addCheckResult(results, "test", "partial", SeverityInfo, StatusOK, "")
+		var b []byte
+		_ = b[16]
+	}}
+	results := runChecker(context.Background(), c, 0)
+	if len(results) != 1 || results[0].Status != StatusFailed || results[0].Category != "test.panic" {
+		t.Fatalf("results = %+v", results)
+	}
+}
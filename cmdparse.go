@@ -0,0 +1,402 @@
+package main
+
+import (
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// 本文件中的解析器不依赖系统显示语言：字段优先按固定顺序定位，
+// 取值通过多语言词表归一化，标签只作为辅助
+
+// 防火墙规则方向和操作的归一化取值
+const (
+	DirectionIn  = "in"
+	DirectionOut = "out"
+
+	ActionAllow  = "allow"
+	ActionBlock  = "block"
+	ActionBypass = "bypass"
+)
+
+// 各语言中的 是/否、方向、操作、任意 取值
+var (
+	yesValues = map[string]bool{
+		"yes": true, "是": true, "はい": true, "ja": true, "oui": true, "sí": true, "si": true, "да": true,
+		"no": false, "否": false, "いいえ": false, "nein": false, "non": false, "нет": false,
+	}
+	directionValues = map[string]string{
+		"in": DirectionIn, "入": DirectionIn, "輸入": DirectionIn, "受信": DirectionIn, "ein": DirectionIn, "entrée": DirectionIn, "entrant": DirectionIn,
+		"out": DirectionOut, "出": DirectionOut, "輸出": DirectionOut, "送信": DirectionOut, "aus": DirectionOut, "sortie": DirectionOut, "sortant": DirectionOut,
+	}
+	actionValues = map[string]string{
+		"allow": ActionAllow, "允许": ActionAllow, "允許": ActionAllow, "許可": ActionAllow, "zulassen": ActionAllow, "autoriser": ActionAllow,
+		"block": ActionBlock, "阻止": ActionBlock, "封鎖": ActionBlock, "ブロック": ActionBlock, "blockieren": ActionBlock, "bloquer": ActionBlock,
+		"bypass": ActionBypass, "绕过": ActionBypass, "略過": ActionBypass, "バイパス": ActionBypass, "umgehen": ActionBypass, "ignorer": ActionBypass,
+	}
+	anyValues = map[string]bool{
+		"any": true, "任何": true, "任意": true, "beliebig": true, "tout": true, "toutes": true, "cualquiera": true,
+	}
+)
+
+// parseYesNo 解析各语言的 是/否，无法识别时返回ok=false
+func parseYesNo(value string) (yes bool, ok bool) {
+	yes, ok = yesValues[strings.ToLower(strings.TrimSpace(value))]
+	return yes, ok
+}
+
+// isAnyValue 判断取值是否表示“任意”
+func isAnyValue(value string) bool {
+	return anyValues[strings.ToLower(strings.TrimSpace(value))]
+}
+
+// splitLines 按行拆分命令输出，兼容CRLF
+func splitLines(output string) []string {
+	return strings.Split(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+}
+
+// splitLabel 以第一个冒号（含全角冒号）拆分 "标签: 值"
+func splitLabel(line string) (label, value string, ok bool) {
+	i := strings.IndexAny(line, ":：")
+	if i < 0 {
+		return "", "", false
+	}
+	sep := ":"
+	if strings.HasPrefix(line[i:], "：") {
+		sep = "："
+	}
+	return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+len(sep):]), true
+}
+
+// FirewallRule netsh advfirewall firewall show rule 输出中的一条规则
+type FirewallRule struct {
+	Name          string
+	Enabled       bool
+	Direction     string // DirectionIn / DirectionOut，无法识别时为原始值
+	Profiles      string
+	Grouping      string
+	LocalIP       string
+	RemoteIP      string
+	Protocol      string
+	LocalPort     string
+	RemotePort    string
+	EdgeTraversal string
+	Action        string // ActionAllow / ActionBlock / ActionBypass，无法识别时为原始值
+}
+
+// 规则中固定位置的字段，各语言顺序一致
+const (
+	ruleFieldEnabled = iota
+	ruleFieldDirection
+	ruleFieldProfiles
+	ruleFieldGrouping
+	ruleFieldLocalIP
+	ruleFieldRemoteIP
+	ruleFieldProtocol
+	ruleFieldLocalPort
+	ruleFieldRemotePort
+)
+
+// 分隔规则名称与规则字段的横线
+var dashLine = regexp.MustCompile(`^-{10,}$`)
+
+// parseFirewallRules 解析 netsh advfirewall firewall show rule name=all 的输出。
+// 每条规则以“名称行 + 横线”开始，其后字段顺序固定；最后两个字段为边缘遍历和操作。
+// ICMP规则在协议之后是不含冒号的类型/代码表，不影响首尾字段的定位
+func parseFirewallRules(output string) []FirewallRule {
+	var rules []FirewallRule
+	var fields []string
+	name := ""
+
+	flush := func() {
+		if name == "" {
+			return
+		}
+		rules = append(rules, buildFirewallRule(name, fields))
+	}
+
+	lines := splitLines(output)
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		// 下一行是横线时，当前行为新规则的名称行
+		if i+1 < len(lines) && dashLine.MatchString(strings.TrimSpace(lines[i+1])) {
+			flush()
+			_, name, _ = splitLabel(line)
+			fields = nil
+			i++
+			continue
+		}
+		if name == "" || line == "" {
+			continue
+		}
+		if _, value, ok := splitLabel(line); ok {
+			fields = append(fields, value)
+		}
+	}
+	flush()
+	return rules
+}
+
+func buildFirewallRule(name string, fields []string) FirewallRule {
+	rule := FirewallRule{Name: name}
+	field := func(i int) string {
+		if i < len(fields) {
+			return fields[i]
+		}
+		return ""
+	}
+
+	rule.Enabled, _ = parseYesNo(field(ruleFieldEnabled))
+	rule.Direction = normalizeValue(field(ruleFieldDirection), directionValues)
+	rule.Profiles = field(ruleFieldProfiles)
+	rule.Grouping = field(ruleFieldGrouping)
+	rule.LocalIP = field(ruleFieldLocalIP)
+	rule.RemoteIP = field(ruleFieldRemoteIP)
+	rule.Protocol = field(ruleFieldProtocol)
+
+	// 只有TCP/UDP规则才有端口字段，且端口字段与边缘遍历、操作之间没有其他字段
+	if len(fields) >= ruleFieldRemotePort+3 {
+		rule.LocalPort = field(ruleFieldLocalPort)
+		rule.RemotePort = field(ruleFieldRemotePort)
+	}
+	if len(fields) >= 2 {
+		rule.EdgeTraversal = fields[len(fields)-2]
+		rule.Action = normalizeValue(fields[len(fields)-1], actionValues)
+	}
+	return rule
+}
+
+// normalizeValue 通过多语言词表归一化取值，无法识别时返回原始值
+func normalizeValue(value string, table map[string]string) string {
+	if normalized, ok := table[strings.ToLower(strings.TrimSpace(value))]; ok {
+		return normalized
+	}
+	return value
+}
+
+// parsePortList 解析 "80,443,5000-5010" 形式的端口列表，忽略 Any、RPC 等非数字取值
+func parsePortList(value string) [][2]int {
+	var ranges [][2]int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		low, high, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(low))
+		if err != nil {
+			continue
+		}
+		end := start
+		if isRange {
+			if end, err = strconv.Atoi(strings.TrimSpace(high)); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges
+}
+
+// portInList 判断端口是否在端口列表中
+func portInList(port int, value string) bool {
+	for _, r := range parsePortList(value) {
+		if port >= r[0] && port <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// AdapterDNS ipconfig /all 输出中一个网卡的DNS服务器
+type AdapterDNS struct {
+	Adapter string
+	Servers []string
+}
+
+// parseIPAddress 解析ipconfig中的地址，去除 "(Preferred)"/"(首选)" 等后缀
+func parseIPAddress(value string) (netip.Addr, bool) {
+	if i := strings.IndexAny(value, "(（"); i >= 0 {
+		value = value[:i]
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(value))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.WithZone(""), true
+}
+
+// parseIPConfigDNS 解析 ipconfig /all 输出中每个网卡的DNS服务器。
+// 网卡标题为顶格且以冒号结尾的行；各语言的DNS服务器标签都包含“DNS”，
+// 且只有DNS服务器字段的取值为IP地址（DNS后缀等字段为域名），续行为只包含地址的缩进行
+func parseIPConfigDNS(output string) []AdapterDNS {
+	var adapters []AdapterDNS
+	var current *AdapterDNS
+	inDNS := false
+
+	for _, line := range splitLines(output) {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		// 网卡标题行
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inDNS = false
+			if strings.HasSuffix(trimmed, ":") || strings.HasSuffix(trimmed, "：") {
+				adapters = append(adapters, AdapterDNS{Adapter: strings.TrimRight(trimmed, ":：")})
+				current = &adapters[len(adapters)-1]
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		// "标签 . . . . : 值" 行，IPv6地址中含冒号，因此以 " : " 作为分隔
+		if label, value, ok := strings.Cut(trimmed, " : "); ok {
+			inDNS = false
+			if !strings.Contains(strings.ToUpper(label), "DNS") {
+				continue
+			}
+			if addr, ok := parseIPAddress(value); ok {
+				current.Servers = append(current.Servers, addr.String())
+				inDNS = true
+			}
+			continue
+		}
+
+		// DNS服务器的续行
+		if inDNS {
+			if addr, ok := parseIPAddress(trimmed); ok {
+				current.Servers = append(current.Servers, addr.String())
+			}
+		}
+	}
+	return adapters
+}
+
+// UserAccount net user <用户名> 输出中的账户属性
+type UserAccount struct {
+	Name             string
+	FullName         string
+	Comment          string
+	Active           bool
+	Expires          string
+	PasswordLastSet  string
+	PasswordExpires  string
+	PasswordRequired bool
+	LastLogon        string
+}
+
+// net user 输出中固定位置的字段（按非空行计）
+const (
+	userFieldName = iota
+	userFieldFullName
+	userFieldComment
+	userFieldUserComment
+	userFieldCountryCode
+	userFieldActive
+	userFieldExpires
+	userFieldPasswordLastSet
+	userFieldPasswordExpires
+	userFieldPasswordChangeable
+	userFieldPasswordRequired
+	userFieldUserMayChange
+	userFieldWorkstations
+	userFieldLogonScript
+	userFieldProfile
+	userFieldHomeDir
+	userFieldLastLogon
+)
+
+// 标签与值之间至少有两个空格
+var labelValueSeparator = regexp.MustCompile(`\s{2,}`)
+
+// parseNetUser 解析 net user <用户名> 的输出，字段按固定顺序定位
+func parseNetUser(output string) (UserAccount, bool) {
+	var values []string
+	for _, line := range splitLines(output) {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			continue
+		}
+		parts := labelValueSeparator.Split(line, 2)
+		value := ""
+		if len(parts) == 2 {
+			value = strings.TrimSpace(parts[1])
+		}
+		values = append(values, value)
+	}
+	if len(values) <= userFieldLastLogon {
+		return UserAccount{}, false
+	}
+
+	account := UserAccount{
+		Name:            values[userFieldName],
+		FullName:        values[userFieldFullName],
+		Comment:         values[userFieldComment],
+		Expires:         values[userFieldExpires],
+		PasswordLastSet: values[userFieldPasswordLastSet],
+		PasswordExpires: values[userFieldPasswordExpires],
+		LastLogon:       values[userFieldLastLogon],
+	}
+	var ok bool
+	if account.Active, ok = parseYesNo(values[userFieldActive]); !ok {
+		return account, false
+	}
+	account.PasswordRequired, _ = parseYesNo(values[userFieldPasswordRequired])
+	return account, true
+}
+
+// 服务状态码，见 SERVICE_STATUS.dwCurrentState
+const (
+	ServiceStopped         = 1
+	ServiceStartPending    = 2
+	ServiceStopPending     = 3
+	ServiceRunning         = 4
+	ServiceContinuePending = 5
+	ServicePausePending    = 6
+	ServicePaused          = 7
+)
+
+// ServiceStatus sc query <服务名> 输出中的服务状态
+type ServiceStatus struct {
+	Name  string
+	State int // 服务状态码，无法解析时为0
+}
+
+// sc query 输出中状态字段的位置：SERVICE_NAME、TYPE、STATE
+const scFieldState = 2
+
+// parseScQuery 解析 sc query <服务名> 的输出，状态取数字状态码而非状态名称
+func parseScQuery(output string) (ServiceStatus, bool) {
+	var status ServiceStatus
+	var values []string
+	stateFound := false
+	for _, line := range splitLines(output) {
+		label, value, ok := splitLabel(strings.TrimSpace(line))
+		if !ok || label == "" || value == "" {
+			continue
+		}
+		if len(values) == 0 {
+			status.Name = value
+		}
+		values = append(values, value)
+		if strings.EqualFold(label, "STATE") {
+			status.State, stateFound = leadingInt(value)
+		}
+	}
+	if !stateFound && len(values) > scFieldState {
+		status.State, stateFound = leadingInt(values[scFieldState])
+	}
+	return status, stateFound
+}
+
+// leadingInt 解析字符串开头的整数
+func leadingInt(value string) (int, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fields[0])
+	return n, err == nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// 测试所覆盖的系统显示语言
+var fixtureLocales = []string{"en-US", "zh-CN"}
+
+func readFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseFirewallRules(t *testing.T) {
+	for _, locale := range fixtureLocales {
+		t.Run(locale, func(t *testing.T) {
+			rules := parseFirewallRules(readFixture(t, "netsh_rules_"+locale+".txt"))
+			if len(rules) != 3 {
+				t.Fatalf("got %d rules, want 3", len(rules))
+			}
+
+			rdp := rules[0]
+			if !rdp.Enabled || rdp.Direction != DirectionIn || rdp.Action != ActionAllow {
+				t.Errorf("rdp rule = %+v", rdp)
+			}
+			if rdp.Protocol != "TCP" || rdp.LocalPort != "3389" || !isAnyValue(rdp.RemoteIP) || !isAnyValue(rdp.RemotePort) {
+				t.Errorf("rdp rule = %+v", rdp)
+			}
+
+			icmp := rules[1]
+			if icmp.Enabled || icmp.Direction != DirectionIn || icmp.Action != ActionAllow {
+				t.Errorf("icmp rule = %+v", icmp)
+			}
+			if icmp.Protocol != "ICMPv4" || icmp.LocalPort != "" || icmp.RemoteIP != "LocalSubnet" {
+				t.Errorf("icmp rule = %+v", icmp)
+			}
+
+			block := rules[2]
+			if block.Name != "Block Telemetry: diagtrack" {
+				t.Errorf("block rule name = %q", block.Name)
+			}
+			if !block.Enabled || block.Direction != DirectionOut || block.Action != ActionBlock || block.Grouping != "" {
+				t.Errorf("block rule = %+v", block)
+			}
+		})
+	}
+}
+
+func TestPortInList(t *testing.T) {
+	tests := []struct {
+		port  int
+		value string
+		want  bool
+	}{
+		{3389, "3389", true},
+		{445, "135,139,445", true},
+		{5005, "5000-5010", true},
+		{22, "5000-5010", false},
+		{80, "Any", false},
+		{135, "RPC", false},
+	}
+	for _, tt := range tests {
+		if got := portInList(tt.port, tt.value); got != tt.want {
+			t.Errorf("portInList(%d, %q) = %v, want %v", tt.port, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseIPConfigDNS(t *testing.T) {
+	wantServers := []string{"192.168.10.2", "8.8.8.8", "fec0:0:0:ffff::1"}
+	adapterNames := map[string][]string{
+		"en-US": {"Ethernet adapter Ethernet0", "Ethernet adapter Bluetooth Network Connection"},
+		"zh-CN": {"以太网适配器 Ethernet0", "以太网适配器 蓝牙网络连接"},
+	}
+
+	for _, locale := range fixtureLocales {
+		t.Run(locale, func(t *testing.T) {
+			adapters := parseIPConfigDNS(readFixture(t, "ipconfig_all_"+locale+".txt"))
+			if len(adapters) != 2 {
+				t.Fatalf("got %d adapters, want 2: %+v", len(adapters), adapters)
+			}
+			for i, name := range adapterNames[locale] {
+				if adapters[i].Adapter != name {
+					t.Errorf("adapter %d = %q, want %q", i, adapters[i].Adapter, name)
+				}
+			}
+			if !reflect.DeepEqual(adapters[0].Servers, wantServers) {
+				t.Errorf("servers = %v, want %v", adapters[0].Servers, wantServers)
+			}
+			if len(adapters[1].Servers) != 0 {
+				t.Errorf("disconnected adapter servers = %v, want none", adapters[1].Servers)
+			}
+		})
+	}
+}
+
+func TestParseNetUser(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   UserAccount
+	}{
+		{"en-US", UserAccount{
+			Name:            "Guest",
+			Comment:         "Built-in account for guest access to the computer/domain",
+			Active:          false,
+			Expires:         "Never",
+			PasswordLastSet: "3/4/2024 9:00:00 AM",
+			PasswordExpires: "Never",
+			LastLogon:       "Never",
+		}},
+		{"zh-CN", UserAccount{
+			Name:            "Guest",
+			Comment:         "供来宾访问计算机或访问域的内置帐户",
+			Active:          true,
+			Expires:         "从不",
+			PasswordLastSet: "2024/3/4 9:00:00",
+			PasswordExpires: "从不",
+			LastLogon:       "2024/3/6 21:15:42",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			account, ok := parseNetUser(readFixture(t, "net_user_guest_"+tt.locale+".txt"))
+			if !ok {
+				t.Fatal("parseNetUser failed")
+			}
+			if account != tt.want {
+				t.Errorf("got %+v\nwant %+v", account, tt.want)
+			}
+		})
+	}
+
+	if _, ok := parseNetUser("The user name could not be found.\r\n"); ok {
+		t.Error("parseNetUser accepted an error message")
+	}
+}
+
+func TestParseScQuery(t *testing.T) {
+	tests := []struct {
+		locale string
+		want   ServiceStatus
+	}{
+		{"en-US", ServiceStatus{Name: "WinDefend", State: ServiceRunning}},
+		{"zh-CN", ServiceStatus{Name: "RemoteRegistry", State: ServiceStopped}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			status, ok := parseScQuery(readFixture(t, "sc_query_"+tt.locale+".txt"))
+			if !ok {
+				t.Fatal("parseScQuery failed")
+			}
+			if status != tt.want {
+				t.Errorf("got %+v, want %+v", status, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package main
+
+// activeCodePage 非Windows平台的命令输出均为UTF-8
+func activeCodePage() int {
+	return CodePageUTF8
+}
@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package main
+
+import "golang.org/x/sys/windows"
+
+var (
+	kernel32               = windows.NewLazySystemDLL("kernel32.dll")
+	procGetConsoleOutputCP = kernel32.NewProc("GetConsoleOutputCP")
+	procGetOEMCP           = kernel32.NewProc("GetOEMCP")
+)
+
+// activeCodePage 返回子进程输出所用的代码页：
+// 有控制台时为控制台输出代码页，否则控制台程序使用OEM代码页
+func activeCodePage() int {
+	if cp, _, _ := procGetConsoleOutputCP.Call(); cp != 0 {
+		return int(cp)
+	}
+	cp, _, _ := procGetOEMCP.Call()
+	return int(cp)
+}
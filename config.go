@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 编译时嵌入的默认配置
+//
+//go:embed default_config.yaml
+var defaultConfigData []byte
+
+// Config 检测参数，可通过 -config 指定的YAML或JSON文件覆盖
+type Config struct {
+	SuspiciousPorts PortMap        `yaml:"suspicious_ports"`
+	Registry        RegistryConfig `yaml:"registry"`
+	Files           FilesConfig    `yaml:"files"`
+	Process         ProcessConfig  `yaml:"process"`
+	Auth            AuthConfig     `yaml:"auth"`
+	IIS             IISConfig      `yaml:"iis"`
+	Firewall        FirewallConfig `yaml:"firewall"`
+	MFT             MFTConfig      `yaml:"mft"`
+	USN             USNConfig      `yaml:"usn"`
+}
+
+// PortMap 端口到服务名称的映射，端口可以写成数字或字符串（JSON中的键只能是字符串）
+type PortMap map[int]string
+
+func (m *PortMap) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]string
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	ports := make(PortMap, len(raw))
+	for key, service := range raw {
+		port, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf(tr("line %d: 端口 %q 不是数字"), node.Line, key)
+		}
+		ports[port] = service
+	}
+	*m = ports
+	return nil
+}
+
+// sortedPorts 返回按端口号排序的端口列表
+func (m PortMap) sortedPorts() []int {
+	ports := make([]int, 0, len(m))
+	for port := range m {
+		ports = append(ports, port)
+	}
+	sort.Ints(ports)
+	return ports
+}
+
+// maxRegistryDepth 注册表检查递归层数的上限
+const maxRegistryDepth = 8
+
+// RegistryConfig 注册表检查参数
+type RegistryConfig struct {
+	CriticalPaths []string       `yaml:"critical_paths"`
+	MaxDepth      int            `yaml:"max_depth"`
+	PathDepths    RegistryDepths `yaml:"path_depths"`
+}
+
+// RegistryDepths 个别路径的读取层数，覆盖max_depth。文件中出现时整体替换默认值
+type RegistryDepths map[string]int
+
+func (m *RegistryDepths) UnmarshalYAML(node *yaml.Node) error {
+	var raw map[string]int
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*m = raw
+	return nil
+}
+
+// depth 返回路径的读取层数，路径不区分大小写
+func (c RegistryConfig) depth(path string) int {
+	for p, depth := range c.PathDepths {
+		if strings.EqualFold(p, path) {
+			return depth
+		}
+	}
+	return c.MaxDepth
+}
+
+// FilesConfig 文件检查参数
+type FilesConfig struct {
+	CriticalFiles  []string      `yaml:"critical_files"`
+	SuspiciousDirs []string      `yaml:"suspicious_dirs"`
+	SuspiciousExts []string      `yaml:"suspicious_exts"`
+	RecentWindow   time.Duration `yaml:"recent_window"`
+}
+
+// ProcessConfig 进程行为检查参数
+type ProcessConfig struct {
+	CPUThreshold    float64 `yaml:"cpu_threshold"`
+	MemoryThreshold float64 `yaml:"memory_threshold"`
+}
+
+// AuthConfig 安全日志中认证攻击的检测参数
+type AuthConfig struct {
+	Window                time.Duration `yaml:"window"`
+	BruteForceThreshold   int           `yaml:"brute_force_threshold"`
+	SprayAccountThreshold int           `yaml:"spray_account_threshold"`
+	SuccessAfterFailures  int           `yaml:"success_after_failures"`
+	RDPTrustedNetworks    []string      `yaml:"rdp_trusted_networks"`
+}
+
+// IISConfig IIS日志的检测参数
+type IISConfig struct {
+	Window              time.Duration `yaml:"window"`
+	ErrorBurstThreshold int           `yaml:"error_burst_threshold"`
+	RarePageHits        int           `yaml:"rare_page_hits"`
+	ScannerAgents       []string      `yaml:"scanner_agents"`
+	TopClients          int           `yaml:"top_clients"`
+}
+
+// FirewallConfig Windows防火墙日志的检测参数
+type FirewallConfig struct {
+	Window                  time.Duration `yaml:"window"`
+	PortScanThreshold       int           `yaml:"port_scan_threshold"`
+	BlockedInboundThreshold int           `yaml:"blocked_inbound_threshold"`
+	RareDestinationHits     int           `yaml:"rare_destination_hits"`
+}
+
+// MFTConfig $MFT分析参数
+type MFTConfig struct {
+	TimelineWindow time.Duration `yaml:"timeline_window"`
+}
+
+// USNConfig USN日志的检测参数
+type USNConfig struct {
+	Window         time.Duration `yaml:"window"`
+	BurstThreshold int           `yaml:"burst_threshold"`
+	TimelineWindow time.Duration `yaml:"timeline_window"`
+}
+
+// 当前生效的配置
+var config = mustParseConfig(defaultConfigData)
+
+func mustParseConfig(data []byte) Config {
+	cfg, err := parseConfig(data)
+	if err != nil {
+		panic(fmt.Sprintf("默认配置无效: %v", err))
+	}
+	if err := cfg.validate(); err != nil {
+		panic(fmt.Sprintf("默认配置无效: %v", err))
+	}
+	return cfg
+}
+
+// parseConfig 解析YAML或JSON格式的配置，不允许出现未知的配置项
+func parseConfig(data []byte) (Config, error) {
+	var cfg Config
+	err := decodeConfig(data, &cfg)
+	return cfg, err
+}
+
+// decodeConfig 将配置解码到cfg上，只替换文件中出现的项，未出现的项保持原值。
+// 出现的项即使为0也会替换，由validate检查取值
+func decodeConfig(data []byte, cfg *Config) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// loadConfig 读取配置文件并与默认配置合并，文件中出现的项整体替换默认值
+func loadConfig(path string) (Config, error) {
+	cfg := mustParseConfig(defaultConfigData)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf(tr("读取配置文件失败: %v"), err)
+	}
+	if err := decodeConfig(data, &cfg); err != nil {
+		return cfg, fmt.Errorf(tr("解析配置文件 %s 失败: %v"), path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return cfg, fmt.Errorf(tr("配置文件 %s 无效: %v"), path, err)
+	}
+	return cfg, nil
+}
+
+// validate 检查配置取值，返回所有问题
+func (c *Config) validate() error {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	for _, port := range c.SuspiciousPorts.sortedPorts() {
+		service := c.SuspiciousPorts[port]
+		if port < 1 || port > 65535 {
+			problem(tr("suspicious_ports: 端口 %d 超出范围 1-65535"), port)
+		}
+		if strings.TrimSpace(service) == "" {
+			problem(tr("suspicious_ports: 端口 %d 缺少服务名称"), port)
+		}
+	}
+	for i, path := range c.Registry.CriticalPaths {
+		if strings.TrimSpace(path) == "" {
+			problem(tr("registry.critical_paths[%d]: 路径为空"), i)
+		} else if strings.HasPrefix(strings.ToUpper(path), "HKEY_") || strings.HasPrefix(path, "\\") {
+			problem(tr("registry.critical_paths[%d]: %q 应为不含根键的相对路径"), i, path)
+		}
+	}
+	if c.Registry.MaxDepth < 1 || c.Registry.MaxDepth > maxRegistryDepth {
+		problem(tr("registry.max_depth: %d 超出范围 1-%d"), c.Registry.MaxDepth, maxRegistryDepth)
+	}
+	for _, path := range slices.Sorted(maps.Keys(c.Registry.PathDepths)) {
+		if depth := c.Registry.PathDepths[path]; depth < 1 || depth > maxRegistryDepth {
+			problem(tr("registry.path_depths[%s]: %d 超出范围 1-%d"), path, depth, maxRegistryDepth)
+		}
+	}
+	for i, file := range c.Files.CriticalFiles {
+		if strings.TrimSpace(file) == "" {
+			problem(tr("files.critical_files[%d]: 路径为空"), i)
+		}
+	}
+	for i, dir := range c.Files.SuspiciousDirs {
+		if strings.TrimSpace(dir) == "" {
+			problem(tr("files.suspicious_dirs[%d]: 路径为空"), i)
+		}
+	}
+	for i, ext := range c.Files.SuspiciousExts {
+		if !strings.HasPrefix(ext, ".") || len(ext) < 2 {
+			problem(tr("files.suspicious_exts[%d]: %q 应以点开头，如 .exe"), i, ext)
+		}
+	}
+	if c.Files.RecentWindow <= 0 {
+		problem(tr("files.recent_window: 必须大于0，如 24h"))
+	}
+	if c.Process.CPUThreshold <= 0 || c.Process.CPUThreshold > 100 {
+		problem(tr("process.cpu_threshold: %v 超出范围 (0, 100]"), c.Process.CPUThreshold)
+	}
+	if c.Process.MemoryThreshold <= 0 || c.Process.MemoryThreshold > 100 {
+		problem(tr("process.memory_threshold: %v 超出范围 (0, 100]"), c.Process.MemoryThreshold)
+	}
+	if c.Auth.Window <= 0 {
+		problem(tr("auth.window: 必须大于0，如 10m"))
+	}
+	if c.Auth.BruteForceThreshold < 2 {
+		problem(tr("auth.brute_force_threshold: %d 应不小于2"), c.Auth.BruteForceThreshold)
+	}
+	if c.Auth.SprayAccountThreshold < 2 {
+		problem(tr("auth.spray_account_threshold: %d 应不小于2"), c.Auth.SprayAccountThreshold)
+	}
+	if c.Auth.SuccessAfterFailures < 1 {
+		problem(tr("auth.success_after_failures: %d 应不小于1"), c.Auth.SuccessAfterFailures)
+	}
+	for i, network := range c.Auth.RDPTrustedNetworks {
+		if _, err := parseNetwork(network); err != nil {
+			problem(tr("auth.rdp_trusted_networks[%d]: %q 不是有效的IP地址或网段"), i, network)
+		}
+	}
+	if c.IIS.Window <= 0 {
+		problem(tr("iis.window: 必须大于0，如 5m"))
+	}
+	if c.IIS.ErrorBurstThreshold < 2 {
+		problem(tr("iis.error_burst_threshold: %d 应不小于2"), c.IIS.ErrorBurstThreshold)
+	}
+	if c.IIS.RarePageHits < 1 {
+		problem(tr("iis.rare_page_hits: %d 应不小于1"), c.IIS.RarePageHits)
+	}
+	for i, agent := range c.IIS.ScannerAgents {
+		if strings.TrimSpace(agent) == "" {
+			problem(tr("iis.scanner_agents[%d]: 关键字为空"), i)
+		}
+	}
+	if c.IIS.TopClients < 1 {
+		problem(tr("iis.top_clients: %d 应不小于1"), c.IIS.TopClients)
+	}
+	if c.Firewall.Window <= 0 {
+		problem(tr("firewall.window: 必须大于0，如 5m"))
+	}
+	if c.Firewall.PortScanThreshold < 2 {
+		problem(tr("firewall.port_scan_threshold: %d 应不小于2"), c.Firewall.PortScanThreshold)
+	}
+	if c.Firewall.BlockedInboundThreshold < 1 {
+		problem(tr("firewall.blocked_inbound_threshold: %d 应不小于1"), c.Firewall.BlockedInboundThreshold)
+	}
+	if c.Firewall.RareDestinationHits < 1 {
+		problem(tr("firewall.rare_destination_hits: %d 应不小于1"), c.Firewall.RareDestinationHits)
+	}
+	if c.MFT.TimelineWindow <= 0 {
+		problem(tr("mft.timeline_window: 必须大于0，如 72h"))
+	}
+	if c.USN.Window <= 0 {
+		problem(tr("usn.window: 必须大于0，如 1m"))
+	}
+	if c.USN.BurstThreshold < 2 {
+		problem(tr("usn.burst_threshold: %d 应不小于2"), c.USN.BurstThreshold)
+	}
+	if c.USN.TimelineWindow <= 0 {
+		problem(tr("usn.timeline_window: 必须大于0，如 72h"))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("\n  " + strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// parseNetwork 解析CIDR网段，单个IP地址视为只包含该地址的网段
+func parseNetwork(s string) (*net.IPNet, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(s)
+	return network, err
+}
+
+// Windows风格的环境变量引用，如 %TEMP%
+var windowsEnvVar = regexp.MustCompile(`%([A-Za-z0-9_()]+)%`)
+
+// expandPath 展开路径中 %VAR% 形式的环境变量，变量未定义时返回空字符串
+func expandPath(path string) string {
+	undefined := false
+	path = windowsEnvVar.ReplaceAllStringFunc(path, func(ref string) string {
+		value, ok := os.LookupEnv(ref[1 : len(ref)-1])
+		if !ok || value == "" {
+			undefined = true
+		}
+		return value
+	})
+	if undefined {
+		return ""
+	}
+	return path
+}
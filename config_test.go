@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	defaults := mustParseConfig(defaultConfigData)
+
+	cfg, err := loadConfig(write("partial.yaml", "auth:\n  window: 30m\nregistry:\n  critical_paths: [SOFTWARE\\Test]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Auth.Window != 30*time.Minute || cfg.Auth.BruteForceThreshold != defaults.Auth.BruteForceThreshold {
+		t.Errorf("auth = %+v", cfg.Auth)
+	}
+	if !reflect.DeepEqual(cfg.Registry.CriticalPaths, []string{`SOFTWARE\Test`}) || cfg.Registry.MaxDepth != defaults.Registry.MaxDepth {
+		t.Errorf("registry = %+v", cfg.Registry)
+	}
+	if !reflect.DeepEqual(cfg.SuspiciousPorts, defaults.SuspiciousPorts) {
+		t.Errorf("suspicious ports changed: %v", cfg.SuspiciousPorts)
+	}
+
+	// 显式写出的0不能被当作未设置而保留默认值
+	_, err = loadConfig(write("zero.yaml", `{"auth": {"brute_force_threshold": 0}, "usn": {"window": "0s"}}`))
+	if err == nil || !strings.Contains(err.Error(), "auth.brute_force_threshold") || !strings.Contains(err.Error(), "usn.window") {
+		t.Errorf("explicit zeros: err = %v", err)
+	}
+}
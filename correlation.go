@@ -0,0 +1,250 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// executionSource 一种执行痕迹的读取方法
+type executionSource struct {
+	name    string
+	collect func(ctx context.Context) ([]executionArtifact, error)
+}
+
+var executionSources = []executionSource{
+	{artifactPrefetch, collectPrefetchArtifacts},
+	{artifactShimcache, collectShimcacheArtifacts},
+	{artifactAmcache, collectAmcacheArtifacts},
+	{"BAM/DAM", collectBAMArtifacts},
+	{artifactUserAssist, collectUserAssistArtifacts},
+	{artifactProcessCreation, collectProcessCreationArtifacts},
+}
+
+func collectPrefetchArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	dir := prefetchSourceDir()
+	if dir == "" {
+		return nil, errors.New(tr("未指定Prefetch目录 (-prefetch-dir)"))
+	}
+	files, _, err := loadPrefetch(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []executionArtifact
+	for _, p := range files {
+		path := p.Path
+		if path == "" {
+			path = p.Executable
+		}
+		artifacts = append(artifacts, executionArtifact{Source: artifactPrefetch, Path: path, Times: p.RunTimes,
+			RunCount: int(p.RunCount), Serial: p.volumeSerial(path)})
+	}
+	return artifacts, nil
+}
+
+// volumeSerial 返回路径所在卷的序列号，路径不在Prefetch记录的卷上时为0
+func (p prefetchFile) volumeSerial(path string) uint32 {
+	for _, v := range p.Volumes {
+		if v.Device != "" && len(path) > len(v.Device) && strings.EqualFold(path[:len(v.Device)], v.Device) {
+			return v.Serial
+		}
+	}
+	return 0
+}
+
+// collectShimcacheArtifacts Shimcache中的时间是文件修改时间，不作为运行时间
+func collectShimcacheArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	entries, _, err := readShimcache()
+	if err != nil && len(entries) == 0 {
+		return nil, err
+	}
+	var artifacts []executionArtifact
+	for _, entry := range entries {
+		artifacts = append(artifacts, executionArtifact{Source: artifactShimcache, Path: entry.Path})
+	}
+	return artifacts, nil
+}
+
+// collectAmcacheArtifacts 系统组件不参与关联
+func collectAmcacheArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	entries, _, err := readAmcache(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []executionArtifact
+	for _, entry := range entries {
+		if entry.OSComponent {
+			continue
+		}
+		artifacts = append(artifacts, executionArtifact{Source: artifactAmcache, Path: entry.Path, SHA1: entry.SHA1, Times: nonZeroTimes(entry.FirstSeen)})
+	}
+	return artifacts, nil
+}
+
+func collectBAMArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	entries, err := readBAM(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []executionArtifact
+	for _, entry := range entries {
+		user := entry.SID
+		if name, ok := wellKnownSIDs[user]; ok {
+			user = name
+		}
+		artifacts = append(artifacts, executionArtifact{Source: entry.Source, Path: entry.Path, Times: nonZeroTimes(entry.LastRun), User: user})
+	}
+	return artifacts, nil
+}
+
+// collectUserAssistArtifacts 离线分析时无法得知NTUSER.DAT属于哪个用户
+func collectUserAssistArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	entries, err := readUserAssist()
+	if err != nil {
+		return nil, err
+	}
+	user := os.Getenv("USERNAME")
+	if registryHiveDir != "" {
+		user = "NTUSER.DAT"
+	}
+	var artifacts []executionArtifact
+	for _, entry := range entries {
+		artifacts = append(artifacts, executionArtifact{Source: artifactUserAssist, Path: entry.Path,
+			Times: nonZeroTimes(entry.LastRun), RunCount: int(entry.RunCount), User: user})
+	}
+	return artifacts, nil
+}
+
+// collectProcessCreationArtifacts 安全日志中的4688进程创建事件，每个事件计一次运行
+func collectProcessCreationArtifacts(ctx context.Context) ([]executionArtifact, error) {
+	events, _, err := readEventLog(ctx, SecurityLog, 4688)
+	if err != nil {
+		return nil, err
+	}
+	var artifacts []executionArtifact
+	for _, event := range events {
+		path := event.Data["NewProcessName"]
+		if path == "" {
+			continue
+		}
+		user := event.Data["SubjectUserName"]
+		if domain := event.Data["SubjectDomainName"]; domain != "" && user != "" {
+			user = domain + `\` + user
+		}
+		artifacts = append(artifacts, executionArtifact{Source: artifactProcessCreation, Path: path,
+			Times: nonZeroTimes(event.TimeStamp), RunCount: 1, User: user})
+	}
+	return artifacts, nil
+}
+
+// loadVolumeMap 读取卷与盘符的对应关系：SYSTEM hive的MountedDevices中卷GUID对应的盘符，
+// 分析本机时还有各盘符的设备路径、卷GUID和序列号
+func loadVolumeMap() *volumeMap {
+	volumes := newVolumeMap()
+	readMountedDevices(volumes)
+	if registryHiveDir == "" && prefetchDir == "" {
+		addLocalVolumes(volumes)
+	}
+	return volumes
+}
+
+// readMountedDevices 数据相同的\DosDevices\C:和\??\Volume{GUID}为同一个卷
+func readMountedDevices(volumes *volumeMap) {
+	key, err := openRegistryKey(hkeyLocalMachine, `SYSTEM\MountedDevices`)
+	if err != nil {
+		return
+	}
+	defer key.Close()
+	names, err := key.ReadValueNames()
+	if err != nil {
+		return
+	}
+	letters := make(map[string]string)
+	guids := make(map[string]string)
+	for _, name := range names {
+		_, data, err := key.GetValue(name)
+		if err != nil {
+			continue
+		}
+		if letter, ok := strings.CutPrefix(name, `\DosDevices\`); ok && hasDriveLetter(letter) {
+			letters[string(data)] = letter
+		} else if _, ok := volumeGUID(name); ok {
+			guids[name] = string(data)
+		}
+	}
+	for name, data := range guids {
+		if letter, ok := letters[data]; ok {
+			volumes.addDevice(name, letter)
+		}
+	}
+}
+
+func nonZeroTimes(t time.Time) []time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return []time.Time{t}
+}
+
+// checkExecutionCorrelation 汇总各种执行痕迹，按程序合并为执行历史，在报告中单独成节，
+// 并报告只出现在用户可写目录中、或只出现在一种痕迹中的程序
+func checkExecutionCorrelation(ctx context.Context, results *[]CheckResult) {
+	category := tr("执行证据关联")
+
+	var artifacts []executionArtifact
+	var status []string
+	for _, source := range executionSources {
+		if ctx.Err() != nil {
+			return
+		}
+		collected, err := source.collect(ctx)
+		if err != nil {
+			status = append(status, fmt.Sprintf(tr("%s: 不可用 (%v)"), source.name, err))
+			continue
+		}
+		status = append(status, fmt.Sprintf(tr("%s: %d 条"), source.name, len(collected)))
+		artifacts = append(artifacts, collected...)
+	}
+
+	records := correlateExecutions(artifacts, loadVolumeMap())
+
+	var single []string
+	for _, record := range records {
+		if record.UserWritableOnly {
+			addCheckResult(results, category, fmt.Sprintf(tr("只出现在用户可写目录中的程序: %s"), record.Path()),
+				SeverityWarning, StatusAbnormal, record.details(), record.Paths...)
+			continue
+		}
+		if record.SingleSource() {
+			single = append(single, fmt.Sprintf("%s [%s]", record.Path(), record.Sources[0]))
+		}
+	}
+	if len(single) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("只出现在一种执行痕迹中的程序: %d 个"), len(single)), SeverityInfo, StatusAbnormal,
+			tr("只有一种来源的记录可能是痕迹被清除，也可能只是该来源记录范围更广（如Shimcache中的条目不一定运行过）\n"), single...)
+	}
+
+	addAttachedResult(results, category, fmt.Sprintf(tr("执行历史: %d 个程序"), len(records)), SeverityInfo, StatusOK,
+		strings.Join(status, "\n")+"\n"+tr("完整的执行历史见报告中的“执行证据关联”一节\n"), records)
+}
+
+// details 程序的时间范围、运行次数、用户和来源
+func (r executionRecord) details() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("时间范围: %s 至 %s\n"), r.FirstSeenText(), r.LastSeenText())
+	fmt.Fprintf(&b, tr("运行次数: %d\n"), r.RunCount)
+	if len(r.Users) > 0 {
+		fmt.Fprintf(&b, tr("用户: %s\n"), strings.Join(r.Users, ", "))
+	}
+	fmt.Fprintf(&b, tr("来源: %s\n"), strings.Join(r.Sources, ", "))
+	if len(r.SHA1) > 0 {
+		fmt.Fprintf(&b, "SHA1: %s\n", strings.Join(r.SHA1, ", "))
+	}
+	return b.String()
+}
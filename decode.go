@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/encoding/unicode"
+)
+
+// 常用代码页
+const (
+	CodePageUTF16LE = 1200
+	CodePageUTF16BE = 1201
+	CodePageUTF8    = 65001
+)
+
+// Windows代码页与编码的对应关系
+var codePages = map[int]encoding.Encoding{
+	437:             charmap.CodePage437,
+	850:             charmap.CodePage850,
+	852:             charmap.CodePage852,
+	855:             charmap.CodePage855,
+	858:             charmap.CodePage858,
+	860:             charmap.CodePage860,
+	862:             charmap.CodePage862,
+	863:             charmap.CodePage863,
+	865:             charmap.CodePage865,
+	866:             charmap.CodePage866,
+	874:             charmap.Windows874,
+	932:             japanese.ShiftJIS,
+	936:             simplifiedchinese.GBK,
+	949:             korean.EUCKR,
+	950:             traditionalchinese.Big5,
+	1250:            charmap.Windows1250,
+	1251:            charmap.Windows1251,
+	1252:            charmap.Windows1252,
+	1253:            charmap.Windows1253,
+	1254:            charmap.Windows1254,
+	1255:            charmap.Windows1255,
+	1256:            charmap.Windows1256,
+	1257:            charmap.Windows1257,
+	1258:            charmap.Windows1258,
+	20936:           simplifiedchinese.HZGB2312,
+	54936:           simplifiedchinese.GB18030,
+	CodePageUTF16LE: unicode.UTF16(unicode.LittleEndian, unicode.IgnoreBOM),
+	CodePageUTF16BE: unicode.UTF16(unicode.BigEndian, unicode.IgnoreBOM),
+	CodePageUTF8:    unicode.UTF8,
+}
+
+// 通过 -codepage 参数强制指定的代码页，0表示自动检测
+var forcedCodePage int
+
+// setForcedCodePage 设置强制使用的代码页，0表示恢复自动检测
+func setForcedCodePage(codePage int) error {
+	if codePage != 0 {
+		if _, ok := codePages[codePage]; !ok {
+			return fmt.Errorf(tr("不支持的代码页: %d"), codePage)
+		}
+	}
+	forcedCodePage = codePage
+	return nil
+}
+
+// 字节序标记
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// looksLikeUTF16LE 判断无BOM的数据是否为UTF-16LE：
+// 以ASCII字符为主的UTF-16LE文本中，奇数位置的字节大多为0
+func looksLikeUTF16LE(data []byte) bool {
+	if len(data) < 4 || len(data)%2 != 0 {
+		return false
+	}
+	zeros := 0
+	for i := 1; i < len(data); i += 2 {
+		if data[i] == 0 {
+			zeros++
+		}
+	}
+	return zeros*2 >= len(data)/2
+}
+
+// detectCodePage 确定数据使用的代码页，返回去除BOM后的数据。
+// 优先级: BOM > 无BOM的UTF-16LE > -codepage参数 > 命令执行时的活动代码页 > 合法的UTF-8。
+// 较短的GBK、Big5输出也可能恰好是合法的UTF-8，因此只在活动代码页未知（0）时按内容判断
+func detectCodePage(data []byte, activeCodePage int) (int, []byte) {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return CodePageUTF8, data[len(bomUTF8):]
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return CodePageUTF16LE, data[len(bomUTF16LE):]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return CodePageUTF16BE, data[len(bomUTF16BE):]
+	case looksLikeUTF16LE(data):
+		return CodePageUTF16LE, data
+	case forcedCodePage != 0:
+		return forcedCodePage, data
+	case activeCodePage == 0 && utf8.Valid(data):
+		return CodePageUTF8, data
+	}
+	return activeCodePage, data
+}
+
+// decodeOutput 将外部命令的输出转换为UTF-8字符串，
+// activeCodePage为命令执行时控制台的代码页，未知时传0
+func decodeOutput(data []byte, activeCodePage int) string {
+	codePage, data := detectCodePage(data, activeCodePage)
+	if codePage == CodePageUTF8 {
+		return string(data)
+	}
+
+	enc, ok := codePages[codePage]
+	if !ok {
+		// 未知代码页时保留原始数据
+		return string(data)
+	}
+	decoded, err := enc.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data) // 如果转换失败，返回原始数据
+	}
+	return string(decoded)
+}
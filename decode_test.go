@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDetectCodePage(t *testing.T) {
+	utf16 := []byte{'o', 0, 'k', 0, ' ', 0, '!', 0}
+	gbk := []byte("\xd5\xfd\xb3\xa3") // GBK "正常"
+	ambiguous := []byte("\xc2\xa3")   // GBK "拢"，同时也是合法的UTF-8 "£"
+	tests := []struct {
+		name   string
+		data   []byte
+		forced int
+		active int
+		want   int
+		rest   []byte
+	}{
+		{"UTF-8 BOM", append([]byte{0xEF, 0xBB, 0xBF}, "正常"...), 936, 936, CodePageUTF8, []byte("正常")},
+		{"UTF-16LE BOM", append([]byte{0xFF, 0xFE}, utf16...), 936, 936, CodePageUTF16LE, utf16},
+		{"UTF-16BE BOM", []byte{0xFE, 0xFF, 0, 'o', 0, 'k'}, 936, 936, CodePageUTF16BE, []byte{0, 'o', 0, 'k'}},
+		{"UTF-16LE without BOM beats -codepage", utf16, 936, 936, CodePageUTF16LE, utf16},
+		{"-codepage beats valid UTF-8", []byte("正常"), 950, CodePageUTF8, 950, []byte("正常")},
+		{"active code page beats valid UTF-8", ambiguous, 0, 936, 936, ambiguous},
+		{"active UTF-8 code page", []byte("正常"), 0, CodePageUTF8, CodePageUTF8, []byte("正常")},
+		{"GBK with active code page", gbk, 0, 936, 936, gbk},
+		{"valid UTF-8 with unknown code page", ambiguous, 0, 0, CodePageUTF8, ambiguous},
+		{"invalid UTF-8 with unknown code page", gbk, 0, 0, 0, gbk},
+		{"-codepage beats active code page", gbk, 950, 936, 950, gbk},
+	}
+	defer setForcedCodePage(0)
+	for _, tt := range tests {
+		if err := setForcedCodePage(tt.forced); err != nil {
+			t.Fatal(err)
+		}
+		got, rest := detectCodePage(tt.data, tt.active)
+		if got != tt.want || !bytes.Equal(rest, tt.rest) {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, got, rest, tt.want, tt.rest)
+		}
+	}
+}
+
+func TestDecodeOutput(t *testing.T) {
+	defer setForcedCodePage(0)
+	tests := []struct {
+		data   []byte
+		active int
+		want   string
+	}{
+		{[]byte("\xd5\xfd\xb3\xa3"), 936, "正常"},
+		{[]byte("\xc2\xa3"), 936, "拢"},
+		{[]byte("\xc2\xa3"), 0, "£"},
+		{[]byte{0xFF, 0xFE, 'o', 0, 'k', 0}, 936, "ok"},
+		{[]byte("正常"), CodePageUTF8, "正常"},
+		{[]byte("\xd5\xfd"), 12345, "\xd5\xfd"}, // 未知代码页保留原始数据
+	}
+	for _, tt := range tests {
+		if got := decodeOutput(tt.data, tt.active); got != tt.want {
+			t.Errorf("decodeOutput(%q, %d) = %q, want %q", tt.data, tt.active, got, tt.want)
+		}
+	}
+	if err := setForcedCodePage(12345); err == nil {
+		t.Error("setForcedCodePage accepted an unknown code page")
+	}
+}
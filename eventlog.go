@@ -0,0 +1,202 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 事件日志通道
+const (
+	SystemLog                = "System"
+	ApplicationLog           = "Application"
+	SecurityLog              = "Security"
+	PowerShellLog            = "Windows PowerShell"
+	PowerShellOperationalLog = "Microsoft-Windows-PowerShell/Operational"
+	SysmonLog                = "Microsoft-Windows-Sysmon/Operational"
+)
+
+// eventLogDir 离线事件日志所在的目录，由 -evtx-dir 指定，为空时读取本机日志
+var eventLogDir string
+
+// 每个分析项在证据中列出的最近事件数
+const maxEventEvidence = 10
+
+// eventLogFileName 返回通道对应的日志文件名，通道名中的"/"在文件名中记为"%4"
+func eventLogFileName(channel string) string {
+	return strings.ReplaceAll(channel, "/", "%4") + ".evtx"
+}
+
+// readEventLog 读取通道中指定ID的事件，eventIDs为空时返回全部事件，同时返回损坏而跳过的记录数
+func readEventLog(ctx context.Context, channel string, eventIDs ...uint32) ([]LogAnalysis, int, error) {
+	path := filepath.Join(eventLogDir, eventLogFileName(channel))
+	if eventLogDir == "" {
+		exported, cleanup, err := exportEventLog(ctx, channel)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer cleanup()
+		path = exported
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	var events []LogAnalysis
+	damaged, err := parseEvtx(ctx, bufio.NewReaderSize(file, evtxChunkSize), func(event LogAnalysis) {
+		if len(eventIDs) == 0 || containsEventID(eventIDs, event.EventID) {
+			events = append(events, event)
+		}
+	})
+	if err != nil {
+		return nil, damaged, fmt.Errorf("%s: %v", path, err)
+	}
+	return events, damaged, nil
+}
+
+func containsEventID(eventIDs []uint32, id uint32) bool {
+	for _, eventID := range eventIDs {
+		if eventID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// loadEventLog 读取通道中的事件，失败时记录采集失败的结果并返回false。
+// 通道不存在（如未安装Sysmon）时记为跳过
+func loadEventLog(ctx context.Context, results *[]CheckResult, category, channel string, eventIDs ...uint32) ([]LogAnalysis, bool) {
+	events, damaged, err := readEventLog(ctx, channel, eventIDs...)
+	if isNotPresent(err) {
+		addAbsentResult(results, category, fmt.Sprintf(tr("%s日志不存在"), channel), fmt.Sprintf(tr("错误: %v"), err))
+		return nil, false
+	}
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取%s日志失败"), channel), err)
+		return nil, false
+	}
+	if damaged > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("%s日志中有%d条记录损坏，已跳过"), channel, damaged),
+			SeverityInfo, StatusFailed, "")
+	}
+	return events, true
+}
+
+// formatEvent 返回事件的单行摘要，用作证据
+func formatEvent(event LogAnalysis) string {
+	message := event.Message
+	if runes := []rune(message); len(runes) > 300 {
+		message = string(runes[:300]) + "..."
+	}
+	return fmt.Sprintf("%s [%d] %s %s", event.TimeStamp.Local().Format("2006-01-02 15:04:05"),
+		event.EventID, event.Source, message)
+}
+
+// analyzeEventLog 统计指定事件ID的数量，并列出最近的事件
+func analyzeEventLog(results *[]CheckResult, category, description string, events []LogAnalysis, eventIDs []uint32) {
+	counts := make(map[uint32]int)
+	var matched []LogAnalysis
+	for _, event := range events {
+		if containsEventID(eventIDs, event.EventID) {
+			counts[event.EventID]++
+			matched = append(matched, event)
+		}
+	}
+	if len(matched) == 0 {
+		addCheckResult(results, category, description, SeverityInfo, StatusOK, tr("未发现相关事件"))
+		return
+	}
+
+	var details strings.Builder
+	for _, id := range eventIDs {
+		if counts[id] > 0 {
+			fmt.Fprintf(&details, tr("事件ID %d: %d 条\n"), id, counts[id])
+		}
+	}
+	if len(matched) > maxEventEvidence {
+		matched = matched[len(matched)-maxEventEvidence:]
+	}
+	var evidence []string
+	for _, event := range matched {
+		evidence = append(evidence, formatEvent(event))
+	}
+	addCheckResult(results, category, description, SeverityInfo, StatusOK, details.String(), evidence...)
+}
+
+// 分析系统日志
+func analyzeSystemLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("系统日志分析")
+	events, ok := loadEventLog(ctx, results, category, SystemLog)
+	if !ok {
+		return
+	}
+
+	// 分析系统启动和关机事件
+	analyzeEventLog(results, category, tr("系统启动和关机事件"), events, []uint32{6005, 6006, 6008, 6013})
+
+	// 分析系统错误和警告
+	analyzeEventLog(results, category, tr("系统错误和警告"), events, []uint32{1001, 1002, 1003, 1004, 1005, 1006})
+
+	// 分析驱动程序错误
+	analyzeEventLog(results, category, tr("驱动程序错误"), events, []uint32{219, 7000, 7001, 7022, 7023, 7024, 7026, 7034, 7035, 7045})
+}
+
+// 分析安全日志
+func analyzeSecurityLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("安全日志分析")
+	events, ok := loadEventLog(ctx, results, category, SecurityLog)
+	if !ok {
+		return
+	}
+
+	// 分析登录事件
+	analyzeEventLog(results, category, tr("登录事件分析"), events, []uint32{4624, 4625, 4634, 4647, 4672})
+
+	// 分析账户管理
+	analyzeEventLog(results, category, tr("账户管理事件"), events, []uint32{4720, 4722, 4724, 4725, 4726, 4728, 4732, 4735, 4740, 4756})
+
+	// 分析策略更改
+	analyzeEventLog(results, category, tr("策略更改事件"), events, []uint32{4739, 4902, 4904, 4905, 4906, 4907, 4908, 4912})
+
+	// 检测暴力破解、密码喷洒、异常RDP登录等认证攻击
+	detectAuthAttacks(results, category, events)
+}
+
+// 分析应用程序日志
+func analyzeApplicationLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("应用程序日志分析")
+	events, ok := loadEventLog(ctx, results, category, ApplicationLog)
+	if !ok {
+		return
+	}
+
+	// 分析应用程序错误
+	analyzeEventLog(results, category, tr("应用程序错误"), events, []uint32{1000, 1001, 1002})
+
+	// 分析服务启动失败
+	analyzeEventLog(results, category, tr("服务启动失败"), events, []uint32{7000, 7001, 7022, 7023, 7024, 7026, 7031, 7034})
+}
+
+// 分析PowerShell日志
+func analyzePowerShellLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("PowerShell日志分析")
+
+	// 分析PowerShell执行策略更改，记录在经典的Windows PowerShell日志中
+	if events, ok := loadEventLog(ctx, results, category, PowerShellLog); ok {
+		analyzeEventLog(results, category, tr("执行策略更改"), events, []uint32{400, 403, 800})
+	}
+
+	// 分析脚本执行，脚本块日志记录在PowerShell的Operational日志中
+	if events, ok := loadEventLog(ctx, results, category, PowerShellOperationalLog); ok {
+		analyzeEventLog(results, category, tr("脚本执行记录"), events, []uint32{4100, 4104})
+	}
+}
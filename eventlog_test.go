@@ -0,0 +1,35 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadEventLogMissingChannel(t *testing.T) {
+	saved := eventLogDir
+	eventLogDir = t.TempDir()
+	defer func() { eventLogDir = saved }()
+
+	// 未安装Sysmon时离线目录中没有对应的日志文件，记为跳过而不是采集失败
+	var results []CheckResult
+	if _, ok := loadEventLog(context.Background(), &results, "test", SysmonLog); ok {
+		t.Fatal("loadEventLog succeeded without a log file")
+	}
+	if len(results) != 1 || results[0].Status != StatusSkipped || results[0].Severity != SeverityInfo {
+		t.Fatalf("results = %+v", results)
+	}
+
+	if err := os.WriteFile(filepath.Join(eventLogDir, eventLogFileName(SecurityLog)), []byte("not an evtx file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results = nil
+	loadEventLog(context.Background(), &results, "test", SecurityLog)
+	if len(results) != 1 || results[0].Status != StatusFailed {
+		t.Errorf("damaged log results = %+v", results)
+	}
+}
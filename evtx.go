@@ -0,0 +1,844 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// EVTX文件由4096字节的文件头和若干64KB的块组成，每个块有512字节的块头，之后是事件记录
+const (
+	evtxFileHeaderSize   = 4096
+	evtxChunkSize        = 65536
+	evtxChunkHeaderSize  = 512
+	evtxRecordHeaderSize = 24
+	evtxTimeFormat       = "2006-01-02T15:04:05.0000000Z"
+	// 模板或嵌套二进制XML的最大嵌套层数，防止损坏的数据导致无限递归
+	binxmlMaxDepth = 16
+)
+
+var (
+	evtxFileMagic   = []byte("ElfFile\x00")
+	evtxChunkMagic  = []byte("ElfChnk\x00")
+	evtxRecordMagic = []byte("**\x00\x00")
+)
+
+// 二进制XML标记，带0x40标志的变体表示元素有属性或之后还有属性
+const (
+	binxmlEOF            = 0x00
+	binxmlOpenStart      = 0x01
+	binxmlCloseStart     = 0x02
+	binxmlCloseEmpty     = 0x03
+	binxmlEndElement     = 0x04
+	binxmlValueText      = 0x05
+	binxmlAttribute      = 0x06
+	binxmlCDATA          = 0x07
+	binxmlCharRef        = 0x08
+	binxmlEntityRef      = 0x09
+	binxmlPITarget       = 0x0a
+	binxmlPIData         = 0x0b
+	binxmlTemplate       = 0x0c
+	binxmlSubstitution   = 0x0d
+	binxmlOptionalSubst  = 0x0e
+	binxmlFragmentHeader = 0x0f
+	binxmlHasMore        = 0x40
+)
+
+// 替换值的类型，带0x80标志的为对应类型的数组
+const (
+	evtNull       = 0x00
+	evtString     = 0x01
+	evtAnsiString = 0x02
+	evtInt8       = 0x03
+	evtUInt8      = 0x04
+	evtInt16      = 0x05
+	evtUInt16     = 0x06
+	evtInt32      = 0x07
+	evtUInt32     = 0x08
+	evtInt64      = 0x09
+	evtUInt64     = 0x0a
+	evtReal32     = 0x0b
+	evtReal64     = 0x0c
+	evtBool       = 0x0d
+	evtBinary     = 0x0e
+	evtGUID       = 0x0f
+	evtSizeT      = 0x10
+	evtFileTime   = 0x11
+	evtSysTime    = 0x12
+	evtSID        = 0x13
+	evtHexInt32   = 0x14
+	evtHexInt64   = 0x15
+	evtBinXML     = 0x21
+	evtArray      = 0x80
+)
+
+// 日志分析结果结构，对应一条事件记录
+type LogAnalysis struct {
+	Source    string // 事件提供程序
+	EventID   uint32
+	Level     uint16
+	TimeStamp time.Time
+	// 事件数据的"名称=值"摘要，离线分析时没有消息DLL，无法还原完整的事件描述
+	Message  string
+	RecordID uint64
+	Channel  string
+	Computer string
+	// EventData/UserData中的字段，未命名的Data元素合并到"Data"字段
+	Data map[string]string
+}
+
+// parseEvtx 依次解析EVTX文件中的事件记录并交给fn处理，返回因损坏而跳过的记录数。
+// 不校验校验和，以便分析未正常关闭或从运行中的系统复制的日志
+func parseEvtx(ctx context.Context, r io.Reader, fn func(LogAnalysis)) (int, error) {
+	header := make([]byte, evtxFileHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.HasPrefix(header, evtxFileMagic) {
+		return 0, errors.New(tr("不是有效的EVTX文件"))
+	}
+
+	damaged := 0
+	chunk := make([]byte, evtxChunkSize)
+	for {
+		if err := ctx.Err(); err != nil {
+			return damaged, err
+		}
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			// 文件末尾不完整的块无法解析，直接忽略
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return damaged, nil
+			}
+			return damaged, err
+		}
+		// 未使用的块全部为0
+		if !bytes.HasPrefix(chunk, evtxChunkMagic) {
+			continue
+		}
+		damaged += parseEvtxChunk(chunk, fn)
+	}
+}
+
+// evtxChunk 一个块的数据。名称和模板定义通过块内偏移引用，解析结果在块内缓存
+type evtxChunk struct {
+	data      []byte
+	names     map[uint32]string
+	templates map[uint32][]*binxmlNode
+}
+
+// parseEvtxChunk 解析块中的事件记录，返回损坏的记录数
+func parseEvtxChunk(data []byte, fn func(LogAnalysis)) int {
+	c := &evtxChunk{data: data, names: make(map[uint32]string), templates: make(map[uint32][]*binxmlNode)}
+
+	// 块头中的空闲空间偏移即最后一条记录的结束位置，未正常关闭的日志中可能不准确
+	end := int(binary.LittleEndian.Uint32(data[48:]))
+	if end < evtxChunkHeaderSize || end > len(data) {
+		end = len(data)
+	}
+
+	damaged := 0
+	for off := evtxChunkHeaderSize; off+evtxRecordHeaderSize <= end; {
+		if !bytes.Equal(data[off:off+4], evtxRecordMagic) {
+			break
+		}
+		size := int(binary.LittleEndian.Uint32(data[off+4:]))
+		if size < evtxRecordHeaderSize+4 || off+size > len(data) {
+			damaged++
+			break
+		}
+		event, err := c.parseRecord(off, size)
+		if err != nil {
+			damaged++
+		} else {
+			fn(event)
+		}
+		off += size
+	}
+	return damaged
+}
+
+// parseRecord 解析偏移off处长度为size的事件记录
+func (c *evtxChunk) parseRecord(off, size int) (LogAnalysis, error) {
+	recordID := binary.LittleEndian.Uint64(c.data[off+8:])
+	written := filetimeToTime(binary.LittleEndian.Uint64(c.data[off+16:]))
+
+	p := &binxmlParser{chunk: c, pos: off + evtxRecordHeaderSize, end: off + size - 4}
+	nodes := p.fragment()
+	if p.err != nil {
+		return LogAnalysis{}, p.err
+	}
+	elements, err := c.instantiate(nodes, nil, 0)
+	if err != nil {
+		return LogAnalysis{}, err
+	}
+	for _, e := range elements {
+		if e.name == "Event" {
+			return newLogAnalysis(e, recordID, written), nil
+		}
+	}
+	return LogAnalysis{}, errors.New(tr("事件记录中没有Event元素"))
+}
+
+// template 返回偏移off处的模板定义，模板头为4字节下一模板偏移、16字节GUID和4字节数据长度
+func (c *evtxChunk) template(off uint32, depth int) ([]*binxmlNode, error) {
+	if nodes, ok := c.templates[off]; ok {
+		return nodes, nil
+	}
+	start := int(off) + 24
+	if depth > binxmlMaxDepth || start > len(c.data) {
+		return nil, errors.New(tr("二进制XML数据损坏"))
+	}
+	end := start + int(binary.LittleEndian.Uint32(c.data[start-4:]))
+	if end > len(c.data) {
+		return nil, errors.New(tr("二进制XML数据损坏"))
+	}
+
+	p := &binxmlParser{chunk: c, pos: start, end: end, depth: depth}
+	nodes := p.fragment()
+	if p.err != nil {
+		return nil, p.err
+	}
+	c.templates[off] = nodes
+	return nodes, nil
+}
+
+// name 返回偏移off处的名称，名称结构为4字节下一名称偏移、2字节哈希、2字节字符数和UTF-16字符串
+func (c *evtxChunk) name(off uint32) (string, int, error) {
+	start := int(off) + 8
+	if start > len(c.data) {
+		return "", 0, errors.New(tr("二进制XML数据损坏"))
+	}
+	end := start + int(binary.LittleEndian.Uint16(c.data[start-2:]))*2
+	if end+2 > len(c.data) {
+		return "", 0, errors.New(tr("二进制XML数据损坏"))
+	}
+	name := utf16String(c.data[start:end])
+	c.names[off] = name
+	return name, end + 2 - int(off), nil
+}
+
+// binxmlNode 二进制XML解析出的节点。模板中的替换项在实例化时由记录中的值填充
+type binxmlNode struct {
+	kind     int
+	name     string
+	attrs    []binxmlAttr
+	children []*binxmlNode
+	text     string
+	index    int  // 替换项序号
+	optional bool // 可选替换项，值为空时不输出所在的元素或属性
+
+	// 模板实例的定义和值
+	template []*binxmlNode
+	values   []binxmlValue
+}
+
+// 节点类型
+const (
+	binxmlElementNode = iota
+	binxmlTextNode
+	binxmlSubstNode
+	binxmlInstanceNode
+)
+
+type binxmlAttr struct {
+	name  string
+	value []*binxmlNode
+}
+
+// binxmlValue 模板实例中的一个替换值，offset为数据在块内的偏移，嵌套的二进制XML从该处解析
+type binxmlValue struct {
+	typ    byte
+	data   []byte
+	offset int
+}
+
+// binxmlParser 在块数据的[pos, end)范围内解析二进制XML。
+// 出错后err被设置，之后的读取都返回零值，调用方在适当的位置检查err
+type binxmlParser struct {
+	chunk *evtxChunk
+	pos   int
+	end   int
+	depth int
+	err   error
+}
+
+func (p *binxmlParser) fail() {
+	if p.err == nil {
+		p.err = errors.New(tr("二进制XML数据损坏"))
+	}
+}
+
+func (p *binxmlParser) take(n int) []byte {
+	if p.err != nil {
+		return nil
+	}
+	if n < 0 || p.pos+n > p.end {
+		p.fail()
+		return nil
+	}
+	b := p.chunk.data[p.pos : p.pos+n]
+	p.pos += n
+	return b
+}
+
+func (p *binxmlParser) peek() byte {
+	if p.err != nil {
+		return binxmlEOF
+	}
+	if p.pos >= p.end {
+		p.fail()
+		return binxmlEOF
+	}
+	return p.chunk.data[p.pos]
+}
+
+func (p *binxmlParser) u8() byte {
+	if b := p.take(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (p *binxmlParser) u16() uint16 {
+	if b := p.take(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (p *binxmlParser) u32() uint32 {
+	if b := p.take(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// name 读取名称偏移。偏移等于当前位置时名称内联存储在此处，需要跳过
+func (p *binxmlParser) name() string {
+	off := p.u32()
+	if p.err != nil {
+		return ""
+	}
+	if name, ok := p.chunk.names[off]; ok && int(off) != p.pos {
+		return name
+	}
+	name, size, err := p.chunk.name(off)
+	if err != nil {
+		p.err = err
+		return ""
+	}
+	if int(off) == p.pos {
+		p.take(size)
+	}
+	return name
+}
+
+// fragment 解析到EOF标记为止的片段，片段由片段头、元素或模板实例组成
+func (p *binxmlParser) fragment() []*binxmlNode {
+	var nodes []*binxmlNode
+	for p.err == nil && p.pos < p.end {
+		switch p.peek() &^ binxmlHasMore {
+		case binxmlEOF:
+			p.pos++
+			return nodes
+		case binxmlFragmentHeader:
+			p.take(4)
+		case binxmlOpenStart:
+			nodes = append(nodes, p.element())
+		case binxmlTemplate:
+			nodes = append(nodes, p.instance())
+		default:
+			p.fail()
+		}
+	}
+	return nodes
+}
+
+// validNameOffset 判断pos处的4字节是否可能是名称偏移：要么指向紧随其后的内联名称，要么指向之前定义的名称
+func (p *binxmlParser) validNameOffset(pos int) bool {
+	if pos+4 > p.end {
+		return false
+	}
+	off := int(binary.LittleEndian.Uint32(p.chunk.data[pos:]))
+	return off == pos+4 || (off >= evtxChunkHeaderSize && off < pos)
+}
+
+// element 解析元素：标记、2字节依赖标识、4字节数据长度、名称偏移，之后是属性列表和子节点
+func (p *binxmlParser) element() *binxmlNode {
+	token := p.u8()
+	// 部分日志中的元素没有依赖标识，此时名称偏移紧跟在数据长度之后
+	if !p.validNameOffset(p.pos+6) && p.validNameOffset(p.pos+4) {
+		p.take(4)
+	} else {
+		p.take(6)
+	}
+
+	node := &binxmlNode{kind: binxmlElementNode, name: p.name()}
+	if token&binxmlHasMore != 0 {
+		p.take(4) // 属性列表长度
+		for p.err == nil {
+			t := p.u8()
+			if t&^binxmlHasMore != binxmlAttribute {
+				p.fail()
+				break
+			}
+			node.attrs = append(node.attrs, binxmlAttr{name: p.name(), value: p.content()})
+			if t&binxmlHasMore == 0 {
+				break
+			}
+		}
+	}
+
+	switch p.u8() {
+	case binxmlCloseEmpty:
+	case binxmlCloseStart:
+		node.children = p.children()
+	default:
+		p.fail()
+	}
+	return node
+}
+
+// content 解析属性值，由一个或多个文本、替换项或字符引用组成
+func (p *binxmlParser) content() []*binxmlNode {
+	var nodes []*binxmlNode
+	for p.err == nil {
+		switch p.peek() &^ binxmlHasMore {
+		case binxmlValueText, binxmlCharRef, binxmlEntityRef, binxmlSubstitution, binxmlOptionalSubst:
+			nodes = append(nodes, p.item())
+		default:
+			return nodes
+		}
+	}
+	return nodes
+}
+
+// children 解析元素的子节点，直到元素结束标记
+func (p *binxmlParser) children() []*binxmlNode {
+	var nodes []*binxmlNode
+	for p.err == nil {
+		switch p.peek() &^ binxmlHasMore {
+		case binxmlEndElement:
+			p.pos++
+			return nodes
+		case binxmlOpenStart:
+			nodes = append(nodes, p.element())
+		case binxmlTemplate:
+			nodes = append(nodes, p.instance())
+		case binxmlValueText, binxmlCDATA, binxmlCharRef, binxmlEntityRef, binxmlSubstitution, binxmlOptionalSubst,
+			binxmlPITarget, binxmlPIData:
+			if node := p.item(); node != nil {
+				nodes = append(nodes, node)
+			}
+		default:
+			p.fail()
+		}
+	}
+	return nodes
+}
+
+// XML预定义实体
+var xmlEntities = map[string]string{"amp": "&", "lt": "<", "gt": ">", "quot": "\"", "apos": "'"}
+
+// item 解析文本、替换项等单个内容节点，处理指令不影响事件数据，返回nil
+func (p *binxmlParser) item() *binxmlNode {
+	token := p.u8()
+	switch token &^ binxmlHasMore {
+	case binxmlValueText:
+		p.u8() // 值类型，总是字符串
+		return &binxmlNode{kind: binxmlTextNode, text: utf16String(p.take(int(p.u16()) * 2))}
+	case binxmlCDATA:
+		return &binxmlNode{kind: binxmlTextNode, text: utf16String(p.take(int(p.u16()) * 2))}
+	case binxmlCharRef:
+		return &binxmlNode{kind: binxmlTextNode, text: string(rune(p.u16()))}
+	case binxmlEntityRef:
+		name := p.name()
+		text, ok := xmlEntities[name]
+		if !ok {
+			text = "&" + name + ";"
+		}
+		return &binxmlNode{kind: binxmlTextNode, text: text}
+	case binxmlSubstitution, binxmlOptionalSubst:
+		index := int(p.u16())
+		p.u8() // 值类型，以模板实例中的描述为准
+		return &binxmlNode{kind: binxmlSubstNode, index: index, optional: token == binxmlOptionalSubst}
+	case binxmlPITarget:
+		p.name()
+	case binxmlPIData:
+		p.take(int(p.u16()) * 2)
+	default:
+		p.fail()
+	}
+	return nil
+}
+
+// instance 解析模板实例：标记、1字节未知、4字节模板ID、4字节定义偏移，之后是替换值数组。
+// 模板在块内首次使用时定义内联存储在此处
+func (p *binxmlParser) instance() *binxmlNode {
+	p.take(6)
+	off := p.u32()
+	if p.err != nil {
+		return nil
+	}
+	if int(off) == p.pos {
+		p.take(20)
+		p.take(int(p.u32()))
+	}
+	template, err := p.chunk.template(off, p.depth+1)
+	if err != nil {
+		p.err = err
+		return nil
+	}
+
+	// 替换值数组：4字节数量，每个值2字节长度、1字节类型和1字节填充，之后依次是各个值的数据
+	count := int(p.u32())
+	if count*4 > p.end-p.pos {
+		p.fail()
+		return nil
+	}
+	sizes := make([]int, count)
+	values := make([]binxmlValue, count)
+	for i := range values {
+		sizes[i] = int(p.u16())
+		values[i].typ = p.u8()
+		p.u8()
+	}
+	for i := range values {
+		values[i].offset = p.pos
+		values[i].data = p.take(sizes[i])
+	}
+	return &binxmlNode{kind: binxmlInstanceNode, template: template, values: values}
+}
+
+// evtxElement 实例化后的XML元素
+type evtxElement struct {
+	name     string
+	attrs    map[string]string
+	children []*evtxElement
+	text     string
+}
+
+// child 返回第一个指定名称的子元素
+func (e *evtxElement) child(name string) *evtxElement {
+	for _, c := range e.children {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// instantiate 用替换值填充节点，得到XML元素
+func (c *evtxChunk) instantiate(nodes []*binxmlNode, values []binxmlValue, depth int) ([]*evtxElement, error) {
+	if depth > binxmlMaxDepth {
+		return nil, errors.New(tr("二进制XML数据损坏"))
+	}
+	var elements []*evtxElement
+	for _, node := range nodes {
+		switch node.kind {
+		case binxmlElementNode:
+			e, err := c.render(node, values, depth)
+			if err != nil {
+				return nil, err
+			}
+			if e != nil {
+				elements = append(elements, e)
+			}
+		case binxmlInstanceNode:
+			children, err := c.instantiate(node.template, node.values, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, children...)
+		}
+	}
+	return elements, nil
+}
+
+// render 实例化单个元素。元素内容只有可选替换项且值均为空时返回nil
+func (c *evtxChunk) render(node *binxmlNode, values []binxmlValue, depth int) (*evtxElement, error) {
+	e := &evtxElement{name: node.name, attrs: make(map[string]string)}
+	for _, attr := range node.attrs {
+		if text, ok := substitute(attr.value, values); ok {
+			e.attrs[attr.name] = text
+		}
+	}
+
+	omit := len(node.children) > 0
+	var text strings.Builder
+	for _, child := range node.children {
+		if child.kind != binxmlSubstNode || !child.optional || !isEmptyValue(values, child.index) {
+			omit = false
+		}
+		switch child.kind {
+		case binxmlElementNode, binxmlInstanceNode:
+			children, err := c.instantiate([]*binxmlNode{child}, values, depth)
+			if err != nil {
+				return nil, err
+			}
+			e.children = append(e.children, children...)
+		case binxmlTextNode:
+			text.WriteString(child.text)
+		case binxmlSubstNode:
+			if child.index >= len(values) {
+				continue
+			}
+			value := values[child.index]
+			if value.typ != evtBinXML {
+				text.WriteString(value.String())
+				continue
+			}
+			// 嵌套的二进制XML，如UserData
+			p := &binxmlParser{chunk: c, pos: value.offset, end: value.offset + len(value.data), depth: depth + 1}
+			nodes := p.fragment()
+			if p.err != nil {
+				return nil, p.err
+			}
+			children, err := c.instantiate(nodes, nil, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			e.children = append(e.children, children...)
+		}
+	}
+	if omit {
+		return nil, nil
+	}
+	e.text = text.String()
+	return e, nil
+}
+
+// substitute 拼接属性值，值只有可选替换项且为空时返回false
+func substitute(nodes []*binxmlNode, values []binxmlValue) (string, bool) {
+	var text strings.Builder
+	present := len(nodes) == 0
+	for _, node := range nodes {
+		switch node.kind {
+		case binxmlTextNode:
+			text.WriteString(node.text)
+			present = true
+		case binxmlSubstNode:
+			if !node.optional || !isEmptyValue(values, node.index) {
+				present = true
+			}
+			if node.index < len(values) {
+				text.WriteString(values[node.index].String())
+			}
+		}
+	}
+	return text.String(), present
+}
+
+func isEmptyValue(values []binxmlValue, index int) bool {
+	return index >= len(values) || values[index].typ == evtNull || len(values[index].data) == 0
+}
+
+// String 按事件查看器中XML视图的格式返回值的文本
+func (v binxmlValue) String() string {
+	if v.typ&evtArray != 0 {
+		return v.arrayString()
+	}
+	d := v.data
+	switch v.typ {
+	case evtNull:
+		return ""
+	case evtString:
+		return strings.TrimRight(utf16String(d), "\x00")
+	case evtAnsiString:
+		return strings.TrimRight(string(d), "\x00")
+	case evtInt8, evtInt16, evtInt32, evtInt64:
+		return strconv.FormatInt(leInt(d), 10)
+	case evtUInt8, evtUInt16, evtUInt32, evtUInt64:
+		return strconv.FormatUint(leUint(d), 10)
+	case evtReal32:
+		return strconv.FormatFloat(float64(math.Float32frombits(uint32(leUint(d)))), 'g', -1, 32)
+	case evtReal64:
+		return strconv.FormatFloat(math.Float64frombits(leUint(d)), 'g', -1, 64)
+	case evtBool:
+		return strconv.FormatBool(leUint(d) != 0)
+	case evtGUID:
+		if len(d) < 16 {
+			break
+		}
+		return fmt.Sprintf("{%08X-%04X-%04X-%X-%X}", binary.LittleEndian.Uint32(d), binary.LittleEndian.Uint16(d[4:]),
+			binary.LittleEndian.Uint16(d[6:]), d[8:10], d[10:16])
+	case evtSizeT, evtHexInt32, evtHexInt64:
+		return fmt.Sprintf("0x%x", leUint(d))
+	case evtFileTime:
+		if t := filetimeToTime(leUint(d)); !t.IsZero() {
+			return t.Format(evtxTimeFormat)
+		}
+	case evtSysTime:
+		if len(d) < 16 {
+			break
+		}
+		u := func(i int) int { return int(binary.LittleEndian.Uint16(d[i*2:])) }
+		return time.Date(u(0), time.Month(u(1)), u(3), u(4), u(5), u(6), u(7)*int(time.Millisecond), time.UTC).Format(evtxTimeFormat)
+	case evtSID:
+		if sid, _ := parseSID(d); sid != "" {
+			return sid
+		}
+	}
+	return strings.ToUpper(hex.EncodeToString(d))
+}
+
+// 定长类型的数组元素大小
+var evtArrayElementSizes = map[byte]int{
+	evtInt8: 1, evtUInt8: 1, evtInt16: 2, evtUInt16: 2, evtInt32: 4, evtUInt32: 4, evtInt64: 8, evtUInt64: 8,
+	evtReal32: 4, evtReal64: 8, evtBool: 4, evtGUID: 16, evtSizeT: 8, evtFileTime: 8, evtSysTime: 16,
+	evtHexInt32: 4, evtHexInt64: 8,
+}
+
+// arrayString 将数组中的元素以逗号分隔，字符串数组的元素以空字符分隔
+func (v binxmlValue) arrayString() string {
+	typ := v.typ &^ evtArray
+	var items []string
+	switch typ {
+	case evtString:
+		items = strings.Split(strings.TrimRight(utf16String(v.data), "\x00"), "\x00")
+	case evtAnsiString:
+		items = strings.Split(strings.TrimRight(string(v.data), "\x00"), "\x00")
+	case evtSID:
+		for d := v.data; len(d) > 0; {
+			sid, n := parseSID(d)
+			if n == 0 {
+				break
+			}
+			items = append(items, sid)
+			d = d[n:]
+		}
+	default:
+		size := evtArrayElementSizes[typ]
+		if size == 0 {
+			return strings.ToUpper(hex.EncodeToString(v.data))
+		}
+		for d := v.data; len(d) >= size; d = d[size:] {
+			items = append(items, binxmlValue{typ: typ, data: d[:size]}.String())
+		}
+	}
+	return strings.Join(items, ", ")
+}
+
+// parseSID 解析二进制SID，返回字符串形式和占用的字节数
+func parseSID(d []byte) (string, int) {
+	if len(d) < 8 {
+		return "", 0
+	}
+	count := int(d[1])
+	size := 8 + count*4
+	if len(d) < size {
+		return "", 0
+	}
+	var authority uint64
+	for _, b := range d[2:8] {
+		authority = authority<<8 | uint64(b)
+	}
+	sid := fmt.Sprintf("S-%d-%d", d[0], authority)
+	for i := 0; i < count; i++ {
+		sid += fmt.Sprintf("-%d", binary.LittleEndian.Uint32(d[8+i*4:]))
+	}
+	return sid, size
+}
+
+// leUint 读取最多8字节的小端无符号整数
+func leUint(d []byte) uint64 {
+	if len(d) > 8 {
+		d = d[:8]
+	}
+	var v uint64
+	for i := len(d) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(d[i])
+	}
+	return v
+}
+
+// leInt 读取最多8字节的小端有符号整数
+func leInt(d []byte) int64 {
+	if len(d) == 0 || len(d) >= 8 {
+		return int64(leUint(d))
+	}
+	shift := 64 - 8*uint(len(d))
+	return int64(leUint(d)<<shift) >> shift
+}
+
+// utf16String 解码UTF-16LE字符串
+func utf16String(b []byte) string {
+	u := make([]uint16, len(b)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(b[i*2:])
+	}
+	return string(utf16.Decode(u))
+}
+
+// filetimeToTime 将FILETIME（自1601年起的100纳秒数）转换为UTC时间。
+// 0表示未设置，与1970年以前的值一样返回零值，调用方用IsZero判断
+func filetimeToTime(ft uint64) time.Time {
+	const epochDiff = 116444736000000000 // 1601年到1970年的100纳秒数
+	if ft < epochDiff {
+		return time.Time{}
+	}
+	ticks := ft - epochDiff
+	return time.Unix(int64(ticks/10000000), int64(ticks%10000000)*100).UTC()
+}
+
+// newLogAnalysis 从Event元素中提取事件字段
+func newLogAnalysis(event *evtxElement, recordID uint64, written time.Time) LogAnalysis {
+	ev := LogAnalysis{RecordID: recordID, TimeStamp: written, Data: make(map[string]string)}
+
+	if system := event.child("System"); system != nil {
+		if e := system.child("Provider"); e != nil {
+			ev.Source = e.attrs["Name"]
+		}
+		if e := system.child("EventID"); e != nil {
+			id, _ := strconv.ParseUint(strings.TrimSpace(e.text), 10, 32)
+			ev.EventID = uint32(id)
+		}
+		if e := system.child("Level"); e != nil {
+			level, _ := strconv.ParseUint(strings.TrimSpace(e.text), 10, 16)
+			ev.Level = uint16(level)
+		}
+		if e := system.child("TimeCreated"); e != nil {
+			if t, err := time.Parse(time.RFC3339Nano, e.attrs["SystemTime"]); err == nil {
+				ev.TimeStamp = t
+			}
+		}
+		if e := system.child("Channel"); e != nil {
+			ev.Channel = e.text
+		}
+		if e := system.child("Computer"); e != nil {
+			ev.Computer = e.text
+		}
+	}
+
+	var message []string
+	addField := func(name, value string) {
+		message = append(message, name+"="+value)
+		if old, ok := ev.Data[name]; ok {
+			value = old + "\n" + value
+		}
+		ev.Data[name] = value
+	}
+	if data := event.child("EventData"); data != nil {
+		for _, e := range data.children {
+			name := e.attrs["Name"]
+			if name == "" {
+				name = e.name
+			}
+			addField(name, e.text)
+		}
+	}
+	// UserData下是提供程序自定义的元素，取其子元素作为字段
+	if data := event.child("UserData"); data != nil && len(data.children) > 0 {
+		for _, e := range data.children[0].children {
+			addField(e.name, e.text)
+		}
+	}
+	ev.Message = strings.Join(message, "; ")
+	return ev
+}
@@ -0,0 +1,407 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// evtxBuilder 按EVTX格式在块中写入二进制XML，名称和模板在首次使用时内联定义，之后按偏移引用
+type evtxBuilder struct {
+	buf       []byte
+	names     map[string]uint32
+	templates map[uint32]uint32
+}
+
+func newEvtxBuilder() *evtxBuilder {
+	b := &evtxBuilder{buf: make([]byte, evtxChunkHeaderSize), names: map[string]uint32{}, templates: map[uint32]uint32{}}
+	copy(b.buf, evtxChunkMagic)
+	return b
+}
+
+func (b *evtxBuilder) pos() uint32 { return uint32(len(b.buf)) }
+func (b *evtxBuilder) u8(v byte)   { b.buf = append(b.buf, v) }
+func (b *evtxBuilder) u16(v uint16) {
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, v)
+}
+func (b *evtxBuilder) u32(v uint32) {
+	b.buf = binary.LittleEndian.AppendUint32(b.buf, v)
+}
+func (b *evtxBuilder) u64(v uint64) {
+	b.buf = binary.LittleEndian.AppendUint64(b.buf, v)
+}
+func (b *evtxBuilder) utf16(s string) {
+	for _, c := range utf16.Encode([]rune(s)) {
+		b.u16(c)
+	}
+}
+
+func (b *evtxBuilder) name(s string) {
+	if off, ok := b.names[s]; ok {
+		b.u32(off)
+		return
+	}
+	off := b.pos() + 4
+	b.names[s] = off
+	b.u32(off)
+	b.u32(0)
+	b.u16(0)
+	b.u16(uint16(len(utf16.Encode([]rune(s)))))
+	b.utf16(s)
+	b.u16(0)
+}
+
+// open 写入元素开始标记，attrs为属性写入函数
+func (b *evtxBuilder) open(name string, attrs ...func()) {
+	if len(attrs) == 0 {
+		b.u8(binxmlOpenStart)
+	} else {
+		b.u8(binxmlOpenStart | binxmlHasMore)
+	}
+	b.u16(0xffff)
+	b.u32(0)
+	b.name(name)
+	if len(attrs) > 0 {
+		b.u32(0)
+		for i, attr := range attrs {
+			token := byte(binxmlAttribute)
+			if i < len(attrs)-1 {
+				token |= binxmlHasMore
+			}
+			b.u8(token)
+			attr()
+		}
+	}
+}
+
+func (b *evtxBuilder) attr(name string, value func()) func() {
+	return func() {
+		b.name(name)
+		value()
+	}
+}
+
+func (b *evtxBuilder) text(s string) {
+	b.u8(binxmlValueText)
+	b.u8(evtString)
+	b.u16(uint16(len(utf16.Encode([]rune(s)))))
+	b.utf16(s)
+}
+
+func (b *evtxBuilder) subst(index uint16, typ byte, optional bool) {
+	if optional {
+		b.u8(binxmlOptionalSubst)
+	} else {
+		b.u8(binxmlSubstitution)
+	}
+	b.u16(index)
+	b.u8(typ)
+}
+
+// element 写入只包含一个内容节点的元素
+func (b *evtxBuilder) element(name string, content func(), attrs ...func()) {
+	b.open(name, attrs...)
+	if content == nil {
+		b.u8(binxmlCloseEmpty)
+		return
+	}
+	b.u8(binxmlCloseStart)
+	content()
+	b.u8(binxmlEndElement)
+}
+
+type testValue struct {
+	typ  byte
+	data []byte
+}
+
+// instance 写入模板实例，模板首次使用时内联写入定义
+func (b *evtxBuilder) instance(id uint32, body func(), values []testValue) {
+	b.u8(binxmlTemplate)
+	b.u8(1)
+	b.u32(id)
+	if off, ok := b.templates[id]; ok {
+		b.u32(off)
+	} else {
+		off := b.pos() + 4
+		b.templates[id] = off
+		b.u32(off)
+		b.u32(0)
+		b.u32(id)
+		b.buf = append(b.buf, make([]byte, 12)...)
+		sizeAt := len(b.buf)
+		b.u32(0)
+		b.u8(binxmlFragmentHeader)
+		b.u8(1)
+		b.u8(1)
+		b.u8(0)
+		body()
+		b.u8(binxmlEOF)
+		binary.LittleEndian.PutUint32(b.buf[sizeAt:], uint32(len(b.buf)-sizeAt-4))
+	}
+
+	b.u32(uint32(len(values)))
+	for _, v := range values {
+		b.u16(uint16(len(v.data)))
+		b.u8(v.typ)
+		b.u8(0)
+	}
+	for _, v := range values {
+		b.buf = append(b.buf, v.data...)
+	}
+}
+
+func (b *evtxBuilder) record(id uint64, written time.Time, body func()) {
+	start := len(b.buf)
+	b.buf = append(b.buf, evtxRecordMagic...)
+	b.u32(0)
+	b.u64(id)
+	b.u64(timeToFiletime(written))
+	b.u8(binxmlFragmentHeader)
+	b.u8(1)
+	b.u8(1)
+	b.u8(0)
+	body()
+	size := uint32(len(b.buf) - start + 4)
+	b.u32(size)
+	binary.LittleEndian.PutUint32(b.buf[start+4:], size)
+}
+
+// chunk 返回补齐到64KB的块，并写入空闲空间偏移
+func (b *evtxBuilder) chunk() []byte {
+	data := make([]byte, evtxChunkSize)
+	copy(data, b.buf)
+	binary.LittleEndian.PutUint32(data[48:], uint32(len(b.buf)))
+	return data
+}
+
+func timeToFiletime(t time.Time) uint64 {
+	return uint64(t.UnixNano()/100) + 116444736000000000
+}
+
+func stringValue(s string) testValue {
+	var b evtxBuilder
+	b.utf16(s)
+	b.u16(0)
+	return testValue{evtString, b.buf}
+}
+
+func uintValue(typ byte, v uint64, size int) testValue {
+	data := make([]byte, 8)
+	binary.LittleEndian.PutUint64(data, v)
+	return testValue{typ, data[:size]}
+}
+
+// 登录事件模板：System中的提供程序、事件ID、级别、时间和计算机名，EventData中的用户、地址和可选的登录类型
+func (b *evtxBuilder) logonEvent(id uint64, eventID uint16, at time.Time, user, ip string, logonType *uint32) {
+	values := []testValue{
+		stringValue("Microsoft-Windows-Security-Auditing"),
+		uintValue(evtUInt16, uint64(eventID), 2),
+		uintValue(evtUInt8, 0, 1),
+		uintValue(evtFileTime, timeToFiletime(at), 8),
+		stringValue("DC01.corp.local"),
+		stringValue(user),
+		stringValue(ip),
+		{evtNull, nil},
+	}
+	if logonType != nil {
+		values[7] = uintValue(evtUInt32, uint64(*logonType), 4)
+	}
+
+	b.record(id, at, func() {
+		b.instance(1, func() {
+			b.open("Event", b.attr("xmlns", func() { b.text("http://schemas.microsoft.com/win/2004/08/events/event") }))
+			b.u8(binxmlCloseStart)
+			b.open("System")
+			b.u8(binxmlCloseStart)
+			b.element("Provider", nil, b.attr("Name", func() { b.subst(0, evtString, false) }))
+			b.element("EventID", func() { b.subst(1, evtUInt16, false) })
+			b.element("Level", func() { b.subst(2, evtUInt8, false) })
+			b.element("TimeCreated", nil, b.attr("SystemTime", func() { b.subst(3, evtFileTime, false) }))
+			b.element("Channel", func() { b.text("Security") })
+			b.element("Computer", func() { b.subst(4, evtString, false) })
+			b.u8(binxmlEndElement)
+			b.open("EventData")
+			b.u8(binxmlCloseStart)
+			b.element("Data", func() { b.subst(5, evtString, false) }, b.attr("Name", func() { b.text("TargetUserName") }))
+			b.element("Data", func() { b.subst(6, evtString, false) }, b.attr("Name", func() { b.text("IpAddress") }))
+			b.element("Data", func() { b.subst(7, evtUInt32, true) }, b.attr("Name", func() { b.text("LogonType") }))
+			b.u8(binxmlEndElement)
+			b.u8(binxmlEndElement)
+		}, values)
+	})
+}
+
+// 日志清除事件：UserData为嵌套的二进制XML替换值
+func (b *evtxBuilder) clearedEvent(id uint64, at time.Time, user string) {
+	// 嵌套片段中的内联名称和模板以其在块内的位置为准，因此直接写在替换值数据所在的位置
+	b.record(id, at, func() {
+		b.instance(2, func() {
+			b.open("Event")
+			b.u8(binxmlCloseStart)
+			b.open("System")
+			b.u8(binxmlCloseStart)
+			b.element("EventID", func() { b.subst(0, evtUInt16, false) })
+			b.u8(binxmlEndElement)
+			b.element("UserData", func() { b.subst(1, evtBinXML, false) })
+			b.u8(binxmlEndElement)
+		}, nil)
+		// 回填替换值：实例之后依次是数量、描述和数据
+		b.buf = b.buf[:len(b.buf)-4]
+		b.u32(2)
+		b.u16(2)
+		b.u8(evtUInt16)
+		b.u8(0)
+		sizeAt := len(b.buf)
+		b.u16(0)
+		b.u8(evtBinXML)
+		b.u8(0)
+		b.u16(1102)
+		start := len(b.buf)
+		b.u8(binxmlFragmentHeader)
+		b.u8(1)
+		b.u8(1)
+		b.u8(0)
+		b.instance(3, func() {
+			b.open("LogFileCleared")
+			b.u8(binxmlCloseStart)
+			b.element("SubjectUserName", func() { b.subst(0, evtString, false) })
+			b.u8(binxmlEndElement)
+		}, []testValue{stringValue(user)})
+		b.u8(binxmlEOF)
+		binary.LittleEndian.PutUint16(b.buf[sizeAt:], uint16(len(b.buf)-start))
+	})
+}
+
+func buildTestEvtx(chunks ...[]byte) []byte {
+	header := make([]byte, evtxFileHeaderSize)
+	copy(header, evtxFileMagic)
+	data := header
+	for _, chunk := range chunks {
+		data = append(data, chunk...)
+	}
+	return data
+}
+
+func TestParseEvtx(t *testing.T) {
+	at := time.Date(2024, 3, 1, 8, 30, 0, 123456700, time.UTC)
+	rdp := uint32(10)
+
+	b := newEvtxBuilder()
+	b.logonEvent(100, 4625, at, "administrator", "203.0.113.7", nil)
+	b.logonEvent(101, 4624, at.Add(time.Minute), "administrator", "203.0.113.7", &rdp)
+	b.clearedEvent(102, at.Add(2*time.Minute), "attacker")
+
+	// 中间的空块应被跳过
+	data := buildTestEvtx(b.chunk(), make([]byte, evtxChunkSize), b.chunk())
+
+	var events []LogAnalysis
+	damaged, err := parseEvtx(context.Background(), bytes.NewReader(data), func(event LogAnalysis) {
+		events = append(events, event)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if damaged != 0 {
+		t.Errorf("damaged = %d, want 0", damaged)
+	}
+	if len(events) != 6 {
+		t.Fatalf("got %d events, want 6", len(events))
+	}
+
+	failed := events[0]
+	if failed.EventID != 4625 || failed.RecordID != 100 || failed.Source != "Microsoft-Windows-Security-Auditing" {
+		t.Errorf("event = %+v", failed)
+	}
+	if failed.Channel != "Security" || failed.Computer != "DC01.corp.local" || !failed.TimeStamp.Equal(at) {
+		t.Errorf("event = %+v", failed)
+	}
+	if failed.Data["TargetUserName"] != "administrator" || failed.Data["IpAddress"] != "203.0.113.7" {
+		t.Errorf("data = %v", failed.Data)
+	}
+	if _, ok := failed.Data["LogonType"]; ok {
+		t.Errorf("optional LogonType should be omitted: %v", failed.Data)
+	}
+
+	success := events[1]
+	if success.EventID != 4624 || success.Data["LogonType"] != "10" {
+		t.Errorf("event = %+v", success)
+	}
+	if want := "TargetUserName=administrator; IpAddress=203.0.113.7; LogonType=10"; success.Message != want {
+		t.Errorf("message = %q, want %q", success.Message, want)
+	}
+
+	cleared := events[2]
+	if cleared.EventID != 1102 || cleared.Data["SubjectUserName"] != "attacker" {
+		t.Errorf("event = %+v", cleared)
+	}
+	if !cleared.TimeStamp.Equal(at.Add(2 * time.Minute)) {
+		t.Errorf("timestamp = %v, want record header time", cleared.TimeStamp)
+	}
+}
+
+func TestParseEvtxDamagedRecord(t *testing.T) {
+	at := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	b := newEvtxBuilder()
+	b.logonEvent(1, 4625, at, "guest", "198.51.100.1", nil)
+	corrupt := len(b.buf) + evtxRecordHeaderSize
+	b.logonEvent(2, 4625, at, "guest", "198.51.100.1", nil)
+	b.logonEvent(3, 4625, at, "guest", "198.51.100.1", nil)
+	chunk := b.chunk()
+	// 第二条记录的二进制XML以未知标记开头
+	chunk[corrupt] = 0x7f
+
+	var ids []uint64
+	damaged, err := parseEvtx(context.Background(), bytes.NewReader(buildTestEvtx(chunk)), func(event LogAnalysis) {
+		ids = append(ids, event.RecordID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if damaged != 1 || len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("damaged = %d, ids = %v", damaged, ids)
+	}
+
+	if _, err := parseEvtx(context.Background(), bytes.NewReader(chunk), func(LogAnalysis) {}); err == nil {
+		t.Error("expected error for missing file header")
+	}
+}
+
+func TestBinxmlValueString(t *testing.T) {
+	sid := []byte{1, 5, 0, 0, 0, 0, 0, 5, 21, 0, 0, 0, 0xe8, 3, 0, 0, 0xf4, 1, 0, 0, 0x10, 0x27, 0, 0, 0x51, 4, 0, 0}
+	guid := []byte{0x78, 0x56, 0x34, 0x12, 0x34, 0x12, 0x78, 0x56, 0x12, 0x34, 0x56, 0x78, 0x9a, 0xbc, 0xde, 0xf0}
+	tests := []struct {
+		value binxmlValue
+		want  string
+	}{
+		{binxmlValue{typ: evtSID, data: sid}, "S-1-5-21-1000-500-10000-1105"},
+		{binxmlValue{typ: evtGUID, data: guid}, "{12345678-1234-5678-1234-56789ABCDEF0}"},
+		{binxmlValue{typ: evtInt8, data: []byte{0xfe}}, "-2"},
+		{binxmlValue{typ: evtHexInt32, data: []byte{0x10, 0x27, 0, 0}}, "0x2710"},
+		{binxmlValue{typ: evtBool, data: []byte{1, 0, 0, 0}}, "true"},
+		{binxmlValue{typ: evtBinary, data: []byte{0xde, 0xad}}, "DEAD"},
+		{binxmlValue{typ: evtFileTime, data: uintValue(evtFileTime, timeToFiletime(time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC)), 8).data}, "2024-01-02T03:04:05.0000006Z"},
+		{binxmlValue{typ: evtString | evtArray, data: append(stringValue("a").data, stringValue("b").data...)}, "a, b"},
+		{binxmlValue{typ: evtUInt16 | evtArray, data: []byte{1, 0, 2, 0}}, "1, 2"},
+	}
+	for _, tt := range tests {
+		if got := tt.value.String(); got != tt.want {
+			t.Errorf("type 0x%x: got %q, want %q", tt.value.typ, got, tt.want)
+		}
+	}
+}
+
+func TestFiletimeToTime(t *testing.T) {
+	want := time.Date(2024, 3, 1, 8, 0, 0, 123456700, time.UTC)
+	if got := filetimeToTime(timeToFiletime(want)); !got.Equal(want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	for _, ft := range []uint64{0, 1, 116444736000000000 - 1} {
+		if got := filetimeToTime(ft); !got.IsZero() {
+			t.Errorf("filetime %d: got %v, want zero time", ft, got)
+		}
+	}
+}
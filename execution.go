@@ -0,0 +1,335 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 执行痕迹的来源，按报告中的显示顺序排列
+const (
+	artifactPrefetch        = "Prefetch"
+	artifactShimcache       = "Shimcache"
+	artifactAmcache         = "Amcache"
+	artifactBAM             = "BAM"
+	artifactDAM             = "DAM"
+	artifactUserAssist      = "UserAssist"
+	artifactProcessCreation = "4688"
+)
+
+var artifactOrder = []string{
+	artifactPrefetch, artifactShimcache, artifactAmcache, artifactBAM, artifactDAM, artifactUserAssist, artifactProcessCreation,
+}
+
+// executionArtifact 一条执行痕迹。Times为该来源记录的运行时间，Amcache为首次记录时间，Shimcache中的时间是文件修改时间，不计入。
+// Serial为Path所在卷的序列号，只有Prefetch记录
+type executionArtifact struct {
+	Source   string
+	Path     string
+	SHA1     string
+	Times    []time.Time
+	RunCount int
+	User     string
+	Serial   uint32
+}
+
+// executionRecord 合并后的一个程序的执行历史，同一SHA1出现在多个路径时合并为一条
+type executionRecord struct {
+	Paths     []string
+	SHA1      []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// RunCount 各来源中最大的运行次数，Prefetch和UserAssist记录运行次数，4688每个事件计一次
+	RunCount int
+	Users    []string
+	Sources  []string
+	// UserWritableOnly 所有路径都位于用户可写目录
+	UserWritableOnly bool
+}
+
+// Path 显示用的主路径
+func (r executionRecord) Path() string {
+	return r.Paths[0]
+}
+
+// SingleSource 只出现在一种执行痕迹中
+func (r executionRecord) SingleSource() bool {
+	return len(r.Sources) == 1
+}
+
+// Flags 报告中显示的可疑标记
+func (r executionRecord) Flags() []string {
+	var flags []string
+	if r.UserWritableOnly {
+		flags = append(flags, tr("只出现在用户可写目录中"))
+	}
+	if r.SingleSource() {
+		flags = append(flags, tr("只出现在一种执行痕迹中"))
+	}
+	return flags
+}
+
+func (r executionRecord) FirstSeenText() string { return formatReportTime(r.FirstSeen) }
+func (r executionRecord) LastSeenText() string  { return formatReportTime(r.LastSeen) }
+
+// volumeMap 卷与盘符的对应关系，用于把Prefetch、BAM中的卷设备路径换成盘符。键均为小写
+type volumeMap struct {
+	devices map[string]string // 设备路径（\device\harddiskvolume3）对应的盘符
+	guids   map[string]string // 卷GUID（{...}）对应的盘符
+	serials map[uint32]string // 卷序列号对应的盘符
+	// deviceSerials 设备路径的序列号，来自Prefetch的卷信息
+	deviceSerials map[string]uint32
+}
+
+func newVolumeMap() *volumeMap {
+	return &volumeMap{
+		devices:       make(map[string]string),
+		guids:         make(map[string]string),
+		serials:       make(map[uint32]string),
+		deviceSerials: make(map[string]uint32),
+	}
+}
+
+// addDevice 记录盘符的设备路径，\\?\Volume{GUID}\ 形式的卷名记为GUID
+func (m *volumeMap) addDevice(device, letter string) {
+	if guid, ok := volumeGUID(device); ok {
+		m.guids[guid] = strings.ToUpper(letter)
+		return
+	}
+	m.devices[strings.ToLower(strings.TrimSuffix(device, `\`))] = strings.ToUpper(letter)
+}
+
+func (m *volumeMap) addSerial(serial uint32, letter string) {
+	m.serials[serial] = strings.ToUpper(letter)
+}
+
+// volumeGUID 从\??\Volume{GUID}、\\?\Volume{GUID}\或\VOLUME{GUID}中取出小写的{GUID}
+func volumeGUID(name string) (string, bool) {
+	p := strings.ToLower(name)
+	i := strings.Index(p, `volume{`)
+	j := strings.IndexByte(p, '}')
+	if i < 0 || j < i {
+		return "", false
+	}
+	return p[i+len("volume") : j+1], true
+}
+
+// letter 返回卷对应的盘符，设备路径按已知的设备路径、卷GUID或序列号查找
+func (m *volumeMap) letter(volume string) (string, bool) {
+	v := strings.ToLower(volume)
+	if len(v) == 2 && v[1] == ':' {
+		return strings.ToUpper(v), true
+	}
+	if m == nil {
+		return "", false
+	}
+	if letter, ok := m.devices[v]; ok {
+		return letter, true
+	}
+	if guid, ok := volumeGUID(v); ok {
+		if letter, ok := m.guids[guid]; ok {
+			return letter, true
+		}
+	}
+	serial, ok := m.deviceSerials[v]
+	if !ok {
+		serial, ok = prefetchVolumeSerial(v)
+	}
+	if !ok {
+		return "", false
+	}
+	letter, ok := m.serials[serial]
+	return letter, ok
+}
+
+// prefetchVolumeSerial Windows 8起Prefetch中的卷为\VOLUME{创建时间-序列号}，取出其中的序列号
+func prefetchVolumeSerial(volume string) (uint32, bool) {
+	guid, ok := volumeGUID(volume)
+	if !ok {
+		return 0, false
+	}
+	created, serial, found := strings.Cut(strings.Trim(guid, "{}"), "-")
+	if !found || len(created) != 16 || len(serial) != 8 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(serial, 16, 32)
+	return uint32(n), err == nil
+}
+
+// splitVolume 把路径分为卷和卷内的路径，卷为盘符、\Device\HarddiskVolumeN或\VOLUME{...}，去掉\??\、\\?\前缀。
+// 路径不含卷时volume为空
+func splitVolume(path string) (volume, rest string) {
+	p := strings.ReplaceAll(strings.TrimSpace(path), "/", `\`)
+	for _, prefix := range []string{`\??\`, `\\?\`} {
+		p = strings.TrimPrefix(p, prefix)
+	}
+	lower := strings.ToLower(p)
+	if strings.HasPrefix(lower, `volume{`) {
+		p, lower = `\`+p, `\`+lower
+	}
+	// 卷名之后的第一个"\"为卷内路径的开始
+	var name int
+	switch {
+	case strings.HasPrefix(lower, `\volume{`):
+		name = len(`\volume{`)
+	case strings.HasPrefix(lower, `\device\`):
+		name = len(`\device\`)
+	case len(p) >= 2 && p[1] == ':':
+		return p[:2], p[2:]
+	default:
+		return "", p
+	}
+	if i := strings.IndexByte(p[name:], '\\'); i >= 0 {
+		return p[:name+i], p[name+i:]
+	}
+	return p, ""
+}
+
+// resolvePath 把路径中的卷换成已知的盘符，卷未知时保留设备路径，用于统一各来源中不同形式的路径：
+// Prefetch使用\VOLUME{...}或\DEVICE\HARDDISKVOLUMEn，BAM使用\Device\HarddiskVolumeN，其他来源使用盘符。
+// 只有卷没有文件路径时返回空
+func (m *volumeMap) resolvePath(path string) string {
+	volume, rest := splitVolume(path)
+	if volume != "" && strings.Trim(rest, `\`) == "" {
+		return ""
+	}
+	if letter, ok := m.letter(volume); ok {
+		volume = letter
+	}
+	return volume + rest
+}
+
+// hasDriveLetter 路径以盘符开头
+func hasDriveLetter(path string) bool {
+	return len(path) >= 2 && path[1] == ':'
+}
+
+// correlateExecutions 按路径合并各来源的执行痕迹，再把SHA1相同的路径合并为一个程序。
+// 卷设备路径按volumes换成盘符，无法换算的卷上的路径只在SHA1相同时与其他卷合并
+func correlateExecutions(artifacts []executionArtifact, volumes *volumeMap) []executionRecord {
+	if volumes == nil {
+		volumes = newVolumeMap()
+	}
+	// Prefetch的卷信息记录了设备路径对应的序列号
+	for _, a := range artifacts {
+		if volume, _ := splitVolume(a.Path); a.Serial != 0 && volume != "" && !hasDriveLetter(volume) {
+			volumes.deviceSerials[strings.ToLower(volume)] = a.Serial
+		}
+	}
+
+	// 显示时优先使用来源中原本以盘符记录的路径
+	type group struct {
+		path      string
+		lettered  bool
+		artifacts []executionArtifact
+	}
+	var groups []*group
+	byPath := make(map[string]int)
+	for _, a := range artifacts {
+		resolved := volumes.resolvePath(a.Path)
+		key := strings.ToLower(resolved)
+		if key == "" {
+			continue
+		}
+		i, ok := byPath[key]
+		if !ok {
+			i = len(groups)
+			byPath[key] = i
+			groups = append(groups, &group{path: resolved})
+		}
+		g := groups[i]
+		if hasDriveLetter(a.Path) && !g.lettered {
+			g.path, g.lettered = a.Path, true
+		}
+		g.artifacts = append(g.artifacts, a)
+	}
+
+	// 并查集：SHA1相同的路径属于同一个程序
+	parent := make([]int, len(groups))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	byHash := make(map[string]int)
+	for i, g := range groups {
+		for _, a := range g.artifacts {
+			if a.SHA1 == "" {
+				continue
+			}
+			if j, ok := byHash[a.SHA1]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				byHash[a.SHA1] = i
+			}
+		}
+	}
+
+	members := make(map[int][]*group)
+	var roots []int
+	for i, g := range groups {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], g)
+	}
+
+	var records []executionRecord
+	for _, root := range roots {
+		record := executionRecord{UserWritableOnly: true}
+		hashes := make(map[string]bool)
+		users := make(map[string]bool)
+		sources := make(map[string]bool)
+		counts := make(map[string]int)
+		for _, g := range members[root] {
+			record.Paths = append(record.Paths, g.path)
+			record.UserWritableOnly = record.UserWritableOnly && isUserWritablePath(g.path)
+			for _, a := range g.artifacts {
+				sources[a.Source] = true
+				counts[a.Source] += a.RunCount
+				if a.SHA1 != "" {
+					hashes[a.SHA1] = true
+				}
+				if a.User != "" {
+					users[a.User] = true
+				}
+				for _, t := range a.Times {
+					if record.FirstSeen.IsZero() || t.Before(record.FirstSeen) {
+						record.FirstSeen = t
+					}
+					if t.After(record.LastSeen) {
+						record.LastSeen = t
+					}
+				}
+			}
+		}
+		for _, source := range artifactOrder {
+			if sources[source] {
+				record.Sources = append(record.Sources, source)
+			}
+			if counts[source] > record.RunCount {
+				record.RunCount = counts[source]
+			}
+		}
+		record.SHA1 = slices.Sorted(maps.Keys(hashes))
+		record.Users = slices.Sorted(maps.Keys(users))
+		records = append(records, record)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		if !records[i].LastSeen.Equal(records[j].LastSeen) {
+			return records[i].LastSeen.After(records[j].LastSeen)
+		}
+		return strings.ToLower(records[i].Path()) < strings.ToLower(records[j].Path())
+	})
+	return records
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testVolumes C:为HarddiskVolume3，序列号1234ABCD；D:只知道卷GUID
+func testVolumes() *volumeMap {
+	volumes := newVolumeMap()
+	volumes.addDevice(`\Device\HarddiskVolume3`, "C:")
+	volumes.addSerial(0x1234abcd, "C:")
+	volumes.addDevice(`\\?\Volume{6f1e2d3c-0000-0000-0000-100000000000}\`, "d:")
+	return volumes
+}
+
+func TestResolvePath(t *testing.T) {
+	volumes := testVolumes()
+	for _, path := range []string{
+		`C:\Users\bob\Downloads\tool.exe`,
+		`\??\C:\Users\bob\Downloads\tool.exe`,
+		`\VOLUME{01d9a1b2c3d4e5f6-1234abcd}\USERS\BOB\DOWNLOADS\TOOL.EXE`,
+		`\DEVICE\HARDDISKVOLUME3\USERS\BOB\DOWNLOADS\TOOL.EXE`,
+		`\Device\HarddiskVolume3\Users\bob\Downloads\tool.exe`,
+	} {
+		if got := volumes.resolvePath(path); !strings.EqualFold(got, `C:\Users\bob\Downloads\tool.exe`) || got[0] != 'C' {
+			t.Errorf("resolvePath(%q) = %q", path, got)
+		}
+	}
+
+	for path, want := range map[string]string{
+		`\\?\Volume{6F1E2D3C-0000-0000-0000-100000000000}\Tools\a.exe`: `D:\Tools\a.exe`,
+		`\??\Volume{6f1e2d3c-0000-0000-0000-100000000000}\Tools\a.exe`: `D:\Tools\a.exe`,
+		// 未知的卷保留设备路径，不与其他卷上的同名路径合并
+		`\Device\HarddiskVolume5\Tools\a.exe`:            `\Device\HarddiskVolume5\Tools\a.exe`,
+		`\VOLUME{01d9a1b2c3d4e5f6-0badf00d}\TOOLS\A.EXE`: `\VOLUME{01d9a1b2c3d4e5f6-0badf00d}\TOOLS\A.EXE`,
+		`\Device\HarddiskVolume3`:                        "",
+		`\VOLUME{01d9a1b2c3d4e5f6-1234abcd}\`:            "",
+		`%windir%\system32\cmd.exe`:                      `%windir%\system32\cmd.exe`,
+	} {
+		if got := volumes.resolvePath(path); got != want {
+			t.Errorf("resolvePath(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	var unknown *volumeMap
+	if got := unknown.resolvePath(`\??\c:\a.exe`); got != `C:\a.exe` {
+		t.Errorf("nil map: %q", got)
+	}
+}
+
+func TestCorrelateExecutions(t *testing.T) {
+	t1 := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	t3 := t1.Add(2 * time.Hour)
+	hash := "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"
+
+	records := correlateExecutions([]executionArtifact{
+		{Source: artifactPrefetch, Path: `\VOLUME{01d9a1b2c3d4e5f6-1234abcd}\USERS\BOB\DOWNLOADS\TOOL.EXE`, Times: []time.Time{t2, t1}, RunCount: 2},
+		{Source: artifactBAM, Path: `\Device\HarddiskVolume3\Users\bob\Downloads\tool.exe`, Times: []time.Time{t2}, User: "S-1-5-21-1-2-3-1001"},
+		{Source: artifactAmcache, Path: `c:\users\bob\downloads\tool.exe`, SHA1: hash, Times: []time.Time{t1}},
+		// 同一文件被复制到另一个目录后运行
+		{Source: artifactProcessCreation, Path: `C:\ProgramData\svc\tool.exe`, Times: []time.Time{t3}, RunCount: 1, User: `CORP\bob`},
+		{Source: artifactAmcache, Path: `c:\programdata\svc\tool.exe`, SHA1: hash},
+		{Source: artifactShimcache, Path: `C:\Windows\System32\cmd.exe`},
+		{Source: artifactUserAssist, Path: `C:\Program Files\App\app.exe`, Times: []time.Time{t1}, RunCount: 5},
+		{Source: artifactProcessCreation, Path: `C:\Program Files\App\app.exe`, Times: []time.Time{t2}, RunCount: 1},
+		{Source: artifactProcessCreation, Path: ""},
+	}, testVolumes())
+	if len(records) != 3 {
+		t.Fatalf("records = %d, want 3: %+v", len(records), records)
+	}
+
+	want := executionRecord{
+		Paths:            []string{`c:\users\bob\downloads\tool.exe`, `C:\ProgramData\svc\tool.exe`},
+		SHA1:             []string{hash},
+		FirstSeen:        t1,
+		LastSeen:         t3,
+		RunCount:         2,
+		Users:            []string{`CORP\bob`, "S-1-5-21-1-2-3-1001"},
+		Sources:          []string{artifactPrefetch, artifactAmcache, artifactBAM, artifactProcessCreation},
+		UserWritableOnly: true,
+	}
+	if !reflect.DeepEqual(records[0], want) {
+		t.Errorf("tool.exe:\n%+v\nwant\n%+v", records[0], want)
+	}
+	if flags := records[0].Flags(); len(flags) != 1 {
+		t.Errorf("tool.exe flags = %q", flags)
+	}
+
+	app := records[1]
+	if app.Path() != `C:\Program Files\App\app.exe` || app.RunCount != 5 || !app.LastSeen.Equal(t2) || app.UserWritableOnly {
+		t.Errorf("app.exe = %+v", app)
+	}
+	if !reflect.DeepEqual(app.Sources, []string{artifactUserAssist, artifactProcessCreation}) {
+		t.Errorf("app.exe sources = %q", app.Sources)
+	}
+
+	cmd := records[2]
+	if !cmd.SingleSource() || !cmd.LastSeen.IsZero() || cmd.FirstSeenText() != "-" {
+		t.Errorf("cmd.exe = %+v", cmd)
+	}
+}
+
+func TestCorrelateExecutionsVolumes(t *testing.T) {
+	t1 := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	hash := "a94a8fe5ccb19ba61c4c0873d391e987982fbbd3"
+	volumes := newVolumeMap()
+	volumes.addSerial(0x5555aaaa, "E:")
+
+	records := correlateExecutions([]executionArtifact{
+		// Prefetch的卷信息给出设备路径的序列号，对应到E:
+		{Source: artifactPrefetch, Path: `\DEVICE\HARDDISKVOLUME9\TOOLS\X.EXE`, Times: []time.Time{t1}, RunCount: 1, Serial: 0x5555aaaa},
+		{Source: artifactBAM, Path: `\Device\HarddiskVolume9\Tools\x.exe`, Times: []time.Time{t1}},
+		{Source: artifactAmcache, Path: `e:\tools\x.exe`},
+		// 另一个卷上的同名路径，没有SHA1时不合并
+		{Source: artifactBAM, Path: `\Device\HarddiskVolume4\Tools\x.exe`, Times: []time.Time{t1}},
+		// 未知卷上的路径SHA1相同时合并
+		{Source: artifactAmcache, Path: `f:\backup\y.exe`, SHA1: hash},
+		{Source: artifactAmcache, Path: `\Device\HarddiskVolume6\Backup\y.exe`, SHA1: hash},
+	}, volumes)
+
+	var got [][]string
+	for _, r := range records {
+		got = append(got, r.Paths)
+	}
+	want := [][]string{
+		{`\Device\HarddiskVolume4\Tools\x.exe`},
+		{`e:\tools\x.exe`},
+		{`f:\backup\y.exe`, `\Device\HarddiskVolume6\Backup\y.exe`},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths = %q, want %q", got, want)
+	}
+	if len(records) == 3 && !reflect.DeepEqual(records[1].Sources, []string{artifactPrefetch, artifactAmcache, artifactBAM}) {
+		t.Errorf("x.exe sources = %q", records[1].Sources)
+	}
+}
@@ -0,0 +1,336 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// firewallLogDir Windows防火墙日志所在的目录，由 -firewall-dir 指定，Windows上为空时读取默认的日志目录
+var firewallLogDir string
+
+// 防火墙日志中的动作和方向
+const (
+	firewallAllow   = "ALLOW"
+	firewallDrop    = "DROP"
+	firewallSend    = "SEND"
+	firewallReceive = "RECEIVE"
+)
+
+// 罕见出站目标最多列出的条数
+const maxFirewallDestinations = 50
+
+// firewallRecord pfirewall.log中的一条连接记录
+type firewallRecord struct {
+	Time     time.Time
+	Action   string
+	Protocol string
+	SrcIP    string
+	DstIP    string
+	SrcPort  int
+	DstPort  int
+	Path     string
+	PID      string
+}
+
+// String 证据中的一行
+func (r firewallRecord) String() string {
+	line := fmt.Sprintf("%s %s %s %s -> %s %s", formatReportTime(r.Time), r.Action, r.Protocol,
+		firewallEndpoint(r.SrcIP, r.SrcPort), firewallEndpoint(r.DstIP, r.DstPort), r.Path)
+	if r.PID != "" {
+		line += " pid=" + r.PID
+	}
+	return line
+}
+
+func firewallEndpoint(ip string, port int) string {
+	if port == 0 {
+		return ip
+	}
+	return net.JoinHostPort(ip, strconv.Itoa(port))
+}
+
+// parseFirewallLog 解析pfirewall.log，返回连接记录和无法解析的行数。
+// 日志中的时间为记录日志的主机的本地时间，离线分析时按分析机的时区解释
+func parseFirewallLog(r io.Reader) ([]firewallRecord, int, error) {
+	var records []firewallRecord
+	skipped, err := scanW3CLog(r, func(entry map[string]string) bool {
+		t, err := time.ParseInLocation("2006-01-02 15:04:05", entry["date"]+" "+entry["time"], time.Local)
+		if err != nil {
+			return false
+		}
+		record := firewallRecord{
+			Time:     t,
+			Action:   strings.ToUpper(entry["action"]),
+			Protocol: strings.ToUpper(entry["protocol"]),
+			SrcIP:    entry["src-ip"],
+			DstIP:    entry["dst-ip"],
+			Path:     strings.ToUpper(entry["path"]),
+			PID:      entry["pid"],
+		}
+		// 连接记录之外还有INFO-EVENTS-LOST等没有地址的记录
+		if record.Action != firewallAllow && record.Action != firewallDrop {
+			return true
+		}
+		record.SrcPort, _ = strconv.Atoi(entry["src-port"])
+		record.DstPort, _ = strconv.Atoi(entry["dst-port"])
+		records = append(records, record)
+		return true
+	})
+	return records, skipped, err
+}
+
+// loadFirewallLogs 读取目录中的pfirewall.log及轮转出的pfirewall.log.old，按时间排序
+func loadFirewallLogs(ctx context.Context, dir string) ([]firewallRecord, logFileStats, error) {
+	var stats logFileStats
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, stats, err
+	}
+	var records []firewallRecord
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, stats, ctx.Err()
+		}
+		if entry.IsDir() || !strings.Contains(strings.ToLower(entry.Name()), ".log") {
+			continue
+		}
+		f, err := os.Open(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, stats, err
+		}
+		list, skipped, err := parseFirewallLog(f)
+		f.Close()
+		if err != nil {
+			return nil, stats, fmt.Errorf("%s: %v", entry.Name(), err)
+		}
+		records = append(records, list...)
+		stats.files++
+		stats.skipped += skipped
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time.Before(records[j].Time) })
+	return records, stats, nil
+}
+
+// analyzeFirewallLogs 分析防火墙日志中的端口扫描、大量被阻止的入站连接、可疑端口上允许的连接和罕见的出站目标
+func analyzeFirewallLogs(ctx context.Context, results *[]CheckResult, dir string) {
+	category := tr("防火墙日志分析")
+
+	records, stats, err := loadFirewallLogs(ctx, dir)
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取防火墙日志失败: %s"), dir), err)
+		return
+	}
+	if stats.files == 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("未找到防火墙日志文件: %s"), dir), SeverityInfo, StatusOK,
+			tr("防火墙默认不记录连接，需在高级安全Windows Defender防火墙的属性中启用日志记录\n"))
+		return
+	}
+	actions := make(map[string]int)
+	for _, r := range records {
+		actions[r.Action+" "+r.Path]++
+	}
+	details := fmt.Sprintf(tr("日志文件: %d 个\n无法解析的行: %d\n"), stats.files, stats.skipped)
+	for _, key := range []string{"ALLOW SEND", "ALLOW RECEIVE", "DROP SEND", "DROP RECEIVE"} {
+		details += fmt.Sprintf("%s: %d\n", key, actions[key])
+	}
+	if len(records) > 0 {
+		details += fmt.Sprintf(tr("时间范围: %s 至 %s\n"),
+			formatReportTime(records[0].Time), formatReportTime(records[len(records)-1].Time))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("已分析 %d 条防火墙记录"), len(records)), SeverityInfo, StatusOK, details)
+
+	var inbound, outbound []firewallRecord
+	for _, r := range records {
+		switch r.Path {
+		case firewallReceive:
+			inbound = append(inbound, r)
+		case firewallSend:
+			outbound = append(outbound, r)
+		}
+	}
+	detectPortScans(results, category, inbound)
+	detectBlockedInbound(results, category, inbound)
+	detectSuspiciousPortTraffic(results, category, records)
+	detectRareDestinations(results, category, outbound)
+}
+
+// detectPortScans 同一来源在时间窗口内访问的不同本机端口数达到阈值
+func detectPortScans(results *[]CheckResult, category string, inbound []firewallRecord) {
+	window := config.Firewall.Window
+	var connections []firewallRecord
+	for _, r := range inbound {
+		// ICMP等没有端口的记录不计入
+		if r.DstPort != 0 {
+			connections = append(connections, r)
+		}
+	}
+	bySource := groupBy(connections, func(r firewallRecord) string { return r.SrcIP })
+	for _, source := range slices.Sorted(maps.Keys(bySource)) {
+		list := bySource[source]
+		// 滑动窗口内不同目标端口的数量
+		ports := make(map[int]int)
+		bestStart, bestEnd, best := 0, 0, 0
+		start := 0
+		for end, r := range list {
+			ports[r.DstPort]++
+			for r.Time.Sub(list[start].Time) > window {
+				if ports[list[start].DstPort]--; ports[list[start].DstPort] == 0 {
+					delete(ports, list[start].DstPort)
+				}
+				start++
+			}
+			if len(ports) > best {
+				bestStart, bestEnd, best = start, end+1, len(ports)
+			}
+		}
+		if best < config.Firewall.PortScanThreshold {
+			continue
+		}
+		burst := list[bestStart:bestEnd]
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("疑似端口扫描: 来源 %s 在%v内访问 %d 个端口"), source, window, best),
+			SeverityWarning, StatusAbnormal,
+			firewallPorts(burst)+firewallActions(burst),
+			firewallTimeline(burst)...)
+	}
+}
+
+// detectBlockedInbound 同一来源被阻止的入站连接次数达到阈值
+func detectBlockedInbound(results *[]CheckResult, category string, inbound []firewallRecord) {
+	var dropped []firewallRecord
+	for _, r := range inbound {
+		if r.Action == firewallDrop {
+			dropped = append(dropped, r)
+		}
+	}
+	bySource := groupBy(dropped, func(r firewallRecord) string { return r.SrcIP })
+	for _, source := range slices.Sorted(maps.Keys(bySource)) {
+		list := bySource[source]
+		if len(list) < config.Firewall.BlockedInboundThreshold {
+			continue
+		}
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("大量被阻止的入站连接: 来源 %s 共 %d 次"), source, len(list)),
+			SeverityWarning, StatusAbnormal,
+			firewallPorts(list)+fmt.Sprintf(tr("时间范围: %s 至 %s\n"),
+				formatReportTime(list[0].Time), formatReportTime(list[len(list)-1].Time)),
+			firewallTimeline(list)...)
+	}
+}
+
+// detectSuspiciousPortTraffic 允许的连接中，入站访问本机的可疑端口或出站连接远端的可疑端口
+func detectSuspiciousPortTraffic(results *[]CheckResult, category string, records []firewallRecord) {
+	var matched []firewallRecord
+	for _, r := range records {
+		if r.Action != firewallAllow || (r.Path != firewallSend && r.Path != firewallReceive) {
+			continue
+		}
+		if _, ok := config.SuspiciousPorts[r.DstPort]; ok {
+			matched = append(matched, r)
+		}
+	}
+	byPort := groupBy(matched, func(r firewallRecord) string { return fmt.Sprintf("%s %05d", r.Path, r.DstPort) })
+	for _, key := range slices.Sorted(maps.Keys(byPort)) {
+		list := byPort[key]
+		port, service := list[0].DstPort, config.SuspiciousPorts[list[0].DstPort]
+		var description, peers string
+		if list[0].Path == firewallReceive {
+			description = fmt.Sprintf(tr("允许的入站连接访问可疑端口 %d (%s): %d 次"), port, service, len(list))
+			peers = listValues(list, tr("来源"), func(r firewallRecord) string { return r.SrcIP })
+		} else {
+			description = fmt.Sprintf(tr("允许的出站连接访问可疑端口 %d (%s): %d 次"), port, service, len(list))
+			peers = listValues(list, tr("目标"), func(r firewallRecord) string { return r.DstIP })
+		}
+		addCheckResult(results, category, description, SeverityWarning, StatusAbnormal, peers, firewallTimeline(list)...)
+	}
+}
+
+// detectRareDestinations 允许的出站连接中，在日志里出现次数很少的公网目标
+func detectRareDestinations(results *[]CheckResult, category string, outbound []firewallRecord) {
+	var allowed []firewallRecord
+	for _, r := range outbound {
+		if ip := net.ParseIP(r.DstIP); r.Action == firewallAllow && ip != nil && isPublicIP(ip) {
+			allowed = append(allowed, r)
+		}
+	}
+	byDestination := groupBy(allowed, func(r firewallRecord) string { return r.DstIP })
+
+	var rare []firewallRecord
+	for _, destination := range slices.Sorted(maps.Keys(byDestination)) {
+		list := byDestination[destination]
+		if len(list) > config.Firewall.RareDestinationHits {
+			continue
+		}
+		rare = append(rare, list[0])
+	}
+	if len(rare) == 0 {
+		return
+	}
+	sort.SliceStable(rare, func(i, j int) bool { return rare[i].Time.Before(rare[j].Time) })
+	var evidence []string
+	for _, r := range rare {
+		evidence = append(evidence, fmt.Sprintf(tr("%s (%d 次)"), r, len(byDestination[r.DstIP])))
+	}
+	addCheckResult(results, category,
+		fmt.Sprintf(tr("罕见的出站目标: %d 个公网地址只出现不超过 %d 次"), len(rare), config.Firewall.RareDestinationHits),
+		SeverityWarning, StatusAbnormal,
+		fmt.Sprintf(tr("出站公网目标总数: %d\n"), len(byDestination)),
+		elideLines(evidence, maxFirewallDestinations)...)
+}
+
+// isPublicIP 排除私有、回环、链路本地、组播和未指定地址
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsMulticast() && !ip.IsUnspecified() && !ip.Equal(net.IPv4bcast)
+}
+
+// firewallPorts 列出访问的目标端口
+func firewallPorts(records []firewallRecord) string {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, r := range records {
+		if !seen[r.DstPort] {
+			seen[r.DstPort] = true
+			ports = append(ports, r.DstPort)
+		}
+	}
+	sort.Ints(ports)
+	values := make([]string, len(ports))
+	for i, port := range ports {
+		values[i] = strconv.Itoa(port)
+	}
+	return fmt.Sprintf(tr("目标端口: %s\n"), strings.Join(limitList(values), ", "))
+}
+
+// firewallActions 统计允许和阻止的次数
+func firewallActions(records []firewallRecord) string {
+	allowed := 0
+	for _, r := range records {
+		if r.Action == firewallAllow {
+			allowed++
+		}
+	}
+	return fmt.Sprintf(tr("允许: %d 次, 阻止: %d 次\n"), allowed, len(records)-allowed)
+}
+
+// firewallTimeline 按时间列出记录，记录较多时只保留开头和结尾
+func firewallTimeline(records []firewallRecord) []string {
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = r.String()
+	}
+	return elideLines(lines, maxEventEvidence)
+}
@@ -0,0 +1,103 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const firewallLogHeader = `#Version: 1.5
+#Software: Microsoft Windows Firewall
+#Time Format: Local
+#Fields: date time action protocol src-ip dst-ip src-port dst-port size tcpflags tcpsyn tcpack tcpwin icmptype icmpcode info path pid
+`
+
+func TestParseFirewallLog(t *testing.T) {
+	log := firewallLogHeader +
+		"2024-03-01 08:00:01 DROP TCP 203.0.113.9 10.0.0.5 51234 445 52 S 12345 0 64240 - - - RECEIVE 4\r\n" +
+		"2024-03-01 08:00:02 ALLOW ICMP 10.0.0.5 10.0.0.1 - - 60 - - - - 8 0 - SEND 0\r\n" +
+		"2024-03-01 08:00:03 INFO-EVENTS-LOST - - - - - - - - - - - - 12 - -\r\n" +
+		"2024-03-01 08:00:04 ALLOW TCP 10.0.0.5\r\n"
+
+	records, skipped, err := parseFirewallLog(strings.NewReader(log))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 1 || len(records) != 2 {
+		t.Fatalf("skipped = %d, records = %d, want 1 and 2", skipped, len(records))
+	}
+	want := firewallRecord{
+		Time:     time.Date(2024, 3, 1, 8, 0, 1, 0, time.Local),
+		Action:   firewallDrop,
+		Protocol: "TCP",
+		SrcIP:    "203.0.113.9",
+		DstIP:    "10.0.0.5",
+		SrcPort:  51234,
+		DstPort:  445,
+		Path:     firewallReceive,
+		PID:      "4",
+	}
+	if records[0] != want {
+		t.Errorf("records[0] = %+v, want %+v", records[0], want)
+	}
+	if got := records[1].String(); !strings.HasSuffix(got, "ALLOW ICMP 10.0.0.5 -> 10.0.0.1 SEND pid=0") {
+		t.Errorf("records[1] = %q", got)
+	}
+}
+
+func TestAnalyzeFirewallLogs(t *testing.T) {
+	var log strings.Builder
+	log.WriteString(firewallLogHeader)
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.Local)
+	add := func(offset time.Duration, action, src, dst string, dstPort int, path string) {
+		fmt.Fprintf(&log, "%s %s TCP %s %s 50000 %d 0 - 0 0 0 - - - %s 1234\n",
+			start.Add(offset).Format("2006-01-02 15:04:05"), action, src, dst, dstPort, path)
+	}
+	// 扫描30个端口
+	for port := 1; port <= 30; port++ {
+		add(time.Duration(port)*time.Second, "DROP", "198.51.100.7", "10.0.0.5", port, "RECEIVE")
+	}
+	// 反复连接同一端口被阻止
+	for i := 0; i < 200; i++ {
+		add(time.Hour+time.Duration(i)*time.Second, "DROP", "203.0.113.20", "10.0.0.5", 3389, "RECEIVE")
+	}
+	add(2*time.Hour, "ALLOW", "10.0.0.5", "192.0.2.66", 4444, "SEND")
+	for i := 0; i < 5; i++ {
+		add(3*time.Hour+time.Duration(i)*time.Minute, "ALLOW", "10.0.0.5", "8.8.8.8", 443, "SEND")
+	}
+	add(4*time.Hour, "ALLOW", "10.0.0.5", "10.0.0.1", 443, "SEND")
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "pfirewall.log"), []byte(log.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := config.SuspiciousPorts
+	config.SuspiciousPorts = PortMap{4444: "Metasploit"}
+	defer func() { config.SuspiciousPorts = saved }()
+
+	var results []CheckResult
+	analyzeFirewallLogs(context.Background(), &results, dir)
+
+	var abnormal []string
+	for _, r := range results {
+		if r.Status == StatusAbnormal {
+			abnormal = append(abnormal, r.Description)
+		}
+	}
+	want := []string{
+		"疑似端口扫描: 来源 198.51.100.7 在5m0s内访问 30 个端口",
+		"大量被阻止的入站连接: 来源 203.0.113.20 共 200 次",
+		"允许的出站连接访问可疑端口 4444 (Metasploit): 1 次",
+		"罕见的出站目标: 1 个公网地址只出现不超过 2 次",
+	}
+	if strings.Join(abnormal, "\n") != strings.Join(want, "\n") {
+		t.Errorf("abnormal results:\n%s\nwant:\n%s", strings.Join(abnormal, "\n"), strings.Join(want, "\n"))
+	}
+}
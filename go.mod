@@ -8,6 +8,7 @@ require (
 	github.com/shirou/gopsutil/v3 v3.23.7
 	golang.org/x/sys v0.11.0
 	golang.org/x/text v0.26.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+// 支持的输出语言，第一个为默认语言
+var supportedLanguages = []language.Tag{language.SimplifiedChinese, language.English}
+
+// 消息目录，以中文原文为键。中文输出直接使用原文，其他语言缺少译文时回退为原文
+var messages = catalog.NewBuilder(catalog.Fallback(language.SimplifiedChinese))
+
+// 当前输出语言
+var currentLanguage = language.SimplifiedChinese
+
+func init() {
+	for key, text := range englishMessages {
+		if err := messages.SetString(language.English, key, text); err != nil {
+			panic(fmt.Sprintf("无效的译文 %q: %v", key, err))
+		}
+	}
+}
+
+// setLanguage 设置输出语言，支持 zh、en 及 zh-CN、en-US 等形式
+func setLanguage(lang string) error {
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return fmt.Errorf(tr("不支持的语言: %s"), lang)
+	}
+	_, index, confidence := language.NewMatcher(supportedLanguages).Match(tag)
+	if confidence == language.No {
+		return fmt.Errorf(tr("不支持的语言: %s"), lang)
+	}
+	currentLanguage = supportedLanguages[index]
+	return nil
+}
+
+// langFromArgs 在解析命令行参数前取出 -lang 的值，使 -h 的帮助信息也能使用指定语言
+func langFromArgs(args []string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if value, ok := strings.CutPrefix(name, "lang="); ok {
+			return value, true
+		}
+		if name == "lang" && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+// messageRenderer 接收消息目录中的译文原文，格式化仍由fmt完成，
+// 避免message.Printer对端口号、PID等数字添加千位分隔符
+type messageRenderer struct {
+	text strings.Builder
+}
+
+func (r *messageRenderer) Render(s string)       { r.text.WriteString(s) }
+func (r *messageRenderer) Arg(i int) interface{} { return nil }
+
+// tr 返回中文原文在当前语言下的译文，可作为fmt格式串使用
+func tr(key string) string {
+	if currentLanguage == language.SimplifiedChinese {
+		return key
+	}
+	var r messageRenderer
+	if err := messages.Context(currentLanguage, &r).Execute(key); err != nil {
+		return key
+	}
+	return r.text.String()
+}
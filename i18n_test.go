@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestLangFromArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+		ok   bool
+	}{
+		{[]string{"-all", "-lang", "en"}, "en", true},
+		{[]string{"--lang=en-US", "-h"}, "en-US", true},
+		{[]string{"-lang"}, "", false},
+		{[]string{"-all", "--", "-lang", "en"}, "", false},
+		{[]string{"lang", "en"}, "", false},
+		{nil, "", false},
+	}
+	for _, tt := range tests {
+		if got, ok := langFromArgs(tt.args); got != tt.want || ok != tt.ok {
+			t.Errorf("langFromArgs(%q) = %q, %v; want %q, %v", tt.args, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestSetLanguage(t *testing.T) {
+	defer func() { currentLanguage = language.SimplifiedChinese }()
+	for lang, want := range map[string]language.Tag{
+		"zh": language.SimplifiedChinese, "zh-CN": language.SimplifiedChinese,
+		"en": language.English, "en-US": language.English, "en-GB": language.English,
+	} {
+		currentLanguage = language.SimplifiedChinese
+		if err := setLanguage(lang); err != nil || currentLanguage != want {
+			t.Errorf("setLanguage(%q) = %v, language %v; want %v", lang, err, currentLanguage, want)
+		}
+	}
+	for _, lang := range []string{"fr", "not a language"} {
+		if err := setLanguage(lang); err == nil {
+			t.Errorf("setLanguage(%q): expected error", lang)
+		}
+	}
+}
+
+func TestTr(t *testing.T) {
+	defer func() { currentLanguage = language.SimplifiedChinese }()
+	if got := tr("运行所有检查"); got != "运行所有检查" {
+		t.Errorf("zh: tr = %q", got)
+	}
+
+	currentLanguage = language.English
+	if got := tr("运行所有检查"); got != "run all checks" {
+		t.Errorf("en: tr = %q", got)
+	}
+	// 译文中的格式动词由fmt处理，数字不加千位分隔符
+	if got := fmt.Sprintf(tr("错误: %v\n"), 12345); got != "Error: 12345\n" {
+		t.Errorf("en: formatted = %q", got)
+	}
+	// 缺少译文时回退为中文原文
+	if got := tr("没有译文的消息 %d"); got != "没有译文的消息 %d" {
+		t.Errorf("en fallback = %q", got)
+	}
+}
@@ -0,0 +1,388 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// iisLogDir IIS日志所在的目录，由 -iis-dir 指定，Windows上为空时读取IIS的默认日志目录
+var iisLogDir string
+
+// 每个来源的活动时间线最多列出的请求数
+const maxIISTimeline = 40
+
+// 时间线中查询字符串的最大长度
+const maxIISQuery = 200
+
+// 可能是Web Shell的脚本页面扩展名
+var iisScriptExts = map[string]bool{
+	".aspx": true, ".ashx": true, ".asmx": true, ".asp": true, ".cer": true, ".php": true,
+}
+
+// 请求中的攻击载荷，在URL解码后的路径和查询字符串上匹配
+var (
+	iisSQLInjection = regexp.MustCompile(`(?i)\bunion\b.{0,40}\bselect\b|\bselect\b.{1,100}\bfrom\b|` +
+		`\b(and|or)\b\s*\(?\s*['"]?\d+['"]?\s*=\s*['"]?\d+|'\s*(or|and)\s+['"]?\w*['"]?\s*=|` +
+		`\b(sleep|benchmark|pg_sleep)\s*\(|\bwaitfor\s+delay\b|\bxp_cmdshell\b|\binformation_schema\b|` +
+		`@@version|;\s*(drop|exec|execute|declare|shutdown)\b|\bchar\s*\(\s*\d+\s*\)|\bconvert\s*\(\s*int\b`)
+	iisPathTraversal = regexp.MustCompile(`(?i)\.\.[/\\]|[/\\]\.\.|/etc/passwd|win\.ini|boot\.ini|%c0%ae|%c1%9c`)
+)
+
+// iisRequest W3C扩展格式日志中的一条请求
+type iisRequest struct {
+	Time      time.Time
+	ClientIP  string
+	Method    string
+	Stem      string
+	Query     string
+	Status    int
+	UserAgent string
+	File      string
+}
+
+// isError 4xx或5xx响应
+func (r iisRequest) isError() bool { return r.Status >= 400 }
+
+// isSuccess 2xx响应
+func (r iisRequest) isSuccess() bool { return r.Status >= 200 && r.Status < 300 }
+
+// String 时间线中的一行
+func (r iisRequest) String() string {
+	target := r.Stem
+	if r.Query != "" {
+		query := r.Query
+		if len(query) > maxIISQuery {
+			query = query[:maxIISQuery] + "..."
+		}
+		target += "?" + query
+	}
+	line := fmt.Sprintf("%s %s %s %d", formatReportTime(r.Time), r.Method, target, r.Status)
+	if r.UserAgent != "" {
+		line += " " + r.UserAgent
+	}
+	return line
+}
+
+// parseIISLog 解析W3C扩展格式的IIS日志，返回请求和无法解析的行数
+func parseIISLog(r io.Reader, file string) ([]iisRequest, int, error) {
+	var requests []iisRequest
+	skipped, err := scanW3CLog(r, func(record map[string]string) bool {
+		request, ok := newIISRequest(record)
+		if ok {
+			request.File = file
+			requests = append(requests, request)
+		}
+		return ok
+	})
+	return requests, skipped, err
+}
+
+// newIISRequest 从日志记录中取出请求，日志中的时间为UTC
+func newIISRequest(record map[string]string) (iisRequest, bool) {
+	t, err := time.Parse("2006-01-02 15:04:05", record["date"]+" "+record["time"])
+	if err != nil {
+		return iisRequest{}, false
+	}
+	status, err := strconv.Atoi(record["sc-status"])
+	if err != nil {
+		return iisRequest{}, false
+	}
+	return iisRequest{
+		Time:      t,
+		ClientIP:  record["c-ip"],
+		Method:    strings.ToUpper(record["cs-method"]),
+		Stem:      record["cs-uri-stem"],
+		Query:     record["cs-uri-query"],
+		Status:    status,
+		UserAgent: strings.ReplaceAll(record["cs(user-agent)"], "+", " "),
+	}, true
+}
+
+// loadIISLogs 读取目录（包括W3SVC1等站点子目录）中所有 .log 文件的请求，按时间排序
+func loadIISLogs(ctx context.Context, dir string) ([]iisRequest, logFileStats, error) {
+	var requests []iisRequest
+	var stats logFileStats
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(file), ".log") {
+			return nil
+		}
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		rel, _ := filepath.Rel(dir, file)
+		list, skipped, err := parseIISLog(f, rel)
+		if err != nil {
+			return fmt.Errorf("%s: %v", rel, err)
+		}
+		requests = append(requests, list...)
+		stats.files++
+		stats.skipped += skipped
+		return nil
+	})
+	sort.SliceStable(requests, func(i, j int) bool { return requests[i].Time.Before(requests[j].Time) })
+	return requests, stats, err
+}
+
+// analyzeIISLogs 分析IIS日志中的Web Shell访问、注入和遍历攻击、扫描器及错误响应激增，并列出主要来源及其活动时间线
+func analyzeIISLogs(ctx context.Context, results *[]CheckResult, dir string) {
+	category := tr("IIS日志分析")
+
+	requests, stats, err := loadIISLogs(ctx, dir)
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取IIS日志失败: %s"), dir), err)
+		return
+	}
+	if stats.files == 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("未找到IIS日志文件: %s"), dir), SeverityInfo, StatusOK, "")
+		return
+	}
+	details := fmt.Sprintf(tr("日志文件: %d 个\n无法解析的行: %d\n"), stats.files, stats.skipped)
+	if len(requests) > 0 {
+		details += fmt.Sprintf(tr("时间范围: %s 至 %s\n"),
+			formatReportTime(requests[0].Time), formatReportTime(requests[len(requests)-1].Time))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("已分析 %d 条IIS请求"), len(requests)), SeverityInfo, StatusOK, details)
+
+	// 有发现的来源在最后列出完整的活动时间线
+	flagged := make(map[string]bool)
+	detectIISWebShells(results, category, requests, flagged)
+	detectIISPayloads(results, category, requests, flagged)
+	detectIISScanners(results, category, requests, flagged)
+	detectIISErrorBursts(results, category, requests, flagged)
+	reportIISTopClients(results, category, requests)
+	reportIISTimelines(results, category, requests, flagged)
+}
+
+// detectIISWebShells 很少被访问的脚本页面收到成功的POST请求，常见于上传后通过POST传递命令的Web Shell
+func detectIISWebShells(results *[]CheckResult, category string, requests []iisRequest, flagged map[string]bool) {
+	byPage := groupBy(requests, func(r iisRequest) string { return strings.ToLower(r.Stem) })
+	for _, page := range slices.Sorted(maps.Keys(byPage)) {
+		list := byPage[page]
+		if !iisScriptExts[path.Ext(page)] || len(list) > config.IIS.RarePageHits {
+			continue
+		}
+		var posts []iisRequest
+		for _, r := range list {
+			if r.Method == "POST" && r.isSuccess() {
+				posts = append(posts, r)
+			}
+		}
+		if len(posts) == 0 {
+			continue
+		}
+		for _, r := range list {
+			flagged[r.ClientIP] = true
+		}
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("疑似Web Shell: %s 共被请求 %d 次，其中成功的POST %d 次"), list[0].Stem, len(list), len(posts)),
+			SeverityCritical, StatusAbnormal,
+			iisDetails(list, tr("来源"), func(r iisRequest) string { return r.ClientIP }),
+			iisTimeline(list, maxEventEvidence)...)
+	}
+}
+
+// detectIISPayloads 请求中的SQL注入和路径遍历载荷，按来源汇总
+func detectIISPayloads(results *[]CheckResult, category string, requests []iisRequest, flagged map[string]bool) {
+	payloads := []struct {
+		name    string
+		pattern *regexp.Regexp
+	}{
+		{tr("SQL注入"), iisSQLInjection},
+		{tr("路径遍历"), iisPathTraversal},
+	}
+	for _, payload := range payloads {
+		var matched []iisRequest
+		for _, r := range requests {
+			raw := r.Stem + "?" + r.Query
+			if payload.pattern.MatchString(raw) || payload.pattern.MatchString(iisUnescape(raw)) {
+				matched = append(matched, r)
+			}
+		}
+		byClient := groupBy(matched, func(r iisRequest) string { return r.ClientIP })
+		for _, client := range slices.Sorted(maps.Keys(byClient)) {
+			list := byClient[client]
+			flagged[client] = true
+			details := iisDetails(list, tr("目标页面"), func(r iisRequest) string { return r.Stem }) +
+				fmt.Sprintf(tr("成功响应(2xx): %d 次\n"), countIISRequests(list, iisRequest.isSuccess))
+			addCheckResult(results, category,
+				fmt.Sprintf(tr("%s尝试: 来源 %s 共 %d 次请求"), payload.name, client, len(list)),
+				SeverityWarning, StatusAbnormal, details, iisTimeline(list, maxEventEvidence)...)
+		}
+	}
+}
+
+// iisUnescape URL解码，最多两次以还原双重编码
+func iisUnescape(s string) string {
+	for i := 0; i < 2 && strings.ContainsAny(s, "%+"); i++ {
+		decoded, err := url.QueryUnescape(s)
+		if err != nil {
+			break
+		}
+		s = decoded
+	}
+	return s
+}
+
+// detectIISScanners User-Agent中带有已知扫描工具关键字的来源
+func detectIISScanners(results *[]CheckResult, category string, requests []iisRequest, flagged map[string]bool) {
+	scanner := func(r iisRequest) string {
+		agent := strings.ToLower(r.UserAgent)
+		for _, keyword := range config.IIS.ScannerAgents {
+			if strings.Contains(agent, strings.ToLower(keyword)) {
+				return keyword
+			}
+		}
+		return ""
+	}
+
+	var matched []iisRequest
+	for _, r := range requests {
+		if scanner(r) != "" {
+			matched = append(matched, r)
+		}
+	}
+	byClient := groupBy(matched, func(r iisRequest) string { return r.ClientIP })
+	for _, client := range slices.Sorted(maps.Keys(byClient)) {
+		list := byClient[client]
+		flagged[client] = true
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("扫描工具: 来源 %s 共 %d 次请求"), client, len(list)),
+			SeverityWarning, StatusAbnormal,
+			listValues(list, tr("工具"), scanner)+iisDetails(list, "User-Agent", func(r iisRequest) string { return r.UserAgent }),
+			iisTimeline(list, maxEventEvidence)...)
+	}
+}
+
+// detectIISErrorBursts 同一来源在时间窗口内收到的4xx/5xx响应达到阈值，常见于目录爆破和漏洞探测
+func detectIISErrorBursts(results *[]CheckResult, category string, requests []iisRequest, flagged map[string]bool) {
+	window := config.IIS.Window
+	var failed []iisRequest
+	for _, r := range requests {
+		if r.isError() {
+			failed = append(failed, r)
+		}
+	}
+	byClient := groupBy(failed, func(r iisRequest) string { return r.ClientIP })
+	for _, client := range slices.Sorted(maps.Keys(byClient)) {
+		list := byClient[client]
+		start, end := 0, 0
+		for i, j := 0, 0; j < len(list); j++ {
+			for list[j].Time.Sub(list[i].Time) > window {
+				i++
+			}
+			if j+1-i > end-start {
+				start, end = i, j+1
+			}
+		}
+		if end-start < config.IIS.ErrorBurstThreshold {
+			continue
+		}
+		burst := list[start:end]
+		flagged[client] = true
+		addCheckResult(results, category,
+			fmt.Sprintf(tr("错误响应激增: 来源 %s 在%v内收到 %d 个4xx/5xx响应"), client, window, len(burst)),
+			SeverityWarning, StatusAbnormal,
+			iisStatusCounts(burst)+iisDetails(burst, tr("目标页面"), func(r iisRequest) string { return r.Stem }),
+			iisTimeline(burst, maxEventEvidence)...)
+	}
+}
+
+// iisStatusCounts 按状态码统计次数
+func iisStatusCounts(requests []iisRequest) string {
+	counts := make(map[int]int)
+	for _, r := range requests {
+		counts[r.Status]++
+	}
+	codes := make([]int, 0, len(counts))
+	for code := range counts {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = fmt.Sprintf("%d×%d", code, counts[code])
+	}
+	return fmt.Sprintf(tr("状态码: %s\n"), strings.Join(parts, ", "))
+}
+
+// reportIISTopClients 列出请求数最多的来源
+func reportIISTopClients(results *[]CheckResult, category string, requests []iisRequest) {
+	byClient := groupBy(requests, func(r iisRequest) string { return r.ClientIP })
+	clients := slices.Sorted(maps.Keys(byClient))
+	sort.SliceStable(clients, func(i, j int) bool { return len(byClient[clients[i]]) > len(byClient[clients[j]]) })
+	if len(clients) > config.IIS.TopClients {
+		clients = clients[:config.IIS.TopClients]
+	}
+
+	var evidence []string
+	for _, client := range clients {
+		list := byClient[client]
+		evidence = append(evidence, fmt.Sprintf(tr("%s: %d 次请求, %d 个错误响应, %s 至 %s"),
+			client, len(list), countIISRequests(list, iisRequest.isError),
+			formatReportTime(list[0].Time), formatReportTime(list[len(list)-1].Time)))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("请求最多的 %d 个来源"), len(clients)), SeverityInfo, StatusOK,
+		fmt.Sprintf(tr("来源总数: %d\n"), len(byClient)), evidence...)
+}
+
+// reportIISTimelines 列出有发现的来源的全部请求
+func reportIISTimelines(results *[]CheckResult, category string, requests []iisRequest, flagged map[string]bool) {
+	byClient := groupBy(requests, func(r iisRequest) string { return r.ClientIP })
+	for _, client := range slices.Sorted(maps.Keys(byClient)) {
+		if !flagged[client] {
+			continue
+		}
+		list := byClient[client]
+		addCheckResult(results, category, fmt.Sprintf(tr("来源 %s 的活动时间线"), client), SeverityInfo, StatusOK,
+			iisDetails(list, tr("日志文件"), func(r iisRequest) string { return r.File }),
+			iisTimeline(list, maxIISTimeline)...)
+	}
+}
+
+func countIISRequests(requests []iisRequest, match func(iisRequest) bool) int {
+	n := 0
+	for _, r := range requests {
+		if match(r) {
+			n++
+		}
+	}
+	return n
+}
+
+// iisDetails 列出请求中出现的不同取值及时间范围
+func iisDetails(requests []iisRequest, label string, value func(iisRequest) string) string {
+	return listDetails(requests, label, value, func(r iisRequest) time.Time { return r.Time })
+}
+
+// iisTimeline 按时间列出请求，请求较多时只保留开头和结尾
+func iisTimeline(requests []iisRequest, limit int) []string {
+	lines := make([]string, len(requests))
+	for i, r := range requests {
+		lines[i] = r.String()
+	}
+	return elideLines(lines, limit)
+}
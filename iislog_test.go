@@ -0,0 +1,106 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseIISLog(t *testing.T) {
+	log := strings.Join([]string{
+		"#Software: Microsoft Internet Information Services 10.0",
+		"#Version: 1.0",
+		"#Date: 2024-03-01 08:00:00",
+		"#Fields: date time s-ip cs-method cs-uri-stem cs-uri-query s-port cs-username c-ip cs(User-Agent) sc-status time-taken",
+		"2024-03-01 08:00:01 10.0.0.5 GET /default.aspx - 443 - 203.0.113.7 Mozilla/5.0+(Windows+NT+10.0) 200 15",
+		"2024-03-01 08:00:02 10.0.0.5 GET /broken",
+		// IIS重启后字段顺序改变
+		"#Fields: date time c-ip cs-method cs-uri-stem sc-status",
+		"2024-03-01 09:00:00 198.51.100.1 post /upload/a.ashx 200\r",
+		"2024-03-01 09:00:01 198.51.100.1 GET /x.aspx abc",
+	}, "\n")
+
+	requests, skipped, err := parseIISLog(strings.NewReader(log), "W3SVC1/u_ex240301.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 {
+		t.Errorf("skipped = %d, want 2", skipped)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(requests))
+	}
+
+	first := requests[0]
+	if !first.Time.Equal(time.Date(2024, 3, 1, 8, 0, 1, 0, time.UTC)) || first.ClientIP != "203.0.113.7" ||
+		first.Query != "" || first.Status != 200 || first.UserAgent != "Mozilla/5.0 (Windows NT 10.0)" ||
+		first.File != "W3SVC1/u_ex240301.log" {
+		t.Errorf("first = %+v", first)
+	}
+	second := requests[1]
+	if second.ClientIP != "198.51.100.1" || second.Method != "POST" || second.Stem != "/upload/a.ashx" || second.Status != 200 {
+		t.Errorf("second = %+v", second)
+	}
+}
+
+func TestAnalyzeIISLogs(t *testing.T) {
+	lines := []string{"#Fields: date time cs-method cs-uri-stem cs-uri-query c-ip cs(User-Agent) sc-status"}
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	add := func(offset time.Duration, method, stem, query, ip, agent string, status int) {
+		lines = append(lines, fmt.Sprintf("%s %s %s %s %s %s %d",
+			start.Add(offset).Format("2006-01-02 15:04:05"), method, stem, query, ip, agent, status))
+	}
+	// 正常访问
+	for i := 0; i < 20; i++ {
+		add(time.Duration(i)*time.Minute, "GET", "/default.aspx", "-", "192.0.2.10", "Mozilla/5.0", 200)
+		add(time.Duration(i)*time.Minute, "POST", "/login.aspx", "-", "192.0.2.11", "Mozilla/5.0", 200)
+	}
+	// 目录爆破
+	for i := 0; i < 60; i++ {
+		add(time.Hour+time.Duration(i)*time.Second, "GET", fmt.Sprintf("/dir%d/", i), "-", "203.0.113.5", "gobuster/3.6", 404)
+	}
+	add(2*time.Hour, "GET", "/product.aspx", "id=1%27+UNION+SELECT+password+FROM+users--", "203.0.113.6", "Mozilla/5.0", 500)
+	add(2*time.Hour, "GET", "/download.ashx", "file=..%252f..%252fweb.config", "203.0.113.6", "Mozilla/5.0", 200)
+	add(3*time.Hour, "POST", "/uploads/help.aspx", "-", "198.51.100.9", "-", 200)
+
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "W3SVC1"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "W3SVC1", "u_ex240301.log"), []byte(strings.Join(lines, "\r\n")), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []CheckResult
+	analyzeIISLogs(context.Background(), &results, dir)
+
+	var abnormal, timelines []string
+	for _, r := range results {
+		switch {
+		case r.Status == StatusAbnormal:
+			abnormal = append(abnormal, r.Description)
+		case strings.HasPrefix(r.Description, "来源 ") && strings.HasSuffix(r.Description, "的活动时间线"):
+			timelines = append(timelines, r.Description)
+		}
+	}
+	want := []string{
+		"疑似Web Shell: /uploads/help.aspx 共被请求 1 次，其中成功的POST 1 次",
+		"SQL注入尝试: 来源 203.0.113.6 共 1 次请求",
+		"路径遍历尝试: 来源 203.0.113.6 共 1 次请求",
+		"扫描工具: 来源 203.0.113.5 共 60 次请求",
+		"错误响应激增: 来源 203.0.113.5 在5m0s内收到 60 个4xx/5xx响应",
+	}
+	if strings.Join(abnormal, "\n") != strings.Join(want, "\n") {
+		t.Errorf("abnormal results:\n%s\nwant:\n%s", strings.Join(abnormal, "\n"), strings.Join(want, "\n"))
+	}
+	if len(timelines) != 3 {
+		t.Errorf("timelines = %q, want 3 flagged sources", timelines)
+	}
+}
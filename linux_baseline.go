@@ -0,0 +1,141 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+func init() {
+	registerCheck("baseline.password", "baseline", PrivilegeNone, checkPasswordQuality)
+	registerCheck("baseline.updates", "baseline", PrivilegeNone, checkSystemUpdates)
+	registerCheck("baseline.ssh", "baseline", PrivilegeNone, checkSSHConfig)
+}
+
+// 执行命令并将其输出作为信息类检查结果记录
+func addCommandOutputResult(ctx context.Context, results *[]CheckResult, category, description string, name string, args ...string) {
+	cmd := runCommand(ctx, name, args...)
+	output, err := cmd.Text()
+	if isNotPresent(err) {
+		addAbsentResult(results, category, description, fmt.Sprintf(tr("未找到命令: %s\n"), name))
+		return
+	}
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("%s获取失败"), description), err)
+		return
+	}
+	addCheckResult(results, category, description, SeverityInfo, StatusOK, output)
+}
+
+// readConfigValues 读取 "键 值" 或 "键 = 值" 形式的配置文件，忽略注释，键不区分大小写
+func readConfigValues(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(strings.Replace(line, "=", " ", 1))
+		if len(fields) < 2 {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+		// sshd_config中首次出现的配置生效
+		if _, ok := values[key]; !ok {
+			values[key] = strings.Join(fields[1:], " ")
+		}
+	}
+	return values, scanner.Err()
+}
+
+// 检查密码策略
+func checkPasswordQuality(ctx context.Context, results *[]CheckResult) {
+	category := tr("密码策略检查")
+
+	if values, err := readConfigValues("/etc/security/pwquality.conf"); err == nil {
+		var evidence []string
+		for key, value := range values {
+			evidence = append(evidence, fmt.Sprintf("%s = %s", key, value))
+		}
+		addCheckResult(results, category, tr("pwquality配置"), SeverityInfo, StatusOK, "/etc/security/pwquality.conf", evidence...)
+
+		var minlen int
+		if _, err := fmt.Sscan(values["minlen"], &minlen); err == nil && minlen < 8 {
+			addCheckResult(results, category, tr("密码最小长度小于8"), SeverityWarning, StatusAbnormal, fmt.Sprintf("minlen = %d", minlen))
+		}
+	}
+
+	values, err := readConfigValues("/etc/login.defs")
+	if err != nil {
+		addErrorResult(results, category, tr("读取/etc/login.defs失败"), err)
+		return
+	}
+	var evidence []string
+	for _, key := range []string{"pass_max_days", "pass_min_days", "pass_min_len", "pass_warn_age"} {
+		if value, ok := values[key]; ok {
+			evidence = append(evidence, fmt.Sprintf("%s = %s", strings.ToUpper(key), value))
+		}
+	}
+	if values["pass_max_days"] == "99999" {
+		addCheckResult(results, category, tr("未设置密码有效期"), SeverityWarning, StatusAbnormal, "/etc/login.defs", evidence...)
+	} else {
+		addCheckResult(results, category, tr("密码有效期配置"), SeverityInfo, StatusOK, "/etc/login.defs", evidence...)
+	}
+}
+
+// 检查系统更新
+func checkSystemUpdates(ctx context.Context, results *[]CheckResult) {
+	category := tr("系统更新检查")
+
+	if _, err := exec.LookPath("apt"); err == nil {
+		addCommandOutputResult(ctx, results, category, tr("可升级的软件包"), "apt", "list", "--upgradable")
+		return
+	}
+	if _, err := exec.LookPath("yum"); err == nil {
+		// yum check-update 在有可用更新时返回100，不能按失败处理
+		output, _ := runCommand(ctx, "yum", "check-update", "-q").Text()
+		addCheckResult(results, category, tr("可升级的软件包"), SeverityInfo, StatusOK, output)
+		return
+	}
+	addCheckResult(results, category, tr("未找到apt或yum"), SeverityInfo, StatusFailed, "")
+}
+
+// 检查SSH配置
+func checkSSHConfig(ctx context.Context, results *[]CheckResult) {
+	category := tr("SSH配置检查")
+
+	values, err := readConfigValues("/etc/ssh/sshd_config")
+	if isNotPresent(err) {
+		addAbsentResult(results, category, tr("未安装SSH服务"), tr("/etc/ssh/sshd_config 不存在\n"))
+		return
+	}
+	if err != nil {
+		addErrorResult(results, category, tr("读取/etc/ssh/sshd_config失败"), err)
+		return
+	}
+
+	evidence := []string{
+		fmt.Sprintf("PermitRootLogin = %s", values["permitrootlogin"]),
+		fmt.Sprintf("PasswordAuthentication = %s", values["passwordauthentication"]),
+	}
+	if strings.EqualFold(values["permitrootlogin"], "yes") {
+		addCheckResult(results, category, tr("允许root通过SSH登录"), SeverityWarning, StatusAbnormal, "", evidence...)
+	}
+	if strings.EqualFold(values["passwordauthentication"], "yes") {
+		addCheckResult(results, category, tr("SSH允许密码认证"), SeverityWarning, StatusAbnormal, "", evidence...)
+	}
+	addCheckResult(results, category, tr("SSH登录配置"), SeverityInfo, StatusOK, "/etc/ssh/sshd_config", evidence...)
+}
@@ -0,0 +1,29 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "context"
+
+func init() {
+	// 复制出的Prefetch文件，仅在指定了 -prefetch-dir 时分析
+	registerCheck("offline.prefetch", "offline", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if prefetchDir != "" {
+			analyzePrefetch(ctx, results, prefetchDir)
+		}
+	})
+	// 合并Prefetch、注册表hive和事件日志中的执行痕迹，至少指定其中一个目录时执行
+	registerCheck("offline.execution", "offline", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if prefetchDir != "" || registryHiveDir != "" || eventLogDir != "" {
+			checkExecutionCorrelation(ctx, results)
+		}
+	})
+}
+
+// prefetchSourceDir Linux上没有本机的Prefetch目录，只能分析 -prefetch-dir 指定的目录
+func prefetchSourceDir() string {
+	return prefetchDir
+}
+
+// addLocalVolumes Linux上没有本机的Windows卷，盘符只能从离线hive的MountedDevices中得到
+func addLocalVolumes(volumes *volumeMap) {}
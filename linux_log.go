@@ -0,0 +1,147 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+func init() {
+	registerCheck("log.system", "log", PrivilegeNone, analyzeJournalErrors)
+	registerCheck("log.auth", "log", PrivilegeAdmin, analyzeAuthLogs)
+	registerCheck("log.application", "log", PrivilegeNone, analyzeWebServerLogs)
+
+	// 从Windows主机收集的事件日志，仅在指定了 -evtx-dir 时分析
+	registerCheck("offline.system", "offline", PrivilegeNone, requireEventLogDir(analyzeSystemLogs))
+	registerCheck("offline.security", "offline", PrivilegeNone, requireEventLogDir(analyzeSecurityLogs))
+	registerCheck("offline.application", "offline", PrivilegeNone, requireEventLogDir(analyzeApplicationLogs))
+	registerCheck("offline.powershell", "offline", PrivilegeNone, requireEventLogDir(analyzePowerShellLogs))
+	registerCheck("offline.sigma", "offline", PrivilegeNone, requireEventLogDir(analyzeSigmaRules))
+
+	// 从Windows主机收集的IIS日志和防火墙日志，仅在指定了 -iis-dir 或 -firewall-dir 时分析
+	registerCheck("offline.iis", "offline", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if iisLogDir != "" {
+			analyzeIISLogs(ctx, results, iisLogDir)
+		}
+	})
+	registerCheck("offline.firewall", "offline", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if firewallLogDir != "" {
+			analyzeFirewallLogs(ctx, results, firewallLogDir)
+		}
+	})
+}
+
+// requireEventLogDir 未指定离线事件日志目录时不执行检查
+func requireEventLogDir(run checkFunc) checkFunc {
+	return func(ctx context.Context, results *[]CheckResult) {
+		if eventLogDir != "" {
+			run(ctx, results)
+		}
+	}
+}
+
+// exportEventLog Linux上没有本机事件日志，只能分析 -evtx-dir 指定的离线日志
+func exportEventLog(ctx context.Context, channel string) (string, func(), error) {
+	return "", nil, errors.New(tr("未指定离线事件日志目录 (-evtx-dir)"))
+}
+
+// 认证日志路径，Debian系为auth.log，RedHat系为secure
+var authLogPaths = []string{"/var/log/auth.log", "/var/log/secure"}
+
+// 认证失败次数超过该值时提示可能存在暴力破解
+const authFailureThreshold = 20
+
+// tailLines 读取文件末尾的n行，仅读取最后64KB以避免加载大文件
+func tailLines(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	const maxTail = 64 * 1024
+	if info, err := file.Stat(); err == nil && info.Size() > maxTail {
+		if _, err := file.Seek(-maxTail, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return lines, scanner.Err()
+}
+
+// 分析systemd日志中本次启动以来的错误
+func analyzeJournalErrors(ctx context.Context, results *[]CheckResult) {
+	addCommandOutputResult(ctx, results, tr("系统日志分析"), tr("系统错误"), "journalctl", "-p", "3", "-xb", "-n", "10", "--no-pager")
+}
+
+// 分析认证日志中的失败记录
+func analyzeAuthLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("安全日志分析")
+
+	for _, path := range authLogPaths {
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+
+		var failures []string
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			if strings.Contains(strings.ToLower(scanner.Text()), "failed") {
+				failures = append(failures, scanner.Text())
+			}
+		}
+		file.Close()
+
+		total := len(failures)
+		if total > 10 {
+			failures = failures[total-10:]
+		}
+		description := fmt.Sprintf(tr("认证失败: %d 次 (%s)"), total, path)
+		if total >= authFailureThreshold {
+			addCheckResult(results, category, description, SeverityWarning, StatusAbnormal,
+				tr("认证失败次数较多，可能存在暴力破解，以下为最近10条记录"), failures...)
+		} else {
+			addCheckResult(results, category, description, SeverityInfo, StatusOK, "", failures...)
+		}
+		return
+	}
+
+	addAbsentResult(results, category, tr("未找到认证日志"), strings.Join(authLogPaths, "\n"))
+}
+
+// 分析Web服务器错误日志
+func analyzeWebServerLogs(ctx context.Context, results *[]CheckResult) {
+	category := tr("应用日志分析")
+
+	logs := []struct {
+		name string
+		path string
+	}{
+		{tr("Apache错误日志"), "/var/log/apache2/error.log"},
+		{tr("Nginx错误日志"), "/var/log/nginx/error.log"},
+	}
+
+	for _, log := range logs {
+		lines, err := tailLines(log.path, 10)
+		if err != nil {
+			continue
+		}
+		addCheckResult(results, category, log.name, SeverityInfo, StatusOK, log.path, lines...)
+	}
+}
@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func init() {
+	registerCheck("mem.zombie", "mem", PrivilegeNone, checkZombieProcesses)
+}
+
+// 检查僵尸进程
+func checkZombieProcesses(ctx context.Context, results *[]CheckResult) {
+	category := tr("进程行为监控")
+
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		addErrorResult(results, category, tr("获取进程列表失败"), err)
+		return
+	}
+
+	var zombies []string
+	for _, p := range processes {
+		if ctx.Err() != nil {
+			break
+		}
+		status, err := p.StatusWithContext(ctx)
+		if err != nil || len(status) == 0 || status[0] != process.Zombie {
+			continue
+		}
+		name, _ := p.NameWithContext(ctx)
+		ppid, _ := p.PpidWithContext(ctx)
+		zombies = append(zombies, fmt.Sprintf(tr("PID: %d 名称: %s 父进程: %d"), p.Pid, name, ppid))
+	}
+
+	if len(zombies) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("僵尸进程: %d 个"), len(zombies)), SeverityWarning, StatusAbnormal, "", zombies...)
+	} else {
+		addCheckResult(results, category, tr("未发现僵尸进程"), SeverityInfo, StatusOK, "")
+	}
+}
@@ -0,0 +1,32 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"context"
+	"os/exec"
+)
+
+func init() {
+	registerCheck("net.firewall", "net", PrivilegeAdmin, analyzeFirewallConfig)
+}
+
+// 分析防火墙配置
+func analyzeFirewallConfig(ctx context.Context, results *[]CheckResult) {
+	category := tr("防火墙配置")
+
+	found := false
+	if _, err := exec.LookPath("iptables"); err == nil {
+		found = true
+		addCommandOutputResult(ctx, results, category, tr("iptables规则"), "iptables", "-L", "-n")
+	}
+	if _, err := exec.LookPath("ufw"); err == nil {
+		found = true
+		addCommandOutputResult(ctx, results, category, tr("UFW状态"), "ufw", "status")
+	}
+
+	if !found {
+		addCheckResult(results, category, tr("未找到iptables或ufw"), SeverityWarning, StatusAbnormal, "")
+	}
+}
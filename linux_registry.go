@@ -0,0 +1,43 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+func init() {
+	// 从Windows主机收集的注册表hive文件，仅在指定了 -hive-dir 时分析
+	registerCheck("offline.registry", "offline", PrivilegeNone, requireHiveDir(checkRegistry))
+	registerCheck("offline.autoruns", "offline", PrivilegeNone, requireHiveDir(getRegistryAutoRuns))
+	registerCheck("offline.shimcache", "offline", PrivilegeNone, requireHiveDir(checkShimcache))
+	registerCheck("offline.amcache", "offline", PrivilegeNone, requireHiveDir(checkAmcache))
+
+	// 复制出的计划任务定义文件，仅在指定了 -tasks-dir 时分析，同时指定 -hive-dir 时与TaskCache比对
+	registerCheck("offline.tasks", "offline", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if scheduledTasksDir != "" {
+			analyzeScheduledTasks(ctx, results, scheduledTasksDir)
+		}
+	})
+}
+
+// requireHiveDir 未指定离线注册表目录时不执行检查
+func requireHiveDir(run checkFunc) checkFunc {
+	return func(ctx context.Context, results *[]CheckResult) {
+		if registryHiveDir != "" {
+			run(ctx, results)
+		}
+	}
+}
+
+// openLiveRegistryKey Linux上没有本机注册表，只能分析 -hive-dir 指定的离线hive文件
+func openLiveRegistryKey(root, path string) (registryKey, error) {
+	return nil, errors.New(tr("未指定离线注册表目录 (-hive-dir)"))
+}
+
+// liveAmcachePath Linux上没有本机的Amcache.hve，只能分析 -hive-dir 中复制出的文件
+func liveAmcachePath() (string, error) {
+	return "", errors.New(tr("未指定离线注册表目录 (-hive-dir)"))
+}
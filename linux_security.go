@@ -0,0 +1,155 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func init() {
+	registerCheck("sec.suid", "sec", PrivilegeAdmin, checkSUIDFiles)
+	registerCheck("sec.users", "sec", PrivilegeNone, checkPrivilegedUsers)
+	registerCheck("sec.logins", "sec", PrivilegeNone, checkRecentLogins)
+	registerCheck("sec.services", "sec", PrivilegeNone, checkRunningServices)
+	registerCheck("sec.ports", "sec", PrivilegeAdmin, checkListeningPorts)
+}
+
+// 遍历文件系统时跳过的伪文件系统目录
+var skipWalkDirs = map[string]bool{
+	"/proc": true,
+	"/sys":  true,
+	"/dev":  true,
+	"/run":  true,
+}
+
+// 攻击者常用于落地文件的可写目录
+var writableDirs = []string{"/tmp/", "/var/tmp/", "/dev/shm/", "/home/"}
+
+// 检查SUID文件
+func checkSUIDFiles(ctx context.Context, results *[]CheckResult) {
+	category := tr("文件完整性检查")
+
+	var suidFiles, suspicious []string
+	filepath.WalkDir("/", func(path string, d fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if skipWalkDirs[path] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Mode()&os.ModeSetuid == 0 {
+			return nil
+		}
+
+		suidFiles = append(suidFiles, path)
+		for _, dir := range writableDirs {
+			if strings.HasPrefix(path, dir) {
+				suspicious = append(suspicious, path)
+				break
+			}
+		}
+		return nil
+	})
+
+	if len(suspicious) > 0 {
+		addCheckResult(results, category, tr("可写目录中存在SUID文件"), SeverityCritical, StatusAbnormal,
+			tr("SUID文件位于/tmp、/var/tmp、/dev/shm或/home下，常见于提权后门"), suspicious...)
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("SUID文件: %d 个"), len(suidFiles)), SeverityInfo, StatusOK, "", suidFiles...)
+}
+
+// 检查UID为0的特权用户
+func checkPrivilegedUsers(ctx context.Context, results *[]CheckResult) {
+	category := tr("用户安全检查")
+
+	file, err := os.Open("/etc/passwd")
+	if err != nil {
+		addErrorResult(results, category, tr("读取/etc/passwd失败"), err)
+		return
+	}
+	defer file.Close()
+
+	var privileged []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 7 || fields[2] != "0" {
+			continue
+		}
+		if fields[0] == "root" {
+			continue
+		}
+		privileged = append(privileged, scanner.Text())
+	}
+
+	if len(privileged) > 0 {
+		addCheckResult(results, category, tr("发现root以外的特权用户"), SeverityCritical, StatusAbnormal,
+			tr("以下账户的UID为0"), privileged...)
+	} else {
+		addCheckResult(results, category, tr("特权用户仅有root"), SeverityInfo, StatusOK, "")
+	}
+}
+
+// 检查最近用户登录活动
+func checkRecentLogins(ctx context.Context, results *[]CheckResult) {
+	addCommandOutputResult(ctx, results, tr("用户安全检查"), tr("最近用户活动"), "last", "-n", "5")
+}
+
+// 检查运行中的服务。容器等未以systemd启动的系统中没有 /run/systemd/system，systemctl无法使用
+func checkRunningServices(ctx context.Context, results *[]CheckResult) {
+	if _, err := os.Stat("/run/systemd/system"); err != nil {
+		addAbsentResult(results, tr("服务检查"), tr("运行的服务"), tr("系统未以systemd启动\n"))
+		return
+	}
+	addCommandOutputResult(ctx, results, tr("服务检查"), tr("运行的服务"), "systemctl", "list-units", "--type=service", "--state=running", "--no-pager")
+}
+
+// 检查开放端口及其所属进程
+func checkListeningPorts(ctx context.Context, results *[]CheckResult) {
+	category := tr("端口检查")
+
+	conns, err := net.ConnectionsWithContext(ctx, "inet")
+	if err != nil {
+		addErrorResult(results, category, tr("获取监听端口失败"), err)
+		return
+	}
+
+	var ports []string
+	for _, conn := range conns {
+		if ctx.Err() != nil {
+			break
+		}
+		// TCP取LISTEN状态，UDP取未连接的套接字
+		listening := conn.Status == "LISTEN" || (conn.Type == syscall.SOCK_DGRAM && conn.Raddr.IP == "")
+		if !listening {
+			continue
+		}
+		name := ""
+		if proc, err := process.NewProcess(conn.Pid); err == nil {
+			name, _ = proc.NameWithContext(ctx)
+		}
+		ports = append(ports, fmt.Sprintf(tr("%s:%d 进程: %s (PID: %d)"), conn.Laddr.IP, conn.Laddr.Port, name, conn.Pid))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("开放的端口: %d 个"), len(ports)), SeverityInfo, StatusOK, "", ports...)
+}
@@ -1,17 +1,266 @@
-//go:build !windows
-// +build !windows
+//go:build windows || linux
+// +build windows linux
 
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
+	"io"
 	"os"
-	"runtime"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/host"
 )
 
+// runCheckers 使用最多parallel个并发执行检查项，按列表顺序在控制台输出结果并返回全部结果，
+// 输出顺序与并发数无关。ctx被取消后，剩余的检查项记录为未执行；elevated为false时，
+// 需要管理员权限的检查项记录为已跳过
+func runCheckers(ctx context.Context, out io.Writer, list []Checker, parallel int, checkTimeout time.Duration, elevated bool) []CheckResult {
+	fmt.Fprintln(out, tr(toolBanner))
+
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// 每个检查项的结果写入各自的位置，完成后关闭对应的done
+	sections := make([][]CheckResult, len(list))
+	done := make([]chan struct{}, len(list))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	jobs := make(chan int)
+	for w := 0; w < parallel; w++ {
+		go func() {
+			for i := range jobs {
+				switch {
+				case ctx.Err() != nil:
+					sections[i] = skippedChecker(ctx, list[i])
+				case list[i].Privilege() == PrivilegeAdmin && !elevated:
+					sections[i] = skippedPrivilege(list[i])
+				default:
+					sections[i] = runChecker(withCommandSeq(ctx, i), list[i], checkTimeout)
+				}
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		for i := range list {
+			jobs <- i
+		}
+		close(jobs)
+	}()
+
+	var results []CheckResult
+	category := ""
+	for i, c := range list {
+		if c.Category() != category {
+			category = c.Category()
+			if g := groupIndex(category); g < len(checkGroups) {
+				fmt.Fprintf(out, "\n[+] %s\n", tr(checkGroups[g].Banner))
+			}
+		}
+
+		<-done[i]
+		printCheckResults(out, sections[i])
+		results = append(results, sections[i]...)
+	}
+	return results
+}
+
 func main() {
-	fmt.Printf("错误: 此工具仅支持Windows平台\n")
-	fmt.Printf("当前平台: %s/%s\n", runtime.GOOS, runtime.GOARCH)
-	fmt.Printf("请在Windows系统上运行此工具\n")
-	os.Exit(1)
-}
\ No newline at end of file
+	// 帮助信息在解析参数的过程中输出，因此先确定输出语言
+	if lang, ok := langFromArgs(os.Args[1:]); ok {
+		if err := setLanguage(lang); err != nil {
+			fmt.Printf(tr("错误: %v\n"), err)
+			os.Exit(ExitError)
+		}
+	}
+
+	// 解析命令行参数
+	var (
+		runAll       = flag.Bool("all", false, tr("运行所有检查"))
+		genReport    = flag.Bool("report", true, tr("生成HTML格式检查报告"))
+		listChecks   = flag.Bool("list-checks", false, tr("列出所有可用的检查项"))
+		onlyIDs      = flag.String("only", "", tr("仅运行指定的检查项或分组，以逗号分隔"))
+		skipIDs      = flag.String("skip", "", tr("跳过指定的检查项或分组，以逗号分隔"))
+		cmdTimeout   = flag.Duration("cmd-timeout", defaultCommandTimeout, tr("单条外部命令的超时时间"))
+		runTimeout   = flag.Duration("timeout", 0, tr("整体运行时间限制，超时后写入已收集的结果，0表示不限制"))
+		checkTimeout = flag.Duration("check-timeout", 0, tr("单项检查的时间限制，0表示不限制"))
+		configPath   = flag.String("config", "", tr("检测参数配置文件（YAML或JSON），未指定的项使用内置默认值"))
+		summaryPath  = flag.String("summary", "", tr("将JSON格式的运行摘要写入指定文件，\"-\"表示标准输出"))
+		parallel     = flag.Int("parallel", 1, tr("同时执行的检查项数量，输出顺序不受影响"))
+		recordDir    = flag.String("record", "", tr("将外部命令的输出录制到指定目录"))
+		replayDir    = flag.String("replay", "", tr("从指定目录回放录制的命令输出，不执行外部命令"))
+		evtxDir      = flag.String("evtx-dir", "", tr("分析指定目录中的EVTX事件日志文件，代替读取本机日志"))
+		sigmaDir     = flag.String("sigma-dir", "", tr("从指定目录加载Sigma规则，在事件日志上评估"))
+		hiveDir      = flag.String("hive-dir", "", tr("分析指定目录中的注册表hive文件（SYSTEM、SOFTWARE、NTUSER.DAT等），代替读取本机注册表"))
+		iisDir       = flag.String("iis-dir", "", tr("分析指定目录中的IIS日志（W3C格式），代替读取本机的IIS日志目录"))
+		firewallDir  = flag.String("firewall-dir", "", tr("分析指定目录中的Windows防火墙日志（pfirewall.log），代替读取本机的防火墙日志目录"))
+		tasksDir     = flag.String("tasks-dir", "", tr("分析指定目录中的计划任务定义文件（System32\\Tasks），代替读取本机的计划任务"))
+		prefetchPath = flag.String("prefetch-dir", "", tr("分析指定目录中的Prefetch文件（*.pf），代替读取本机的Prefetch目录"))
+		mftPath      = flag.String("mft", "", tr("分析从NTFS卷复制出的$MFT文件，检测时间戳篡改并生成文件系统时间线"))
+		usnPath      = flag.String("usn", "", tr("分析从NTFS卷复制出的USN日志（$UsnJrnl:$J），同时指定 -mft 时解析完整路径"))
+		langFlag     = flag.String("lang", "zh", tr("输出语言: zh（中文）或 en（英文）"))
+		codePage     = flag.Int("codepage", 0, tr("外部命令输出的代码页（如936、950、932、437、1252），默认自动检测"))
+	)
+	groupFlags := make(map[string]*bool)
+	for _, group := range checkGroups {
+		groupFlags[group.Name] = flag.Bool(group.Name, false, tr(group.Usage))
+	}
+
+	flag.Parse()
+
+	if err := setLanguage(*langFlag); err != nil {
+		fmt.Printf(tr("错误: %v\n"), err)
+		os.Exit(ExitError)
+	}
+
+	available := availableCheckers()
+	if *listChecks {
+		listCheckers(os.Stdout, available)
+		return
+	}
+
+	// 在执行任何检查前加载并校验配置
+	if *configPath != "" {
+		cfg, err := loadConfig(*configPath)
+		if err != nil {
+			fmt.Printf(tr("错误: %v\n"), err)
+			os.Exit(ExitError)
+		}
+		config = cfg
+	}
+
+	eventLogDir = *evtxDir
+	registryHiveDir = *hiveDir
+	iisLogDir = *iisDir
+	firewallLogDir = *firewallDir
+	scheduledTasksDir = *tasksDir
+	prefetchDir = *prefetchPath
+	mftFile = *mftPath
+	usnJournalFile = *usnPath
+
+	// Sigma规则在执行检查前加载，个别规则无法加载时在结果中列出
+	if *sigmaDir != "" {
+		rules, skipped, problems, err := loadSigmaRules(*sigmaDir)
+		if err != nil {
+			fmt.Printf(tr("错误: %v\n"), err)
+			os.Exit(ExitError)
+		}
+		sigmaRuleDir, sigmaRules, sigmaSkipped, sigmaLoadProblems = *sigmaDir, rules, skipped, problems
+	}
+
+	if err := setForcedCodePage(*codePage); err != nil {
+		fmt.Printf(tr("错误: %v\n"), err)
+		os.Exit(ExitError)
+	}
+
+	// 配置外部命令执行器
+	cmdRunner = newExecRunner(*cmdTimeout)
+	if *replayDir != "" {
+		cmdRunner = newReplayRunner(*replayDir)
+	} else if *recordDir != "" {
+		recorder, err := newRecordingRunner(cmdRunner, *recordDir)
+		if err != nil {
+			fmt.Printf(tr("错误: %v\n"), err)
+			os.Exit(ExitError)
+		}
+		cmdRunner = recorder
+	}
+
+	only := parseCheckIDs(*onlyIDs)
+	skip := parseCheckIDs(*skipIDs)
+	for _, ids := range [][]string{only, skip} {
+		if err := validateCheckIDs(available, ids); err != nil {
+			fmt.Printf(tr("错误: %v\n"), err)
+			os.Exit(ExitError)
+		}
+	}
+
+	groups := make(map[string]bool)
+	for name, enabled := range groupFlags {
+		groups[name] = *runAll || *enabled
+	}
+	selected := selectCheckers(available, groups, only, skip)
+
+	// 如果没有选中任何检查项，显示帮助信息
+	if len(selected) == 0 {
+		flag.Usage()
+		os.Exit(ExitError)
+	}
+
+	// 摘要写入标准输出时，检查结果改为输出到标准错误，避免混入JSON
+	var console io.Writer = os.Stdout
+	if *summaryPath == "-" {
+		console = os.Stderr
+	}
+
+	// 未提升权限时仍执行普通检查项，需要管理员权限的检查项在报告中记录为已跳过
+	elevated := isAdmin()
+	if !elevated {
+		fmt.Fprintln(console, tr(notElevatedWarning))
+	}
+
+	// 收到中断信号或超过整体时间限制时取消剩余检查，已收集的结果仍写入报告
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *runTimeout)
+		defer cancel()
+	}
+	finished := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			// 恢复默认的信号处理，再次按下Ctrl+C时立即退出
+			stop()
+			fmt.Fprintf(console, tr("\n[!] %s，正在停止检查并写入已收集的结果...\n"), interruptReason(ctx))
+		case <-finished:
+		}
+	}()
+
+	// 所有检查结果，供控制台、报告共同使用
+	results := runCheckers(ctx, console, selected, *parallel, *checkTimeout, elevated)
+	close(finished)
+
+	// 添加系统信息作为基本信息
+	hostname, sysInfo := "", ""
+	if hostInfo, err := host.Info(); err == nil {
+		hostname = hostInfo.Hostname
+		sysInfo = fmt.Sprintf(tr("主机名: %s\n操作系统: %s\n平台: %s %s\n"),
+			hostInfo.Hostname, hostInfo.OS, hostInfo.Platform, hostInfo.PlatformVersion)
+	}
+	report := newReport(results, sysInfo)
+	exitCode := report.ExitCode()
+
+	// 如果需要生成报告
+	reportPath := ""
+	if *genReport {
+		path, err := generateReport(report)
+		if err != nil {
+			fmt.Fprintf(console, tr("生成报告失败: %v\n"), err)
+			exitCode = ExitError
+		} else {
+			fmt.Fprintf(console, tr("报告已生成: %s\n"), path)
+		}
+		reportPath = path
+	}
+
+	if *summaryPath != "" {
+		summary := newSummary(report, hostname, len(selected), exitCode, reportPath)
+		if err := writeSummary(*summaryPath, summary); err != nil {
+			fmt.Fprintf(os.Stderr, tr("错误: %v\n"), err)
+			exitCode = ExitError
+		}
+	}
+
+	printSummary(console, report, exitCode)
+	os.Exit(exitCode)
+}
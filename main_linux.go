@@ -0,0 +1,31 @@
+//go:build linux
+// +build linux
+
+package main
+
+import "os"
+
+// 工具名称、报告标题及权限提示
+const (
+	toolBanner          = "Linux系统应急响应工具 v1.0"
+	reportTitle         = "Linux系统应急响应报告"
+	notElevatedWarning  = "[!] 当前未以root权限运行，需要root权限的检查项将被跳过"
+	privilegeSkipReason = "需要root权限，当前有效用户不是root"
+)
+
+// 检查分组，顺序即执行顺序，与linux_forensics.sh的模块对应
+var checkGroups = []checkGroup{
+	{"ir", "运行基础系统检查", "开始基础系统检查..."},
+	{"mem", "运行内存和进程行为分析", "开始内存和进程行为分析..."},
+	{"sec", "运行安全检查（SUID文件、特权用户、服务和端口）", "开始安全检查..."},
+	{"log", "运行系统日志分析", "开始系统日志分析..."},
+	{"net", "运行网络安全分析", "开始网络安全分析..."},
+	{"baseline", "运行系统安全基线检查", "开始系统安全基线检查..."},
+	{"ntfs", "分析从NTFS卷复制出的$MFT和USN日志（配合 -mft、-usn 参数使用）", "开始NTFS元数据分析..."},
+	{"offline", "分析从Windows主机收集的离线证据（配合 -evtx-dir、-hive-dir、-iis-dir 等目录参数使用）", "开始离线证据分析..."},
+}
+
+// isAdmin 检查程序是否以root权限运行
+func isAdmin() bool {
+	return os.Geteuid() == 0
+}
@@ -0,0 +1,30 @@
+//go:build !windows && !linux
+// +build !windows,!linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// 报告标题及权限不足时的跳过原因
+const (
+	reportTitle         = "系统应急响应报告"
+	privilegeSkipReason = "需要管理员权限"
+)
+
+// 当前平台没有可用的检查分组
+var checkGroups []checkGroup
+
+func main() {
+	if lang, ok := langFromArgs(os.Args[1:]); ok {
+		setLanguage(lang)
+	}
+
+	fmt.Print(tr("错误: 此工具仅支持Windows和Linux平台\n"))
+	fmt.Printf(tr("当前平台: %s/%s\n"), runtime.GOOS, runtime.GOARCH)
+	fmt.Print(tr("请在Windows或Linux系统上运行此工具\n"))
+	os.Exit(1)
+}
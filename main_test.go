@@ -0,0 +1,121 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunCheckersOrdered(t *testing.T) {
+	savedRunner, savedLog := cmdRunner, commandLog
+	defer func() { cmdRunner, commandLog = savedRunner, savedLog }()
+	stub := stubRunner{}
+	cmdRunner, commandLog = stub, nil
+
+	// 第一个检查项等到最后一个完成后才返回，只有并发执行时才能按时结束
+	const n = 6
+	last := make(chan struct{})
+	var list []Checker
+	var want []string
+	for i := 0; i < n; i++ {
+		id := fmt.Sprintf("test.%d", i)
+		stub["echo "+id] = &CommandResult{}
+		list = append(list, &funcChecker{id: id, run: func(ctx context.Context, results *[]CheckResult) {
+			switch i {
+			case 0:
+				select {
+				case <-last:
+				case <-time.After(5 * time.Second):
+					addCheckResult(results, id, "timed out waiting for the last check", SeverityWarning, StatusAbnormal, "")
+				}
+			case n - 1:
+				defer close(last)
+			default:
+				time.Sleep(time.Duration(n-i) * time.Millisecond)
+			}
+			runCommand(ctx, "echo", id)
+			addCheckResult(results, id, id, SeverityInfo, StatusOK, "")
+		}})
+		want = append(want, id)
+	}
+
+	var out bytes.Buffer
+	results := runCheckers(context.Background(), &out, list, 3, time.Minute, true)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Description)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results = %q, want %q", got, want)
+	}
+	// 控制台输出与结果的顺序一致
+	for i := 1; i < n; i++ {
+		if strings.Index(out.String(), "=== test."+fmt.Sprint(i-1)) > strings.Index(out.String(), "=== test."+fmt.Sprint(i)) {
+			t.Errorf("output out of order:\n%s", out.String())
+			break
+		}
+	}
+	// 命令记录按检查项顺序排列，而不是按完成顺序
+	var commands []string
+	for _, c := range executedCommands() {
+		commands = append(commands, c.Args[0])
+	}
+	if !reflect.DeepEqual(commands, want) {
+		t.Errorf("commands = %q, want %q", commands, want)
+	}
+}
+
+func TestRunCheckersCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	list := []Checker{
+		&funcChecker{id: "test.a", run: func(ctx context.Context, results *[]CheckResult) {}},
+		&funcChecker{id: "test.b", privilege: PrivilegeAdmin, run: func(ctx context.Context, results *[]CheckResult) {}},
+	}
+	results := runCheckers(ctx, &bytes.Buffer{}, list, 2, time.Minute, false)
+	if len(results) != 2 || results[0].Status != StatusIncomplete || results[1].Status != StatusIncomplete {
+		t.Errorf("results = %+v", results)
+	}
+}
+
+func TestRunCheckersSkipsPrivileged(t *testing.T) {
+	ran := false
+	list := []Checker{
+		&funcChecker{id: "test.user", run: func(ctx context.Context, results *[]CheckResult) {
+			addCheckResult(results, "test.user", "ran", SeverityInfo, StatusOK, "")
+		}},
+		&funcChecker{id: "test.admin", privilege: PrivilegeAdmin, run: func(ctx context.Context, results *[]CheckResult) {
+			ran = true
+		}},
+	}
+
+	var out bytes.Buffer
+	results := runCheckers(context.Background(), &out, list, 1, time.Minute, false)
+	if ran {
+		t.Error("admin-only check ran without elevation")
+	}
+	if len(results) != 2 || results[0].Description != "ran" {
+		t.Fatalf("results = %+v", results)
+	}
+	if r := results[1]; r.Category != "test.admin" || r.Status != StatusSkipped || r.Details != privilegeSkipReason {
+		t.Errorf("skipped result = %+v", r)
+	}
+	if report := newReport(results, ""); report.SkippedCount != 1 || report.ExitCode() != ExitClean {
+		t.Errorf("skipped = %d, exit code = %d", report.SkippedCount, report.ExitCode())
+	}
+	if !strings.Contains(out.String(), "[信息] 检查未执行 (已跳过)") {
+		t.Errorf("output:\n%s", out.String())
+	}
+
+	if results := runCheckers(context.Background(), &bytes.Buffer{}, list[1:], 1, time.Minute, true); !ran || len(results) != 0 {
+		t.Errorf("elevated run: ran = %v, results = %+v", ran, results)
+	}
+}
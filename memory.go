@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// 进程行为监控结构
+type ProcessBehavior struct {
+	PID          int32
+	Name         string
+	CPUUsage     float64
+	MemoryUsage  float32
+	ThreadCount  int32
+	HandleCount  int32
+	ReadBytes    uint64
+	WriteBytes   uint64
+	NetworkUsage uint64
+	DLLs         []string
+	FileAccesses []string
+}
+
+func init() {
+	registerCommonCheck("mem.memory", "mem", PrivilegeNone, analyzeMemory)
+	registerCommonCheck("mem.behavior", "mem", PrivilegeAdmin, monitorProcessBehavior)
+}
+
+// 获取进程详细信息
+func getProcessDetails(ctx context.Context, pid int32) (*ProcessBehavior, error) {
+	proc, err := process.NewProcessWithContext(ctx, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	behavior := &ProcessBehavior{PID: pid}
+
+	// 获取进程名称
+	name, err := proc.NameWithContext(ctx)
+	if err == nil {
+		behavior.Name = name
+	}
+
+	// 获取CPU使用率
+	cpu, err := proc.CPUPercentWithContext(ctx)
+	if err == nil {
+		behavior.CPUUsage = cpu
+	}
+
+	// 获取内存使用率
+	mem, err := proc.MemoryPercentWithContext(ctx)
+	if err == nil {
+		behavior.MemoryUsage = mem
+	}
+
+	// 获取线程数
+	threads, err := proc.NumThreadsWithContext(ctx)
+	if err == nil {
+		behavior.ThreadCount = threads
+	}
+
+	// 获取句柄数 (Windows特定功能，暂时跳过)
+	// handles, err := proc.NumHandles()
+	// if err == nil {
+	//	behavior.HandleCount = handles
+	// }
+
+	// 获取IO统计
+	io, err := proc.IOCountersWithContext(ctx)
+	if err == nil {
+		behavior.ReadBytes = io.ReadBytes
+		behavior.WriteBytes = io.WriteBytes
+	}
+
+	// 获取网络使用情况
+	conns, err := proc.ConnectionsWithContext(ctx)
+	if err == nil {
+		behavior.NetworkUsage = uint64(len(conns))
+	}
+
+	// 获取加载的DLL (Windows平台可能不支持，暂时跳过)
+	// dlls, err := proc.MemoryMaps(true)
+	// if err == nil {
+	//	for _, dll := range *dlls {
+	//		behavior.DLLs = append(behavior.DLLs, dll.Path)
+	//	}
+	// }
+
+	return behavior, nil
+}
+
+// 监控进程行为
+func monitorProcessBehavior(ctx context.Context, results *[]CheckResult) {
+	category := tr("进程行为监控")
+
+	// 获取所有进程
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		addErrorResult(results, category, tr("获取进程列表失败"), err)
+		return
+	}
+
+	// 监控高CPU和内存使用的进程
+	found := false
+	for _, proc := range processes {
+		if ctx.Err() != nil {
+			break
+		}
+		behavior, err := getProcessDetails(ctx, proc.Pid)
+		if err != nil {
+			continue
+		}
+
+		// 检查是否为异常行为
+		if behavior.CPUUsage > config.Process.CPUThreshold || float64(behavior.MemoryUsage) > config.Process.MemoryThreshold {
+			found = true
+			var details strings.Builder
+			fmt.Fprintf(&details, "PID: %d\n", behavior.PID)
+			fmt.Fprintf(&details, tr("名称: %s\n"), behavior.Name)
+			fmt.Fprintf(&details, tr("CPU使用率: %.2f%%\n"), behavior.CPUUsage)
+			fmt.Fprintf(&details, tr("内存使用率: %.2f%%\n"), behavior.MemoryUsage)
+			fmt.Fprintf(&details, tr("线程数: %d\n"), behavior.ThreadCount)
+			fmt.Fprintf(&details, tr("句柄数: %d\n"), behavior.HandleCount)
+			fmt.Fprintf(&details, tr("读取字节: %d\n"), behavior.ReadBytes)
+			fmt.Fprintf(&details, tr("写入字节: %d\n"), behavior.WriteBytes)
+			fmt.Fprintf(&details, tr("网络连接数: %d\n"), behavior.NetworkUsage)
+
+			addCheckResult(results, category, fmt.Sprintf(tr("发现高资源使用进程: %s (PID: %d)"), behavior.Name, behavior.PID),
+				SeverityWarning, StatusAbnormal, details.String(), behavior.DLLs...)
+		}
+	}
+
+	if !found {
+		addCheckResult(results, category, tr("未发现高资源使用进程"), SeverityInfo, StatusOK, "")
+	}
+}
+
+// 内存分析
+func analyzeMemory(ctx context.Context, results *[]CheckResult) {
+	category := tr("内存分析")
+
+	// 获取系统内存信息
+	memInfo, err := mem.VirtualMemory()
+	if err != nil {
+		addErrorResult(results, category, tr("获取内存信息失败"), err)
+		return
+	}
+
+	var details strings.Builder
+	fmt.Fprintf(&details, tr("内存使用率: %.2f%%\n"), memInfo.UsedPercent)
+	fmt.Fprintf(&details, tr("总物理内存: %.2f GB\n"), float64(memInfo.Total)/(1024*1024*1024))
+	fmt.Fprintf(&details, tr("可用物理内存: %.2f GB\n"), float64(memInfo.Available)/(1024*1024*1024))
+	fmt.Fprintf(&details, tr("已使用内存: %.2f GB\n"), float64(memInfo.Used)/(1024*1024*1024))
+	fmt.Fprintf(&details, tr("空闲内存: %.2f GB\n"), float64(memInfo.Free)/(1024*1024*1024))
+	addCheckResult(results, category, tr("系统内存使用情况"), SeverityInfo, StatusOK, details.String())
+
+	// 分析大内存进程
+	processes, _ := process.ProcessesWithContext(ctx)
+	type ProcessMemInfo struct {
+		pid     int32
+		name    string
+		memory  float32
+		path    string
+		cmdline string
+	}
+
+	var processMemList []ProcessMemInfo
+	for _, p := range processes {
+		if ctx.Err() != nil {
+			break
+		}
+		name, _ := p.NameWithContext(ctx)
+		mem, _ := p.MemoryPercentWithContext(ctx)
+		path, _ := p.ExeWithContext(ctx)
+		cmd, _ := p.CmdlineWithContext(ctx)
+		processMemList = append(processMemList, ProcessMemInfo{
+			pid:     p.Pid,
+			name:    name,
+			memory:  mem,
+			path:    path,
+			cmdline: cmd,
+		})
+	}
+
+	// 按内存使用排序
+	sort.SliceStable(processMemList, func(i, j int) bool {
+		return processMemList[i].memory > processMemList[j].memory
+	})
+
+	// 记录前10个进程
+	var evidence []string
+	for i := 0; i < 10 && i < len(processMemList); i++ {
+		evidence = append(evidence, fmt.Sprintf(tr("PID: %d 名称: %s 内存使用率: %.2f%% 路径: %s 命令行: %s"),
+			processMemList[i].pid, processMemList[i].name, processMemList[i].memory, processMemList[i].path, processMemList[i].cmdline))
+	}
+	addCheckResult(results, category, tr("内存使用TOP 10进程"), SeverityInfo, StatusOK, "", evidence...)
+}
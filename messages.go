@@ -0,0 +1,658 @@
+package main
+
+// englishMessages 英文译文，键为源码中的中文原文。
+// 新增或修改界面文字时需同步更新此表
+var englishMessages = map[string]string{
+	// appcompat.go
+	"Shimcache检查":              "Shimcache check",
+	"读取AppCompatCache失败":       "Failed to read AppCompatCache",
+	"位置: %d（越小越新）\n":           "Position: %d (lower is more recent)\n",
+	"文件修改时间: %s\n":             "File modified: %s\n",
+	"执行标志: %v\n":               "Execution flag: %v\n",
+	"Shimcache中用户可写目录中的程序: %s": "Shimcache entry in a user-writable directory: %s",
+	"格式: %s\n":                 "Format: %s\n",
+	"AppCompatCache只在关机时写入注册表，本次开机后运行的程序不在其中；条目存在不代表程序一定运行过\n": "AppCompatCache is written to the registry only at shutdown, so programs run since the last boot are missing; an entry does not prove the program ran\n",
+	"Shimcache条目数: %d":       "Shimcache entries: %d",
+	"AppCompatCache中的部分条目损坏": "Some AppCompatCache entries are corrupt",
+	" [已执行]":                 " [executed]",
+	"Amcache检查":              "Amcache check",
+	"读取Amcache失败: %s（文件被系统占用时可复制出后用 -hive-dir 分析）": "Failed to read Amcache: %s (if the file is locked by the system, copy it out and analyze it with -hive-dir)",
+	"Amcache中用户可写目录中的程序: %s":                       "Amcache file in a user-writable directory: %s",
+	"Amcache文件: %s\n":     "Amcache file: %s\n",
+	"系统组件: %d 个（未列出）\n":   "OS components: %d (not listed)\n",
+	"Amcache中的程序文件: %d 个": "Program files in Amcache: %d",
+	"首次记录时间: %s\n":        "First seen: %s\n",
+	"(无)":                 "(none)",
+	"发布者: %s\n":           "Publisher: %s\n",
+	"产品: %s %s\n":         "Product: %s %s\n",
+	"大小: %d 字节\n":         "Size: %d bytes\n",
+	"链接时间: %s\n":          "Link date: %s\n",
+	"BAM/DAM注册表项不存在（Windows 10 1709之前的系统没有）": "BAM/DAM registry keys not found (not present before Windows 10 1709)",
+	// authlog.go
+	"未发现认证攻击迹象":                          "No signs of authentication attacks",
+	"登录失败: %d 次, 登录成功: %d 次, 账户锁定: %d 次": "Logon failures: %d, logon successes: %d, account lockouts: %d",
+	"疑似暴力破解: 来源 %s 在%v内登录失败 %d 次":        "Possible brute force: %s failed to log on %[3]d times within %[2]v",
+	"账户": "Accounts",
+	"来源": "Sources",
+	"疑似密码喷洒: %v内对 %d 个账户登录失败":  "Possible password spraying: failed logons against %[2]d accounts within %[1]v",
+	"登录失败 %d 次后登录成功: %s 来自 %s": "Successful logon after %d failures: %s from %s",
+	"登录类型: %s\n登录成功时间: %s\n":   "Logon type: %s\nSuccessful logon at: %s\n",
+	"来自非常见来源的RDP登录: %s (%d 次)": "RDP logon from unusual source: %s (%d times)",
+	"账户被锁定: %s (%d 次)":         "Account locked out: %s (%d times)",
+	"调用方计算机":                   "Caller computers",
+	"等%d个":                     "%d in total",
+	"时间范围: %s 至 %s\n":          "Time range: %s to %s\n",
+	"... 省略 %d 条 ...":          "... %d omitted ...",
+	" 登录类型 %s":                 " logon type %s",
+	// checker.go
+	"管理员":  "Administrator",
+	"普通用户": "Standard user",
+	"未知的检查项: %s (使用 -list-checks 查看可用检查项)": "unknown check: %s (use -list-checks to see available checks)",
+	"检查被中断，结果不完整":                          "Check interrupted, results incomplete",
+	"检查超时，结果不完整":                           "Check timed out, results incomplete",
+	"超过单项检查时间限制 (%v)":                      "Exceeded the per-check time limit (%v)",
+	"检查项执行失败":                              "Check failed",
+	"检查未执行":                                "Check not run",
+	"超过整体运行时间限制":                           "Exceeded the overall run time limit",
+	"运行被用户中断":                              "Run interrupted by user",
+	"ID\t分组\t平台\t权限":                       "ID\tGROUP\tPLATFORM\tPRIVILEGE",
+	// config.go
+	"line %d: 端口 %q 不是数字":                            "line %d: port %q is not a number",
+	"读取配置文件失败: %v":                                   "failed to read config file: %v",
+	"解析配置文件 %s 失败: %v":                               "failed to parse config file %s: %v",
+	"配置文件 %s 无效: %v":                                 "invalid config file %s: %v",
+	"suspicious_ports: 端口 %d 超出范围 1-65535":           "suspicious_ports: port %d is outside 1-65535",
+	"suspicious_ports: 端口 %d 缺少服务名称":                 "suspicious_ports: port %d has no service name",
+	"registry.critical_paths[%d]: 路径为空":              "registry.critical_paths[%d]: empty path",
+	"registry.critical_paths[%d]: %q 应为不含根键的相对路径":    "registry.critical_paths[%d]: %q must be a path relative to the root key",
+	"files.critical_files[%d]: 路径为空":                 "files.critical_files[%d]: empty path",
+	"files.suspicious_dirs[%d]: 路径为空":                "files.suspicious_dirs[%d]: empty path",
+	"files.suspicious_exts[%d]: %q 应以点开头，如 .exe":     "files.suspicious_exts[%d]: %q must start with a dot, e.g. .exe",
+	"files.recent_window: 必须大于0，如 24h":               "files.recent_window: must be greater than 0, e.g. 24h",
+	"process.cpu_threshold: %v 超出范围 (0, 100]":        "process.cpu_threshold: %v is outside (0, 100]",
+	"process.memory_threshold: %v 超出范围 (0, 100]":     "process.memory_threshold: %v is outside (0, 100]",
+	"auth.window: 必须大于0，如 10m":                       "auth.window: must be greater than 0, e.g. 10m",
+	"auth.brute_force_threshold: %d 应不小于2":           "auth.brute_force_threshold: %d should be at least 2",
+	"auth.spray_account_threshold: %d 应不小于2":         "auth.spray_account_threshold: %d should be at least 2",
+	"auth.success_after_failures: %d 应不小于1":          "auth.success_after_failures: %d should be at least 1",
+	"auth.rdp_trusted_networks[%d]: %q 不是有效的IP地址或网段": "auth.rdp_trusted_networks[%d]: %q is not a valid IP address or network",
+	"registry.max_depth: %d 超出范围 1-%d":               "registry.max_depth: %d out of range 1-%d",
+	"iis.window: 必须大于0，如 5m":                         "iis.window: must be greater than 0, e.g. 5m",
+	"iis.error_burst_threshold: %d 应不小于2":            "iis.error_burst_threshold: %d should be at least 2",
+	"iis.rare_page_hits: %d 应不小于1":                   "iis.rare_page_hits: %d should be at least 1",
+	"iis.scanner_agents[%d]: 关键字为空":                  "iis.scanner_agents[%d]: empty keyword",
+	"iis.top_clients: %d 应不小于1":                      "iis.top_clients: %d should be at least 1",
+	"firewall.window: 必须大于0，如 5m":                    "firewall.window: must be greater than 0, e.g. 5m",
+	"firewall.port_scan_threshold: %d 应不小于2":         "firewall.port_scan_threshold: %d should be at least 2",
+	"firewall.blocked_inbound_threshold: %d 应不小于1":   "firewall.blocked_inbound_threshold: %d should be at least 1",
+	"firewall.rare_destination_hits: %d 应不小于1":       "firewall.rare_destination_hits: %d should be at least 1",
+	"mft.timeline_window: 必须大于0，如 72h":               "mft.timeline_window: must be greater than 0, e.g. 72h",
+	"usn.window: 必须大于0，如 1m":                         "usn.window: must be greater than 0, e.g. 1m",
+	"usn.burst_threshold: %d 应不小于2":                  "usn.burst_threshold: %d should be at least 2",
+	"usn.timeline_window: 必须大于0，如 72h":               "usn.timeline_window: must be greater than 0, e.g. 72h",
+	"registry.path_depths[%s]: %d 超出范围 1-%d":         "registry.path_depths[%s]: %d out of range 1-%d",
+	// correlation.go
+	"执行证据关联":       "Execution evidence correlation",
+	"%s: 不可用 (%v)": "%s: unavailable (%v)",
+	"%s: %d 条":     "%s: %d entries",
+	"未指定Prefetch目录 (-prefetch-dir)": "No Prefetch directory specified (-prefetch-dir)",
+	"只出现在用户可写目录中的程序: %s":            "Program seen only in user-writable directories: %s",
+	"只出现在一种执行痕迹中的程序: %d 个":          "Programs seen in only one execution artifact: %d",
+	"只有一种来源的记录可能是痕迹被清除，也可能只是该来源记录范围更广（如Shimcache中的条目不一定运行过）\n": "A single source may mean the other traces were cleared, or just that the source covers more (e.g. Shimcache entries did not necessarily run)\n",
+	"执行历史: %d 个程序": "Execution history: %d programs",
+	"完整的执行历史见报告中的“执行证据关联”一节\n": "See the Execution evidence correlation section of the report for the full history\n",
+	"用户: %s\n": "User: %s\n",
+	"来源: %s\n": "Sources: %s\n",
+	// decode.go / i18n.go
+	"不支持的代码页: %d": "unsupported code page: %d",
+	"不支持的语言: %s":  "unsupported language: %s",
+	// eventlog.go
+	"读取%s日志失败":          "Failed to read the %s log",
+	"%s日志中有%d条记录损坏，已跳过": "%s log: %d damaged records skipped",
+	"未发现相关事件":           "No matching events found",
+	"事件ID %d: %d 条\n":   "Event ID %d: %d\n",
+	"%s日志不存在":           "%s log not present",
+	// evtx.go
+	"不是有效的EVTX文件":    "not a valid EVTX file",
+	"事件记录中没有Event元素": "event record has no Event element",
+	"二进制XML数据损坏":     "corrupt binary XML",
+	// execution.go
+	"只出现在用户可写目录中": "Only in user-writable directories",
+	"只出现在一种执行痕迹中": "Only in one execution artifact",
+	// firewalllog.go
+	"读取防火墙日志失败: %s":  "Failed to read firewall logs: %s",
+	"未找到防火墙日志文件: %s": "No firewall log files found: %s",
+	"防火墙默认不记录连接，需在高级安全Windows Defender防火墙的属性中启用日志记录\n": "The firewall does not log connections by default; enable logging in the Windows Defender Firewall with Advanced Security properties\n",
+	"已分析 %d 条防火墙记录":               "Analyzed %d firewall records",
+	"疑似端口扫描: 来源 %s 在%v内访问 %d 个端口": "Possible port scan: source %s, within %v, %d ports",
+	"大量被阻止的入站连接: 来源 %s 共 %d 次":    "High volume of blocked inbound connections: source %s, %d times",
+	"允许的入站连接访问可疑端口 %d (%s): %d 次": "Allowed inbound connections to suspicious port %d (%s): %d",
+	"允许的出站连接访问可疑端口 %d (%s): %d 次": "Allowed outbound connections to suspicious port %d (%s): %d",
+	"目标":        "Destinations",
+	"%s (%d 次)": "%s (%d times)",
+	"罕见的出站目标: %d 个公网地址只出现不超过 %d 次": "Rare outbound destinations: %d public addresses seen at most %d times",
+	"出站公网目标总数: %d\n":               "Total public outbound destinations: %d\n",
+	"目标端口: %s\n":                   "Destination ports: %s\n",
+	"允许: %d 次, 阻止: %d 次\n":         "Allowed: %d, dropped: %d\n",
+	// iislog.go
+	"读取IIS日志失败: %s":                            "Failed to read IIS logs: %s",
+	"未找到IIS日志文件: %s":                           "No IIS log files found: %s",
+	"日志文件: %d 个\n无法解析的行: %d\n":                 "Log files: %d\nUnparsable lines: %d\n",
+	"已分析 %d 条IIS请求":                            "Analyzed %d IIS requests",
+	"疑似Web Shell: %s 共被请求 %d 次，其中成功的POST %d 次": "Possible web shell: %s requested %d times, %d successful POSTs",
+	"SQL注入":                "SQL injection",
+	"路径遍历":                 "Path traversal",
+	"目标页面":                 "Target pages",
+	"成功响应(2xx): %d 次\n":    "Successful responses (2xx): %d\n",
+	"%s尝试: 来源 %s 共 %d 次请求": "%s attempts: source %s, %d requests",
+	"扫描工具: 来源 %s 共 %d 次请求": "Scanner: source %s, %d requests",
+	"工具": "Tools",
+	"错误响应激增: 来源 %s 在%v内收到 %d 个4xx/5xx响应": "Error response burst: source %s, within %v, %d 4xx/5xx responses",
+	"状态码: %s\n": "Status codes: %s\n",
+	"%s: %d 次请求, %d 个错误响应, %s 至 %s": "%s: %d requests, %d error responses, %s to %s",
+	"请求最多的 %d 个来源":                  "Top %d sources by requests",
+	"来源总数: %d\n":                    "Total sources: %d\n",
+	"来源 %s 的活动时间线":                  "Activity timeline for %s",
+	"日志文件":                          "Log files",
+	// linux_baseline.go
+	"%s获取失败":                     "Failed to collect %s",
+	"密码策略检查":                     "Password Policy",
+	"pwquality配置":                "pwquality configuration",
+	"密码最小长度小于8":                  "Minimum password length is less than 8",
+	"读取/etc/login.defs失败":        "Failed to read /etc/login.defs",
+	"未设置密码有效期":                   "Password expiry is not set",
+	"密码有效期配置":                    "Password expiry configuration",
+	"系统更新检查":                     "System Updates",
+	"可升级的软件包":                    "Upgradable packages",
+	"未找到apt或yum":                 "Neither apt nor yum found",
+	"SSH配置检查":                    "SSH Configuration",
+	"读取/etc/ssh/sshd_config失败":   "Failed to read /etc/ssh/sshd_config",
+	"允许root通过SSH登录":              "root login over SSH is allowed",
+	"SSH允许密码认证":                  "SSH allows password authentication",
+	"SSH登录配置":                    "SSH login configuration",
+	"/etc/ssh/sshd_config 不存在\n": "/etc/ssh/sshd_config does not exist\n",
+	"未安装SSH服务":                   "SSH server not installed",
+	"未找到命令: %s\n":                "command not found: %s\n",
+	// linux_log.go
+	"系统日志分析":          "System Log Analysis",
+	"系统错误":            "System errors",
+	"安全日志分析":          "Security Log Analysis",
+	"认证失败: %d 次 (%s)": "Authentication failures: %d (%s)",
+	"认证失败次数较多，可能存在暴力破解，以下为最近10条记录": "Many authentication failures, possible brute force. Last 10 entries:",
+	"未找到认证日志":                 "No authentication log found",
+	"应用日志分析":                  "Application Log Analysis",
+	"Apache错误日志":              "Apache error log",
+	"Nginx错误日志":               "Nginx error log",
+	"未指定离线事件日志目录 (-evtx-dir)": "no offline event log directory specified (-evtx-dir)",
+	// linux_memory.go
+	"进程行为监控":                 "Process Behavior",
+	"获取进程列表失败":               "Failed to list processes",
+	"PID: %d 名称: %s 父进程: %d": "PID: %d Name: %s Parent: %d",
+	"僵尸进程: %d 个":             "Zombie processes: %d",
+	"未发现僵尸进程":                "No zombie processes found",
+	// linux_network.go
+	"防火墙配置":           "Firewall Configuration",
+	"iptables规则":      "iptables rules",
+	"UFW状态":           "UFW status",
+	"未找到iptables或ufw": "Neither iptables nor ufw found",
+	// linux_registry.go
+	"未指定离线注册表目录 (-hive-dir)": "no offline registry directory specified (-hive-dir)",
+	// linux_security.go
+	"文件完整性检查":       "File Integrity",
+	"可写目录中存在SUID文件": "SUID files in world-writable directories",
+	"SUID文件位于/tmp、/var/tmp、/dev/shm或/home下，常见于提权后门": "SUID files under /tmp, /var/tmp, /dev/shm or /home are a common privilege escalation backdoor",
+	"SUID文件: %d 个":           "SUID files: %d",
+	"用户安全检查":                 "User Security",
+	"读取/etc/passwd失败":        "Failed to read /etc/passwd",
+	"发现root以外的特权用户":          "Privileged users other than root found",
+	"以下账户的UID为0":             "The following accounts have UID 0",
+	"特权用户仅有root":             "root is the only privileged user",
+	"最近用户活动":                 "Recent user activity",
+	"服务检查":                   "Services",
+	"运行的服务":                  "Running services",
+	"端口检查":                   "Ports",
+	"获取监听端口失败":               "Failed to list listening ports",
+	"%s:%d 进程: %s (PID: %d)": "%s:%d Process: %s (PID: %d)",
+	"开放的端口: %d 个":            "Open ports: %d",
+	"系统未以systemd启动\n":        "system was not booted with systemd\n",
+	// main.go
+	"错误: %v\n":           "Error: %v\n",
+	"运行所有检查":             "run all checks",
+	"生成HTML格式检查报告":       "generate an HTML report",
+	"列出所有可用的检查项":         "list all available checks",
+	"仅运行指定的检查项或分组，以逗号分隔": "run only the given checks or groups, comma separated",
+	"跳过指定的检查项或分组，以逗号分隔":  "skip the given checks or groups, comma separated",
+	"单条外部命令的超时时间":        "timeout for each external command",
+	"整体运行时间限制，超时后写入已收集的结果，0表示不限制":                              "overall run time limit; results collected so far are written when it expires, 0 means no limit",
+	"单项检查的时间限制，0表示不限制":                                         "time limit for each check, 0 means no limit",
+	"检测参数配置文件（YAML或JSON），未指定的项使用内置默认值":                         "detection config file (YAML or JSON); unspecified settings use the built-in defaults",
+	"将JSON格式的运行摘要写入指定文件，\"-\"表示标准输出":                           "write a JSON run summary to the given file, \"-\" for stdout",
+	"同时执行的检查项数量，输出顺序不受影响":                                      "number of checks to run concurrently; output order is unaffected",
+	"将外部命令的输出录制到指定目录":                                          "record external command output to the given directory",
+	"从指定目录回放录制的命令输出，不执行外部命令":                                   "replay recorded command output from the given directory without running commands",
+	"输出语言: zh（中文）或 en（英文）":                                     "output language: zh (Chinese) or en (English)",
+	"外部命令输出的代码页（如936、950、932、437、1252），默认自动检测":                 "code page of external command output (e.g. 936, 950, 932, 437, 1252), detected automatically by default",
+	"\n[!] %s，正在停止检查并写入已收集的结果...\n":                            "\n[!] %s, stopping checks and writing the results collected so far...\n",
+	"主机名: %s\n操作系统: %s\n平台: %s %s\n":                           "Hostname: %s\nOS: %s\nPlatform: %s %s\n",
+	"生成报告失败: %v\n":                                             "Failed to generate report: %v\n",
+	"报告已生成: %s\n":                                              "Report written: %s\n",
+	"分析指定目录中的EVTX事件日志文件，代替读取本机日志":                              "analyze EVTX event log files in the given directory instead of the local logs",
+	"从指定目录加载Sigma规则，在事件日志上评估":                                  "Load Sigma rules from the given directory and evaluate them against event logs",
+	"分析指定目录中的注册表hive文件（SYSTEM、SOFTWARE、NTUSER.DAT等），代替读取本机注册表": "Analyze registry hive files (SYSTEM, SOFTWARE, NTUSER.DAT, ...) in the given directory instead of the local registry",
+	"分析指定目录中的IIS日志（W3C格式），代替读取本机的IIS日志目录":                      "Analyze IIS logs (W3C format) in the specified directory instead of the local IIS log directory",
+	"分析指定目录中的Windows防火墙日志（pfirewall.log），代替读取本机的防火墙日志目录":       "Analyze Windows Firewall logs (pfirewall.log) in the specified directory instead of the local firewall log directory",
+	"分析指定目录中的计划任务定义文件（System32\\Tasks），代替读取本机的计划任务":            "Analyze scheduled task definition files (System32\\Tasks) in the specified directory instead of the local scheduled tasks",
+	"分析指定目录中的Prefetch文件（*.pf），代替读取本机的Prefetch目录":               "Analyze Prefetch files (*.pf) in the given directory instead of the local Prefetch directory",
+	"分析从NTFS卷复制出的$MFT文件，检测时间戳篡改并生成文件系统时间线":                     "Analyze a $MFT file copied from an NTFS volume, detect timestomping and build a filesystem timeline",
+	"分析从NTFS卷复制出的USN日志（$UsnJrnl:$J），同时指定 -mft 时解析完整路径":         "Analyze a USN journal ($UsnJrnl:$J) copied from an NTFS volume; full paths are resolved when -mft is also given",
+	// main_linux.go
+	"分析从Windows主机收集的离线证据（配合 -evtx-dir、-hive-dir、-iis-dir 等目录参数使用）": "Analyze offline evidence collected from Windows hosts (use with directory options such as -evtx-dir, -hive-dir, -iis-dir)",
+	// main_linux.go / main_windows.go / main_other.go
+	"Linux系统应急响应工具 v1.0":                        "Linux Incident Response Tool v1.0",
+	"Linux系统应急响应报告":                             "Linux Incident Response Report",
+	"[!] 当前未以root权限运行，需要root权限的检查项将被跳过":         "[!] Not running as root, checks that require root will be skipped",
+	"需要root权限，当前有效用户不是root":                     "Requires root, the effective user is not root",
+	"Windows系统应急响应工具 v1.0":                      "Windows Incident Response Tool v1.0",
+	"Windows系统应急响应报告":                           "Windows Incident Response Report",
+	"[!] 当前未以管理员权限运行，需要管理员权限的检查项将被跳过":           "[!] Not running elevated, checks that require Administrator will be skipped",
+	"需要管理员权限，当前进程未提升":                           "Requires Administrator, the process is not elevated",
+	"需要管理员权限":                                   "Requires Administrator",
+	"系统应急响应报告":                                  "Incident Response Report",
+	"运行基础系统检查":                                  "run basic system checks",
+	"开始基础系统检查...":                               "Starting basic system checks...",
+	"运行基础应急响应检查":                                "run basic incident response checks",
+	"开始基础应急响应检查...":                             "Starting basic incident response checks...",
+	"运行注册表和文件完整性检查":                             "run registry and file integrity checks",
+	"开始注册表和文件完整性检查...":                          "Starting registry and file integrity checks...",
+	"运行内存和进程行为分析":                               "run memory and process behavior analysis",
+	"开始内存和进程行为分析...":                            "Starting memory and process behavior analysis...",
+	"运行安全检查（SUID文件、特权用户、服务和端口）":                 "run security checks (SUID files, privileged users, services and ports)",
+	"开始安全检查...":                                 "Starting security checks...",
+	"运行系统日志分析":                                  "run system log analysis",
+	"开始系统日志分析...":                               "Starting system log analysis...",
+	"运行网络安全分析":                                  "run network security analysis",
+	"开始网络安全分析...":                               "Starting network security analysis...",
+	"运行系统安全基线检查":                                "run security baseline checks",
+	"开始系统安全基线检查...":                             "Starting security baseline checks...",
+	"错误: 此工具仅支持Windows和Linux平台\n":               "Error: this tool only supports Windows and Linux\n",
+	"当前平台: %s/%s\n":                             "Current platform: %s/%s\n",
+	"请在Windows或Linux系统上运行此工具\n":                 "Please run this tool on Windows or Linux\n",
+	"开始离线证据分析...":                               "Starting offline evidence analysis...",
+	"分析从NTFS卷复制出的$MFT和USN日志（配合 -mft、-usn 参数使用）": "Analyze $MFT and USN journal files copied from an NTFS volume (use with -mft, -usn)",
+	"开始NTFS元数据分析...":                            "Starting NTFS metadata analysis...",
+	// memory.go
+	"名称: %s\n":                "Name: %s\n",
+	"CPU使用率: %.2f%%\n":        "CPU usage: %.2f%%\n",
+	"内存使用率: %.2f%%\n":         "Memory usage: %.2f%%\n",
+	"线程数: %d\n":               "Threads: %d\n",
+	"句柄数: %d\n":               "Handles: %d\n",
+	"读取字节: %d\n":              "Bytes read: %d\n",
+	"写入字节: %d\n":              "Bytes written: %d\n",
+	"网络连接数: %d\n":             "Network connections: %d\n",
+	"发现高资源使用进程: %s (PID: %d)": "High resource usage process: %s (PID: %d)",
+	"未发现高资源使用进程":              "No high resource usage processes found",
+	"内存分析":                    "Memory Analysis",
+	"获取内存信息失败":                "Failed to get memory information",
+	"总物理内存: %.2f GB\n":        "Total physical memory: %.2f GB\n",
+	"可用物理内存: %.2f GB\n":       "Available physical memory: %.2f GB\n",
+	"已使用内存: %.2f GB\n":        "Used memory: %.2f GB\n",
+	"空闲内存: %.2f GB\n":         "Free memory: %.2f GB\n",
+	"系统内存使用情况":                "System memory usage",
+	"PID: %d 名称: %s 内存使用率: %.2f%% 路径: %s 命令行: %s": "PID: %d Name: %s Memory: %.2f%% Path: %s Command line: %s",
+	"内存使用TOP 10进程": "Top 10 processes by memory",
+	// mft.go
+	"不是有效的$MFT文件":        "not a valid $MFT file",
+	"$SI创建时间早于$FN创建时间":   "$SI creation time is earlier than $FN creation time",
+	"$SI创建和修改时间的秒以下部分为0": "$SI creation and modification times have zero sub-second precision",
+	// network.go
+	"网络连接分析":                                  "Network Connections",
+	"获取网络连接失败":                                "Failed to list network connections",
+	"发现可疑端口监听: %d (%s)":                       "Suspicious listening port: %d (%s)",
+	"端口: %d (%s)\n进程: %s (PID: %d)\n状态: %s\n": "Port: %d (%s)\nProcess: %s (PID: %d)\nState: %s\n",
+	"发现可疑远程连接: %s:%d (%s)":                    "Suspicious remote connection: %s:%d (%s)",
+	"远程地址: %s:%d (%s)\n本地地址: %s:%d\n进程: %s (PID: %d)\n状态: %s\n": "Remote address: %s:%d (%s)\nLocal address: %s:%d\nProcess: %s (PID: %d)\nState: %s\n",
+	"未发现可疑端口或远程连接":                                              "No suspicious ports or remote connections found",
+	"网络接口分析":                                                    "Network Interfaces",
+	"获取网络接口失败":                                                  "Failed to list network interfaces",
+	"MAC地址: %s\n状态: %v\n":                                       "MAC address: %s\nFlags: %v\n",
+	"IP地址: %s":                                                  "IP address: %s",
+	"接口: %s":                                                    "Interface: %s",
+	"网络流量分析":                                                    "Network Traffic",
+	"获取网络流量统计失败":                                                "Failed to get network traffic statistics",
+	"发送字节: %d\n":                                                "Bytes sent: %d\n",
+	"接收字节: %d\n":                                                "Bytes received: %d\n",
+	"发送包数: %d\n":                                                "Packets sent: %d\n",
+	"接收包数: %d\n":                                                "Packets received: %d\n",
+	"错误数: %d\n":                                                 "Errors: %d\n",
+	"丢包数: %d\n":                                                 "Dropped packets: %d\n",
+	// ntfs.go
+	"MFT检查":        "MFT check",
+	"读取$MFT失败: %s": "Failed to read $MFT: %s",
+	"疑似时间戳篡改: %s":  "Possible timestomping: %s",
+	"其他疑似时间戳篡改的文件或目录: %d 个":                        "Other files or directories with possible timestomping: %d",
+	"文档等文件被程序以“写入临时文件再改名”的方式保存时，$SI创建时间也可能早于$FN\n": "Documents saved by writing a temporary file and renaming it can also have a $SI creation time earlier than $FN\n",
+	"已删除的可疑类型文件: %d 个":                             "Deleted files of suspicious types: %d",
+	"记录尚未被重用，内容驻留在MFT中的小文件可直接恢复\n":                 "The records have not been reused yet; small files whose content is resident in the MFT can be recovered directly\n",
+	"$MFT文件: %s\n":                          "$MFT file: %s\n",
+	"记录大小: %d 字节\n":                         "Record size: %d bytes\n",
+	"使用中: %d, 已删除: %d\n":                    "In use: %d, deleted: %d\n",
+	"损坏的记录: %d\n":                           "Corrupt records: %d\n",
+	"时间线: %s 至 %s, %d 条\n":                  "Timeline: %s to %s, %d entries\n",
+	"时间线过长，省略了较早的 %d 条\n":                   "Timeline too long, %d earlier entries omitted\n",
+	"MFT记录数: %d":                            "MFT records: %d",
+	"记录号: %d, 序列号: %d\n":                    "Record: %d, sequence: %d\n",
+	"文件已删除\n":                               "File deleted\n",
+	"%s 创建: %s, 修改: %s, 记录修改: %s, 访问: %s\n": "%s created: %s, modified: %s, record changed: %s, accessed: %s\n",
+	"下载来源: %s\n":                            "Downloaded from: %s\n",
+	"%s (大小: %d 字节, 修改时间: %s)":              "%s (size: %d bytes, modified: %s)",
+	" [内容驻留在MFT中]":                          " [content resident in MFT]",
+	" [下载来源: %s]":                           " [downloaded from: %s]",
+	"USN日志检查":                               "USN journal check",
+	"读取USN日志失败: %s":                         "Failed to read USN journal: %s",
+	"USN日志文件: %s\n":                         "USN journal file: %s\n",
+	"未指定 -mft，路径只包含文件名\n":                   "-mft not specified, paths contain file names only\n",
+	"无法读取$MFT，路径只包含文件名: %v\n":               "Cannot read $MFT, paths contain file names only: %v\n",
+	"通过$MFT解析出路径: %d / %d 条\n":              "Paths resolved via $MFT: %d / %d\n",
+	"删除的可执行文件或脚本: %d 个":                     "Deleted executables or scripts: %d",
+	"攻击者常在离开前删除投放的工具\n":                     "Attackers often delete the tools they dropped before leaving\n",
+	"用户可写目录中新建或改名的可执行文件或脚本: %d 个":           "Executables or scripts created or renamed in user-writable directories: %d",
+	"新建: %d, 改名: %d, 删除的可疑类型文件: %d\n":       "Created: %d, renamed: %d, deleted files of suspicious types: %d\n",
+	"USN记录数: %d":                            "USN records: %d",
+	"新文件名的扩展名: %s\n":                        "Extensions of new file names: %s\n",
+	"大量新建或改名: %v内 %d 个文件":                   "Mass creation or renaming: %[2]d files within %[1]v",
+	// prefetch.go
+	"Prefetch检查":                 "Prefetch check",
+	"读取Prefetch目录失败: %s":         "Failed to read Prefetch directory: %s",
+	"Prefetch文件数: %d":            "Prefetch files: %d",
+	"Prefetch目录: %s\n":           "Prefetch directory: %s\n",
+	"无法解析的Prefetch文件: %d 个":      "Unparsable Prefetch files: %d",
+	"%s %s (运行 %d 次)":            "%s %s (run %d times)",
+	"路径: %s\n":                   "Path: %s\n",
+	"运行次数: %d\n":                 "Run count: %d\n",
+	"Prefetch版本: %d, 哈希: %08X\n": "Prefetch version: %d, hash: %08X\n",
+	"卷: %s (序列号 %08X, 创建于 %s)\n": "Volume: %s (serial %08X, created %s)\n",
+	"运行时间: %s":                   "Run time: %s",
+	"引用文件: %s":                   "Referenced file: %s",
+	"从用户可写目录运行的程序: %s":           "Program run from a user-writable directory: %s",
+	// regf.go
+	"不是有效的注册表hive文件":    "not a valid registry hive file",
+	"这是事务日志文件，不是hive文件": "this is a transaction log, not a hive file",
+	"无效的单元偏移 %#x":       "invalid cell offset %#x",
+	"偏移 %#x 处不是注册表键":    "no registry key at offset %#x",
+	"偏移 %#x 处的子键列表损坏":   "corrupt subkey list at offset %#x",
+	"注册表项不存在: %s":       "registry key not found: %s",
+	"偏移 %#x 处的值列表损坏":    "corrupt value list at offset %#x",
+	"注册表值不存在: %s":       "registry value not found: %s",
+	"偏移 %#x 处不是注册表值":    "no registry value at offset %#x",
+	"值 %s 的数据损坏":        "corrupt data for value %s",
+	"偏移 %#x 处的分段数据损坏":   "corrupt big data at offset %#x",
+	// registry.go
+	"值 %s 不是字符串类型":                                   "value %s is not a string",
+	"离线hive中没有对应的注册表项: %s\\%s":                       "no offline hive contains %s\\%s",
+	"最后写入时间: %s\n":                                   "Last written: %s\n",
+	"hive未完整写入，已从事务日志恢复 %d 个脏页\n":                    "hive was not fully written, recovered %d dirty pages from transaction logs\n",
+	"hive未完整写入，且没有可用的事务日志 (.LOG1/.LOG2)，最近的修改可能缺失\n": "hive was not fully written and no transaction logs (.LOG1/.LOG2) are available; recent changes may be missing\n",
+	"离线hive: %s":               "Offline hive: %s",
+	"注册表检查":                    "Registry",
+	"无法打开注册表项 %s":              "Unable to open registry key %s",
+	"系统自启动项":                   "System Run key entries",
+	"用户自启动项":                   "User Run key entries",
+	"%s读取失败":                   "Failed to read %s",
+	"%s: 读取失败 (%v)":            "%s: read failed (%v)",
+	"(默认)":                     "(Default)",
+	"... (共 %d 字节)":            "... (%d bytes total)",
+	"值: %d 个\n":                "Values: %d\n",
+	"%s (最后写入时间: %s, 值: %d 个)": "%s (last written: %s, values: %d)",
+	"子键: %d 个\n":               "Subkeys: %d\n",
+	"无法读取值: %v\n":              "Unable to read values: %v\n",
+	"无法读取子键: %v\n":             "Unable to read subkeys: %v\n",
+	"映像路径不在System32中的子键: %s (%d 个)": "Subkeys with image paths outside System32: %s (%d)",
+	"映像路径位于用户可写目录: %s":              "Image path in a user-writable directory: %s",
+	"第三方软件的服务通常位于Program Files中\n":  "Services of third-party software are usually under Program Files\n",
+	"读取失败 (%v)": "read failed (%v)",
+	// report.go
+	"创建报告目录失败: %v": "failed to create report directory: %v",
+	"解析报告模板失败: %v": "failed to parse report template: %v",
+	"生成报告内容失败: %v": "failed to render report: %v",
+	"保存报告文件失败: %v": "failed to save report file: %v",
+	"错误: %v":       "Error: %v",
+	"生成时间":         "Generated at",
+	"系统信息":         "System Information",
+	"检查结果统计":       "Summary",
+	"总问题数":         "Total issues",
+	"严重问题":         "Critical",
+	"警告":           "Warning",
+	"信息":           "Info",
+	"采集失败":         "Collection failures",
+	"未完成的检查项: %d，本报告仅包含中断前已收集的结果": "Incomplete checks: %d. This report only contains results collected before the interruption",
+	"详细检查结果":  "Findings",
+	"描述":      "Description",
+	"严重程度":    "Severity",
+	"状态":      "Status",
+	"证据":      "Evidence",
+	"命令执行记录":  "Executed Commands",
+	"命令":      "Command",
+	"退出码":     "Exit code",
+	"耗时":      "Duration",
+	"错误输出":    "Stderr",
+	"超时":      "Timed out",
+	"严重":      "Critical",
+	"正常":      "OK",
+	"异常":      "Abnormal",
+	"失败":      "Failed",
+	"已跳过":     "Skipped",
+	"未完成":     "Incomplete",
+	"路径":      "Path",
+	"首次出现":    "First seen",
+	"最后出现":    "Last seen",
+	"运行次数":    "Run count",
+	"用户":      "User",
+	"标记":      "Flags",
+	"文件系统时间线": "Filesystem timeline",
+	"MACB: M修改、A访问、C记录修改、B创建": "MACB: M modified, A accessed, C record changed, B created",
+	"时间":          "Time",
+	"已删除":         "Deleted",
+	"目录":          "Directory",
+	"文件活动（USN日志）": "File activity (USN journal)",
+	"变更原因":        "Reasons",
+	"文件引用":        "File reference",
+	"已跳过的检查项: %d（权限不足，或所需的文件、命令不存在），需要完整结果时以管理员权限重新运行": "Skipped checks: %d (insufficient privileges, or a required file or command is missing). Run again elevated for complete results",
+	"注册表值":   "Registry Values",
+	"键":      "Key",
+	"名称":     "Name",
+	"类型":     "Type",
+	"数据":     "Data",
+	"最后写入时间": "Last Write Time",
+	// runner.go
+	"命令被中断: %s":         "command interrupted: %s",
+	"命令执行超时 (%v): %s":   "command timed out (%v): %s",
+	"命令 %s 退出码 %d: %s":  "command %s exited with code %d: %s",
+	"创建录制目录失败: %v":      "failed to create recording directory: %v",
+	"保存命令录制失败 %s: %v\n": "failed to save command recording %s: %v\n",
+	"读取命令录制失败 %s: %v":   "failed to read command recording %s: %v",
+	// sigma.go
+	"%s 中没有Sigma规则文件 (.yml)":           "no Sigma rule files (.yml) in %s",
+	"Sigma规则检测":                        "Sigma Rule Detection",
+	"%d 个Sigma规则文件无法加载":                "%d Sigma rule files could not be loaded",
+	"%s: %d 条规则, %d 条事件\n":             "%s: %d rules, %d events\n",
+	"已评估 %d 条Sigma规则，%d 条命中":           "Evaluated %d Sigma rules, %d matched",
+	"级别: %s\n":                         "Level: %s\n",
+	"规则ID: %s\n":                       "Rule ID: %s\n",
+	"规则文件: %s\n":                       "Rule file: %s\n",
+	"命中事件: %d 条\n":                     "Matched events: %d\n",
+	"Sigma规则命中: %s":                    "Sigma rule matched: %s",
+	"文件中没有规则":                          "no rules in file",
+	"缺少title":                          "missing title",
+	"不支持的日志源: product %s":              "unsupported log source: product %s",
+	"不支持的日志源: category %q, service %q": "unsupported log source: category %q, service %q",
+	"condition应为字符串或字符串列表":             "condition must be a string or a list of strings",
+	"缺少detection.condition":            "missing detection.condition",
+	"第%d行: 无效的检测项":                     "line %d: invalid search identifier",
+	"第%d行: 字段值应为字符串、数字或null":           "line %d: field value must be a string, number or null",
+	"不支持的修饰符: %s":                      "unsupported modifier: %s",
+	"不支持聚合条件":                          "aggregation conditions are not supported",
+	"多余的 %q":                           "unexpected %q",
+	"条件不完整":                            "incomplete condition",
+	"缺少右括号":                            "missing closing parenthesis",
+	"%s 后应为 of":                        "expected of after %s",
+	"未定义的检测项 %s":                       "undefined search identifier %s",
+	"没有与 %s 匹配的检测项":                    "no search identifiers match %s",
+	"跳过的规则: %s: %d 条\n":                "skipped rules: %s: %d\n",
+	// summary.go
+	"生成运行摘要失败: %v": "failed to generate run summary: %v",
+	"写入运行摘要失败: %v": "failed to write run summary: %v",
+	"\n检查完成: 严重 %d, 警告 %d, 信息 %d, 未完成 %d, 采集失败 %d, 已跳过 %d (退出码 %d)\n": "\nDone: critical %d, warning %d, info %d, incomplete %d, collection failures %d, skipped %d (exit code %d)\n",
+	// sysinfo.go
+	"获取系统信息失败":           "Failed to get system information",
+	"主机名: %s\n":          "Hostname: %s\n",
+	"操作系统: %s\n":         "OS: %s\n",
+	"平台: %s\n":           "Platform: %s\n",
+	"平台版本: %s\n":         "Platform version: %s\n",
+	"内核版本: %s\n":         "Kernel version: %s\n",
+	"启动时间: %s\n":         "Boot time: %s\n",
+	"主机基本信息":             "Host information",
+	"CPU信息":              "CPU",
+	"获取CPU信息失败":          "Failed to get CPU information",
+	"CPU型号: %s\n":        "CPU model: %s\n",
+	"核心数: %d\n":          "Cores: %d\n",
+	"频率: %.2f MHz\n":     "Frequency: %.2f MHz\n",
+	"CPU型号":              "CPU model",
+	"获取CPU使用率失败":         "Failed to get CPU usage",
+	"CPU%d使用率: %.2f%%\n": "CPU%d usage: %.2f%%\n",
+	"CPU使用率":             "CPU usage",
+	"内存信息":               "Memory",
+	"总内存: %.2f GB\n":     "Total memory: %.2f GB\n",
+	"可用内存: %.2f GB\n":    "Available memory: %.2f GB\n",
+	"物理内存使用情况":           "Physical memory usage",
+	"磁盘信息":               "Disks",
+	"获取磁盘分区失败":           "Failed to list disk partitions",
+	"挂载点: %s\n":          "Mount point: %s\n",
+	"文件系统: %s\n":         "File system: %s\n",
+	"总空间: %.2f GB\n":     "Total space: %.2f GB\n",
+	"已用空间: %.2f GB\n":    "Used space: %.2f GB\n",
+	"可用空间: %.2f GB\n":    "Free space: %.2f GB\n",
+	"使用率: %.2f%%\n":      "Usage: %.2f%%\n",
+	"分区: %s":             "Partition: %s",
+	"网络信息":               "Network",
+	"获取网卡信息失败":           "Failed to list network adapters",
+	"MAC地址: %s\n":        "MAC address: %s\n",
+	"状态: %v\n":           "Flags: %v\n",
+	"网卡名称: %s":           "Adapter: %s",
+	"本地地址: %s:%d":        "Local address: %s:%d",
+	" 远程地址: %s:%d":       " Remote address: %s:%d",
+	" 状态: %s":            " State: %s",
+	"活动连接数: %d":          "Active connections: %d",
+	"进程信息":               "Processes",
+	"PID: %d 名称: %s CPU使用率: %.2f%% 内存使用率: %.2f%% 命令行: %s": "PID: %d Name: %s CPU: %.2f%% Memory: %.2f%% Command line: %s",
+	"总进程数: %d":     "Total processes: %d",
+	"CPU使用率最高的进程:": "Top processes by CPU usage:",
+	// tasks.go
+	"开始 %s":          "start %s",
+	"重复间隔 %s":        "repeat every %s",
+	"延迟 %s":          "delay %s",
+	"用户 %s":          "user %s",
+	"事件 %s":          "event %s",
+	"已禁用":            "disabled",
+	"读取计划任务目录失败: %s": "Failed to read scheduled task directory: %s",
+	"任务目录: %s\n":     "Task directory: %s\n",
+	"未与TaskCache比对（离线分析时需同时指定 -hive-dir）\n":  "Not compared with TaskCache (offline analysis also requires -hive-dir)\n",
+	"读取TaskCache失败，无法比对注册表中的任务":              "Failed to read TaskCache, registry tasks not compared",
+	"无法解析的任务文件: %d 个":                        "Unparsable task files: %d",
+	"TaskCache中的任务没有对应的任务文件: %s":             "TaskCache task has no task file: %s",
+	"从用户可写目录运行: %s":                          "Runs from a user-writable directory: %s",
+	"运行编码的PowerShell命令: %s":                  "Runs an encoded PowerShell command: %s",
+	"TaskCache中没有对应的注册项":                     "No matching TaskCache entry",
+	"TaskCache\\Tasks中缺少 %s":                 "%s is missing from TaskCache\\Tasks",
+	"TaskCache中缺少SD值，schtasks和任务计划程序无法列出该任务": "SD value missing in TaskCache; schtasks and Task Scheduler cannot list this task",
+	"操作: %s":           "Action: %s",
+	"触发器: %s":          "Trigger: %s",
+	"可疑计划任务: %s":       "Suspicious scheduled task: %s",
+	"作者: %s\n":         "Author: %s\n",
+	"注册日期: %s\n":       "Registration date: %s\n",
+	"运行身份: %s\n":       "Run as: %s\n",
+	"隐藏: %v, 启用: %v\n": "Hidden: %v, enabled: %v\n",
+	"描述: %s\n":         "Description: %s\n",
+	// windows_baseline.go
+	"当前密码策略":             "Current password policy",
+	"未启用强密码要求":           "Password complexity is not required",
+	"用户账户检查":             "User Accounts",
+	"管理员组成员":             "Administrators group members",
+	"查询Guest账户失败":        "Failed to query the Guest account",
+	"无法解析Guest账户状态":      "Unable to parse the Guest account status",
+	"Guest账户未禁用":         "Guest account is enabled",
+	"Guest账户已禁用":         "Guest account is disabled",
+	"系统服务检查":             "System Services",
+	"查询服务失败: %s":         "Failed to query service: %s",
+	"无法解析服务状态: %s":       "Unable to parse service status: %s",
+	"未运行":                "not running",
+	"运行中":                "running",
+	"该服务应处于停止状态":         "This service should be stopped",
+	"系统补丁检查":             "System Patches",
+	"已安装的补丁":             "Installed patches",
+	"审计策略检查":             "Audit Policy",
+	"当前审计策略":             "Current audit policy",
+	"文件系统权限检查":           "File System Permissions",
+	"%s 权限":              "%s permissions",
+	"共享设置检查":             "Shares",
+	"当前共享":               "Current shares",
+	"UAC设置检查":            "UAC Settings",
+	"读取UAC配置失败":          "Failed to read UAC settings",
+	"UAC已禁用":             "UAC is disabled",
+	"UAC已启用":             "UAC is enabled",
+	"Windows Defender检查": "Windows Defender",
+	"Windows Defender配置": "Windows Defender preferences",
+	"Windows Defender状态": "Windows Defender status",
+	// windows_ir.go
+	"自启动项检查":      "Autoruns",
+	"读取启动文件夹失败":   "Failed to read the Startup folder",
+	"启动文件夹中存在启动项": "Startup folder contains entries",
+	"启动文件夹: %s":   "Startup folder: %s",
+	"启动文件夹为空":     "Startup folder is empty",
+	"计划任务检查":      "Scheduled Tasks",
+	"计划任务数: %d":   "Scheduled tasks: %d",
+	"启动文件夹不存在":    "Startup folder does not exist",
+	// windows_log.go
+	"系统启动和关机事件":      "System startup and shutdown events",
+	"系统错误和警告":        "System errors and warnings",
+	"驱动程序错误":         "Driver errors",
+	"登录事件分析":         "Logon events",
+	"账户管理事件":         "Account management events",
+	"策略更改事件":         "Policy change events",
+	"应用程序日志分析":       "Application Log Analysis",
+	"应用程序错误":         "Application errors",
+	"服务启动失败":         "Service start failures",
+	"PowerShell日志分析": "PowerShell Log Analysis",
+	"执行策略更改":         "Execution policy changes",
+	"脚本执行记录":         "Script execution records",
+	"IIS日志分析":        "IIS Log Analysis",
+	"防火墙日志分析":        "Firewall Log Analysis",
+	// windows_network.go
+	"防火墙规则分析":                  "Firewall Rules",
+	"获取防火墙规则失败":                "Failed to get firewall rules",
+	"%s (%s %s, 远程地址: %s)":     "%s (%s %s, remote address: %s)",
+	"入站规则对任意地址开放可疑端口: %d (%s)": "Inbound rule exposes suspicious port to any address: %d (%s)",
+	"规则: %s":         "Rule: %s",
+	"协议: %s":         "Protocol: %s",
+	"本地端口: %s":       "Local port: %s",
+	"配置文件: %s":       "Profiles: %s",
+	"发现入站允许规则: %d 条": "Inbound allow rules: %d",
+	"DNS设置检查":        "DNS Settings",
+	"获取DNS设置失败":      "Failed to get DNS settings",
+	"DNS服务器配置: %s":   "DNS servers: %s",
+	// windows_registry.go
+	"系统文件完整性检查":                "System File Integrity",
+	"文件不存在 - %s":               "File missing - %s",
+	"无法验证文件签名 %s":              "Unable to verify the signature of %s",
+	"签名状态: %s\n":               "Signature status: %s\n",
+	"签名者: %s\n":                "Signer: %s\n",
+	"大小: %d 字节\n修改时间: %v\n":    "Size: %d bytes\nModified: %v\n",
+	"文件: %s":                   "File: %s",
+	"系统文件签名无效: %s":             "Invalid system file signature: %s",
+	"可疑文件检查":                   "Suspicious Files",
+	"%s (大小: %d 字节, 修改时间: %v)": "%s (size: %d bytes, modified: %v)",
+	"检查目录出错 %s":                "Error scanning directory %s",
+	"发现可疑文件: %s":               "Suspicious files found: %s",
+	"最近%v内修改的可执行文件或脚本":         "Executables or scripts modified within the last %v",
+	"检查目录: %s":                 "Scanned directory: %s",
+	"未发现最近修改的可疑文件":             "No recently modified suspicious files found",
+}
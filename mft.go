@@ -0,0 +1,502 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mftFile 从NTFS卷复制出的$MFT文件，由 -mft 指定
+var mftFile string
+
+// $MFT由固定大小的文件记录组成（通常1024字节），记录号即在文件中的序号。
+// 记录以FILE开头，每个扇区的最后两个字节被更新序列号替换，读取时需要还原（fixup）
+const (
+	mftSignature         = "FILE"
+	mftDefaultRecordSize = 1024
+	mftSectorSize        = 512
+	mftHeaderSize        = 0x30
+	// 属性头的最小长度，驻留属性在0x10处记录内容长度和偏移，非驻留属性在0x30处记录文件大小
+	mftResidentHeaderSize    = 0x18
+	mftNonResidentHeaderSize = 0x40
+	// 根目录的记录号，0-15为$MFT、$LogFile等元数据文件
+	mftRootRecord      = 5
+	mftFirstUserRecord = 16
+	// 父目录无法找到或已被重用时，文件放在该虚拟目录下
+	mftOrphanDir = `\$OrphanFiles`
+	// 路径的最大层数，防止损坏的父目录引用形成环
+	mftMaxPathDepth = 256
+)
+
+// 记录头中的标志
+const (
+	mftRecordInUse     = 0x0001
+	mftRecordDirectory = 0x0002
+)
+
+// 属性类型
+const (
+	mftAttrStandardInformation = 0x10
+	mftAttrFileName            = 0x30
+	mftAttrData                = 0x80
+	mftAttrEnd                 = 0xffffffff
+)
+
+// $FILE_NAME中的命名空间，DOS为8.3短文件名
+const (
+	mftNamespacePOSIX    = 0
+	mftNamespaceWin32    = 1
+	mftNamespaceDOS      = 2
+	mftNamespaceWin32DOS = 3
+)
+
+// ntfsTimes $STANDARD_INFORMATION或$FILE_NAME中的四个时间戳
+type ntfsTimes struct {
+	Created  time.Time
+	Modified time.Time
+	Changed  time.Time // MFT记录的修改时间
+	Accessed time.Time
+}
+
+// mftStream 命名的数据流（ADS），如下载文件的Zone.Identifier
+type mftStream struct {
+	Name string
+	Size int64
+	Data []byte // 驻留在MFT记录中时的内容
+}
+
+// mftEntry $MFT中的一个文件或目录。扩展记录中的属性（属性列表）不做合并，
+// 对有大量硬链接或碎片的文件可能缺少部分名称或数据流
+type mftEntry struct {
+	Record    uint64
+	Sequence  uint16
+	InUse     bool
+	Directory bool
+	// 父目录的记录号和序列号
+	Parent         uint64
+	ParentSequence uint16
+	Name           string
+	Path           string
+	SI             ntfsTimes
+	FN             ntfsTimes
+	HasSI          bool
+	HasFN          bool
+	Size           int64
+	// Resident 无名数据流驻留在MFT记录中，内容在Data中，文件被删除后仍可恢复
+	Resident bool
+	Data     []byte
+	Streams  []mftStream
+}
+
+// mftTable 解析后的$MFT，Corrupt为fixup校验失败或结构损坏的记录数
+type mftTable struct {
+	RecordSize int
+	Entries    []mftEntry
+	Corrupt    int
+	index      map[uint64]int
+}
+
+func readNTFSTimes(b []byte) ntfsTimes {
+	return ntfsTimes{
+		Created:  filetimeToTime(binary.LittleEndian.Uint64(b)),
+		Modified: filetimeToTime(binary.LittleEndian.Uint64(b[8:])),
+		Changed:  filetimeToTime(binary.LittleEndian.Uint64(b[16:])),
+		Accessed: filetimeToTime(binary.LittleEndian.Uint64(b[24:])),
+	}
+}
+
+// applyMFTFixup 用更新序列数组还原每个扇区的最后两个字节，末尾与更新序列号不符说明记录未完整写入
+func applyMFTFixup(record []byte) error {
+	offset := int(binary.LittleEndian.Uint16(record[4:]))
+	count := int(binary.LittleEndian.Uint16(record[6:]))
+	if count == 0 || offset+2*count > len(record) {
+		return errors.New("mft: invalid update sequence array")
+	}
+	usn := record[offset : offset+2]
+	for i := 1; i < count; i++ {
+		end := i*mftSectorSize - 2
+		if end+2 > len(record) {
+			break
+		}
+		if !bytes.Equal(record[end:end+2], usn) {
+			return fmt.Errorf("mft: fixup mismatch in sector %d", i-1)
+		}
+		copy(record[end:end+2], record[offset+2*i:])
+	}
+	return nil
+}
+
+// parseMFTRecord 解析一条文件记录，未使用过的空记录和扩展记录返回ok为false
+func parseMFTRecord(record []byte, number uint64) (entry mftEntry, ok bool, err error) {
+	if len(record) < mftHeaderSize || string(record[:4]) != mftSignature {
+		if bytes.Equal(record[:4], []byte{0, 0, 0, 0}) {
+			return mftEntry{}, false, nil
+		}
+		return mftEntry{}, false, fmt.Errorf("mft: record %d: bad signature %q", number, record[:4])
+	}
+	if err := applyMFTFixup(record); err != nil {
+		return mftEntry{}, false, fmt.Errorf("record %d: %w", number, err)
+	}
+	if base := binary.LittleEndian.Uint64(record[0x20:]) & 0xffffffffffff; base != 0 {
+		return mftEntry{}, false, nil
+	}
+	flags := binary.LittleEndian.Uint16(record[0x16:])
+	entry = mftEntry{
+		Record:    number,
+		Sequence:  binary.LittleEndian.Uint16(record[0x10:]),
+		InUse:     flags&mftRecordInUse != 0,
+		Directory: flags&mftRecordDirectory != 0,
+	}
+
+	used := int(binary.LittleEndian.Uint32(record[0x18:]))
+	if used > len(record) || used < mftHeaderSize {
+		used = len(record)
+	}
+	nameRank := -1
+	for offset := int(binary.LittleEndian.Uint16(record[0x14:])); offset+8 <= used; {
+		attrType := binary.LittleEndian.Uint32(record[offset:])
+		if attrType == mftAttrEnd {
+			break
+		}
+		length := int(binary.LittleEndian.Uint32(record[offset+4:]))
+		if offset+length > used {
+			return mftEntry{}, false, fmt.Errorf("mft: record %d: attribute at 0x%x exceeds record", number, offset)
+		}
+		if length < mftResidentHeaderSize {
+			return mftEntry{}, false, fmt.Errorf("mft: record %d: truncated attribute at 0x%x", number, offset)
+		}
+		attr := record[offset : offset+length]
+		offset += length
+
+		nonResident := attr[8] != 0
+		nameLength := int(attr[9])
+		nameOffset := int(binary.LittleEndian.Uint16(attr[10:]))
+		if nameOffset+2*nameLength > len(attr) {
+			return mftEntry{}, false, fmt.Errorf("mft: record %d: attribute name exceeds attribute", number)
+		}
+		attrName := utf16String(attr[nameOffset : nameOffset+2*nameLength])
+
+		var content []byte
+		var size int64
+		if nonResident {
+			if len(attr) < mftNonResidentHeaderSize {
+				return mftEntry{}, false, fmt.Errorf("mft: record %d: truncated non-resident attribute", number)
+			}
+			size = int64(binary.LittleEndian.Uint64(attr[0x30:]))
+		} else {
+			contentLength := int(binary.LittleEndian.Uint32(attr[16:]))
+			contentOffset := int(binary.LittleEndian.Uint16(attr[20:]))
+			if contentOffset+contentLength > len(attr) {
+				return mftEntry{}, false, fmt.Errorf("mft: record %d: attribute content exceeds attribute", number)
+			}
+			content = attr[contentOffset : contentOffset+contentLength]
+			size = int64(contentLength)
+		}
+
+		switch attrType {
+		case mftAttrStandardInformation:
+			if len(content) >= 32 {
+				entry.SI = readNTFSTimes(content)
+				entry.HasSI = true
+			}
+		case mftAttrFileName:
+			if len(content) < 66 || 66+2*int(content[64]) > len(content) {
+				continue
+			}
+			// 同一文件可能有长文件名和8.3短文件名，优先使用Win32名称
+			rank := fileNameRank(content[65])
+			if rank <= nameRank {
+				continue
+			}
+			nameRank = rank
+			parent := binary.LittleEndian.Uint64(content)
+			entry.Parent = parent & 0xffffffffffff
+			entry.ParentSequence = uint16(parent >> 48)
+			entry.FN = readNTFSTimes(content[8:])
+			entry.HasFN = true
+			entry.Name = utf16String(content[66 : 66+2*int(content[64])])
+		case mftAttrData:
+			var data []byte
+			if !nonResident {
+				data = append([]byte(nil), content...)
+			}
+			if attrName != "" {
+				entry.Streams = append(entry.Streams, mftStream{Name: attrName, Size: size, Data: data})
+				continue
+			}
+			entry.Size = size
+			entry.Resident = !nonResident
+			entry.Data = data
+		}
+	}
+	return entry, true, nil
+}
+
+// fileNameRank 选择文件名时命名空间的优先级
+func fileNameRank(namespace byte) int {
+	switch namespace {
+	case mftNamespaceWin32, mftNamespaceWin32DOS:
+		return 2
+	case mftNamespacePOSIX:
+		return 1
+	}
+	return 0
+}
+
+// readMFT 逐条读取$MFT，记录大小取自第一条记录（$MFT本身）的分配大小
+func readMFT(ctx context.Context, r io.Reader) (*mftTable, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	header, err := br.Peek(mftHeaderSize)
+	if err != nil || string(header[:4]) != mftSignature {
+		return nil, errors.New(tr("不是有效的$MFT文件"))
+	}
+	recordSize := int(binary.LittleEndian.Uint32(header[0x1c:]))
+	if recordSize < mftSectorSize || recordSize > 64<<10 || recordSize&(recordSize-1) != 0 {
+		recordSize = mftDefaultRecordSize
+	}
+
+	table := &mftTable{RecordSize: recordSize, index: make(map[uint64]int)}
+	record := make([]byte, recordSize)
+	for number := uint64(0); ; number++ {
+		if number%4096 == 0 && ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if _, err := io.ReadFull(br, record); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				break
+			}
+			return nil, err
+		}
+		entry, ok, err := parseMFTRecord(record, number)
+		if err != nil {
+			table.Corrupt++
+			continue
+		}
+		if ok {
+			table.index[number] = len(table.Entries)
+			table.Entries = append(table.Entries, entry)
+		}
+	}
+	table.resolvePaths()
+	return table, nil
+}
+
+// 已解析的$MFT，以路径为键。$MFT检查和USN日志检查共用同一份，每个文件在一次运行中只解析一次
+var mftCache = struct {
+	sync.Mutex
+	tables map[string]*mftTable
+	errs   map[string]error
+}{tables: make(map[string]*mftTable), errs: make(map[string]error)}
+
+// loadMFT 读取并解析$MFT文件并缓存结果。因取消而中断的解析不缓存。
+// 返回的表由多个检查共用，调用方不应修改
+func loadMFT(ctx context.Context, path string) (*mftTable, error) {
+	mftCache.Lock()
+	defer mftCache.Unlock()
+	if table, ok := mftCache.tables[path]; ok {
+		return table, mftCache.errs[path]
+	}
+	table, err := openMFT(ctx, path)
+	if ctx.Err() == nil {
+		mftCache.tables[path], mftCache.errs[path] = table, err
+	}
+	return table, err
+}
+
+func openMFT(ctx context.Context, path string) (*mftTable, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readMFT(ctx, f)
+}
+
+// lookup 按文件引用（记录号和序列号）查找记录。记录被释放时序列号加1，
+// 因此已删除的记录也接受比引用大1的序列号；sequence为0时不比较
+func (t *mftTable) lookup(record uint64, sequence uint16) (*mftEntry, bool) {
+	i, ok := t.index[record]
+	if !ok {
+		return nil, false
+	}
+	e := &t.Entries[i]
+	if sequence != 0 && e.Sequence != sequence && (e.InUse || e.Sequence != sequence+1) {
+		return nil, false
+	}
+	return e, true
+}
+
+// resolvePaths 沿父目录引用拼出每条记录的完整路径（不含盘符）
+func (t *mftTable) resolvePaths() {
+	cache := make(map[uint64]string)
+	for i := range t.Entries {
+		e := &t.Entries[i]
+		if e.Record == mftRootRecord {
+			e.Path = `\`
+			continue
+		}
+		e.Path = t.directoryPath(e.Parent, e.ParentSequence, cache) + `\` + e.Name
+	}
+}
+
+// directoryPath 目录的完整路径，根目录为空串，找不到时返回孤立文件目录
+func (t *mftTable) directoryPath(record uint64, sequence uint16, cache map[uint64]string) string {
+	var names []string
+	key := record<<16 | uint64(sequence)
+	for depth := 0; record != mftRootRecord; depth++ {
+		if cached, ok := cache[key]; ok {
+			return joinReversed(cached, names)
+		}
+		dir, ok := t.lookup(record, sequence)
+		if !ok || depth >= mftMaxPathDepth || dir.Name == "" {
+			return joinReversed(mftOrphanDir, names)
+		}
+		names = append(names, dir.Name)
+		record, sequence = dir.Parent, dir.ParentSequence
+	}
+	path := joinReversed("", names)
+	if cache != nil {
+		cache[key] = path
+	}
+	return path
+}
+
+// joinReversed 把从内到外收集的目录名拼接到prefix之后
+func joinReversed(prefix string, names []string) string {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i := len(names) - 1; i >= 0; i-- {
+		b.WriteString(`\`)
+		b.WriteString(names[i])
+	}
+	return b.String()
+}
+
+// timestompReasons 时间戳篡改的迹象。SetFileTime等接口只能修改$STANDARD_INFORMATION，
+// $FILE_NAME由文件系统维护，$SI创建时间早于$FN说明被改过；工具设置的时间通常只精确到秒
+func (e mftEntry) timestompReasons() []string {
+	if !e.HasSI || !e.HasFN || e.SI.Created.IsZero() || e.FN.Created.IsZero() {
+		return nil
+	}
+	var reasons []string
+	if e.SI.Created.Before(e.FN.Created) {
+		reasons = append(reasons, tr("$SI创建时间早于$FN创建时间"))
+	}
+	if e.SI.Created.Nanosecond() == 0 && e.SI.Modified.Nanosecond() == 0 && e.FN.Created.Nanosecond() != 0 {
+		reasons = append(reasons, tr("$SI创建和修改时间的秒以下部分为0"))
+	}
+	return reasons
+}
+
+// zoneIdentifier Zone.Identifier数据流中记录的下载来源
+func (e mftEntry) zoneIdentifier() string {
+	for _, s := range e.Streams {
+		if !strings.EqualFold(s.Name, "Zone.Identifier") {
+			continue
+		}
+		var zone, url string
+		for _, line := range strings.Split(string(s.Data), "\n") {
+			key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+			switch {
+			case !ok:
+			case strings.EqualFold(key, "ZoneId"):
+				zone = value
+			case strings.EqualFold(key, "HostUrl"):
+				url = value
+			}
+		}
+		if url != "" {
+			return url
+		}
+		if zone != "" {
+			return "ZoneId=" + zone
+		}
+	}
+	return ""
+}
+
+// fileTimelineEntry 时间线中的一行，SI和FN以MACB表示该时间对应哪些时间戳，
+// M修改、A访问、C记录修改、B创建，不对应的位置为"."
+type fileTimelineEntry struct {
+	Time      time.Time
+	Path      string
+	SI        string
+	FN        string
+	Deleted   bool
+	Directory bool
+}
+
+func (e fileTimelineEntry) TimeText() string { return formatReportTime(e.Time) }
+
+// macb 时间t对应的时间戳标记
+func macb(times ntfsTimes, t time.Time) string {
+	flags := []byte("....")
+	for i, ts := range []time.Time{times.Modified, times.Accessed, times.Changed, times.Created} {
+		if ts.Equal(t) {
+			flags[i] = "MACB"[i]
+		}
+	}
+	return string(flags)
+}
+
+// buildFileTimeline 生成[from, to]内的时间线，同一文件相同时间的时间戳合并为一行，按时间先后排列。
+// 元数据文件不列出
+func buildFileTimeline(entries []mftEntry, from, to time.Time) []fileTimelineEntry {
+	var timeline []fileTimelineEntry
+	for _, e := range entries {
+		if e.Record < mftFirstUserRecord {
+			continue
+		}
+		seen := make(map[time.Time]bool)
+		var times []time.Time
+		if e.HasSI {
+			times = append(times, e.SI.Modified, e.SI.Accessed, e.SI.Changed, e.SI.Created)
+		}
+		if e.HasFN {
+			times = append(times, e.FN.Modified, e.FN.Accessed, e.FN.Changed, e.FN.Created)
+		}
+		for _, t := range times {
+			if t.IsZero() || t.Before(from) || t.After(to) || seen[t] {
+				continue
+			}
+			seen[t] = true
+			row := fileTimelineEntry{Time: t, Path: e.Path, SI: "....", FN: "....", Deleted: !e.InUse, Directory: e.Directory}
+			if e.HasSI {
+				row.SI = macb(e.SI, t)
+			}
+			if e.HasFN {
+				row.FN = macb(e.FN, t)
+			}
+			timeline = append(timeline, row)
+		}
+	}
+	sort.SliceStable(timeline, func(i, j int) bool {
+		if !timeline[i].Time.Equal(timeline[j].Time) {
+			return timeline[i].Time.Before(timeline[j].Time)
+		}
+		return timeline[i].Path < timeline[j].Path
+	})
+	return timeline
+}
+
+// latestMFTTime 记录中最新的时间戳，作为离线分析时时间线的终点，晚于now的时间（可能被篡改）不计
+func latestMFTTime(entries []mftEntry, now time.Time) time.Time {
+	var latest time.Time
+	for _, e := range entries {
+		for _, t := range []time.Time{e.SI.Modified, e.SI.Changed, e.SI.Created, e.FN.Changed, e.FN.Created} {
+			if t.After(latest) && !t.After(now) {
+				latest = t
+			}
+		}
+	}
+	return latest
+}
@@ -0,0 +1,327 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testMFTAttr 测试用属性，size非0时构造非驻留属性
+type testMFTAttr struct {
+	attrType uint32
+	name     string
+	content  []byte
+	size     uint64
+}
+
+func (a testMFTAttr) bytes() []byte {
+	name := []byte(utf16LE(a.name))
+	if a.size != 0 {
+		attr := make([]byte, 0x40)
+		binary.LittleEndian.PutUint32(attr, a.attrType)
+		attr[8] = 1
+		attr[9] = byte(len(name) / 2)
+		binary.LittleEndian.PutUint16(attr[10:], 0x40)
+		binary.LittleEndian.PutUint64(attr[0x30:], a.size)
+		attr = append(attr, name...)
+		attr = append(attr, make([]byte, (8-len(attr)%8)%8)...)
+		binary.LittleEndian.PutUint32(attr[4:], uint32(len(attr)))
+		return attr
+	}
+	contentOffset := (0x18 + len(name) + 7) &^ 7
+	attr := make([]byte, contentOffset)
+	binary.LittleEndian.PutUint32(attr, a.attrType)
+	attr[9] = byte(len(name) / 2)
+	binary.LittleEndian.PutUint16(attr[10:], 0x18)
+	copy(attr[0x18:], name)
+	binary.LittleEndian.PutUint32(attr[16:], uint32(len(a.content)))
+	binary.LittleEndian.PutUint16(attr[20:], uint16(contentOffset))
+	attr = append(attr, a.content...)
+	attr = append(attr, make([]byte, (8-len(attr)%8)%8)...)
+	binary.LittleEndian.PutUint32(attr[4:], uint32(len(attr)))
+	return attr
+}
+
+func testNTFSTimes(times ntfsTimes) []byte {
+	var b []byte
+	for _, t := range []time.Time{times.Created, times.Modified, times.Changed, times.Accessed} {
+		b = binary.LittleEndian.AppendUint64(b, timeToFiletime(t))
+	}
+	return b
+}
+
+func testSI(times ntfsTimes) testMFTAttr {
+	return testMFTAttr{attrType: mftAttrStandardInformation, content: append(testNTFSTimes(times), make([]byte, 40)...)}
+}
+
+func testFN(parent uint64, parentSeq uint16, name string, namespace byte, times ntfsTimes) testMFTAttr {
+	content := binary.LittleEndian.AppendUint64(nil, parent|uint64(parentSeq)<<48)
+	content = append(content, testNTFSTimes(times)...)
+	content = append(content, make([]byte, 24)...)
+	content = append(content, byte(len([]rune(name))), namespace)
+	return testMFTAttr{attrType: mftAttrFileName, content: append(content, utf16LE(name)...)}
+}
+
+// buildTestMFTRecord 构造1024字节的文件记录，并像磁盘上一样写入更新序列号
+func buildTestMFTRecord(sequence, flags uint16, attrs ...testMFTAttr) []byte {
+	record := make([]byte, mftDefaultRecordSize)
+	copy(record, mftSignature)
+	binary.LittleEndian.PutUint16(record[4:], 0x30)
+	binary.LittleEndian.PutUint16(record[6:], 3)
+	binary.LittleEndian.PutUint16(record[0x10:], sequence)
+	binary.LittleEndian.PutUint16(record[0x14:], 0x38)
+	binary.LittleEndian.PutUint16(record[0x16:], flags)
+	binary.LittleEndian.PutUint32(record[0x1c:], mftDefaultRecordSize)
+	offset := 0x38
+	for _, a := range attrs {
+		offset += copy(record[offset:], a.bytes())
+	}
+	binary.LittleEndian.PutUint32(record[offset:], mftAttrEnd)
+	binary.LittleEndian.PutUint32(record[0x18:], uint32(offset+8))
+
+	binary.LittleEndian.PutUint16(record[0x30:], 7)
+	for i := 1; i <= 2; i++ {
+		end := i*mftSectorSize - 2
+		copy(record[0x30+2*i:], record[end:end+2])
+		binary.LittleEndian.PutUint16(record[end:], 7)
+	}
+	return record
+}
+
+// buildTestMFT 按记录号排列，缺少的记录为全0
+func buildTestMFT(records map[int][]byte) []byte {
+	count := 0
+	for n := range records {
+		if n >= count {
+			count = n + 1
+		}
+	}
+	data := make([]byte, count*mftDefaultRecordSize)
+	for n, record := range records {
+		copy(data[n*mftDefaultRecordSize:], record)
+	}
+	return data
+}
+
+func testMFTData() (data []byte, base time.Time) {
+	base = time.Date(2024, 3, 1, 8, 0, 0, 123456700, time.UTC)
+	same := func(t time.Time) ntfsTimes { return ntfsTimes{t, t, t, t} }
+	const inUse, dir = mftRecordInUse, mftRecordInUse | mftRecordDirectory
+
+	stomped := base.Add(time.Hour)
+	old := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	zone := "[ZoneTransfer]\r\nZoneId=3\r\nHostUrl=http://198.51.100.7/evil.exe\r\n"
+
+	torn := buildTestMFTRecord(1, inUse, testSI(same(base)), testFN(mftRootRecord, mftRootRecord, "torn.txt", mftNamespaceWin32, same(base)))
+	binary.LittleEndian.PutUint16(torn[2*mftSectorSize-2:], 8)
+
+	data = buildTestMFT(map[int][]byte{
+		0:  buildTestMFTRecord(1, inUse, testSI(same(base)), testFN(mftRootRecord, mftRootRecord, "$MFT", mftNamespaceWin32DOS, same(base))),
+		5:  buildTestMFTRecord(5, dir, testSI(same(base)), testFN(mftRootRecord, mftRootRecord, ".", mftNamespaceWin32DOS, same(base))),
+		16: buildTestMFTRecord(1, dir, testSI(same(base)), testFN(mftRootRecord, mftRootRecord, "Users", mftNamespaceWin32DOS, same(base))),
+		17: buildTestMFTRecord(1, dir, testSI(same(base)), testFN(16, 1, "bob", mftNamespaceWin32DOS, same(base))),
+		18: buildTestMFTRecord(1, inUse,
+			testSI(ntfsTimes{Created: old, Modified: old, Changed: stomped, Accessed: stomped}),
+			testFN(17, 1, "evil.exe", mftNamespaceWin32DOS, same(stomped)),
+			testMFTAttr{attrType: mftAttrData, size: 73802},
+			testMFTAttr{attrType: mftAttrData, name: "Zone.Identifier", content: []byte(zone)}),
+		19: buildTestMFTRecord(2, 0, testSI(same(base)),
+			testFN(17, 1, "NOTE~1.PS1", mftNamespaceDOS, same(base)),
+			testFN(17, 1, "note.ps1", mftNamespaceWin32, same(base)),
+			testMFTAttr{attrType: mftAttrData, content: []byte("Write-Host hi")}),
+		20: buildTestMFTRecord(1, inUse, testSI(same(base)), testFN(30, 1, "orphan.txt", mftNamespaceWin32DOS, same(base))),
+		// 已删除的目录，序列号在释放时加1
+		21: buildTestMFTRecord(3, mftRecordDirectory, testSI(same(base)), testFN(mftRootRecord, mftRootRecord, "old", mftNamespaceWin32DOS, same(base))),
+		22: buildTestMFTRecord(1, inUse, testSI(same(base)), testFN(21, 2, "a.dll", mftNamespaceWin32DOS, same(base))),
+		23: torn,
+		24: buildTestMFTRecord(1, inUse,
+			testSI(ntfsTimes{Created: base.Add(-time.Minute), Modified: base, Changed: base, Accessed: base}),
+			testFN(16, 1, "report.docx", mftNamespaceWin32DOS, same(base))),
+	})
+	return data, base
+}
+
+func TestReadMFT(t *testing.T) {
+	data, base := testMFTData()
+	table, err := readMFT(context.Background(), strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.RecordSize != mftDefaultRecordSize || table.Corrupt != 1 {
+		t.Errorf("record size = %d, corrupt = %d", table.RecordSize, table.Corrupt)
+	}
+
+	paths := make(map[string]mftEntry)
+	for _, e := range table.Entries {
+		paths[e.Path] = e
+	}
+	for _, path := range []string{`\`, `\$MFT`, `\Users\bob`, `\Users\bob\evil.exe`, `\Users\bob\note.ps1`, `\$OrphanFiles\orphan.txt`, `\old\a.dll`, `\Users\report.docx`} {
+		if _, ok := paths[path]; !ok {
+			t.Errorf("missing path %s", path)
+		}
+	}
+	if len(table.Entries) != 10 {
+		t.Errorf("entries = %d, want 10", len(table.Entries))
+	}
+
+	evil := paths[`\Users\bob\evil.exe`]
+	if evil.Size != 73802 || evil.Resident || !evil.InUse || evil.Record != 18 {
+		t.Errorf("evil.exe = %+v", evil)
+	}
+	if got := evil.zoneIdentifier(); got != "http://198.51.100.7/evil.exe" {
+		t.Errorf("zone identifier = %q", got)
+	}
+	if reasons := evil.timestompReasons(); len(reasons) != 2 {
+		t.Errorf("evil.exe timestomp reasons = %q", reasons)
+	}
+
+	note := paths[`\Users\bob\note.ps1`]
+	if note.InUse || !note.Resident || string(note.Data) != "Write-Host hi" || !note.FN.Created.Equal(base) {
+		t.Errorf("note.ps1 = %+v", note)
+	}
+	if reasons := note.timestompReasons(); reasons != nil {
+		t.Errorf("note.ps1 timestomp reasons = %q", reasons)
+	}
+	if reasons := paths[`\Users\report.docx`].timestompReasons(); !reflect.DeepEqual(reasons, []string{"$SI创建时间早于$FN创建时间"}) {
+		t.Errorf("report.docx timestomp reasons = %q", reasons)
+	}
+
+	if _, err := readMFT(context.Background(), strings.NewReader("not an mft")); err == nil {
+		t.Error("invalid file: expected error")
+	}
+}
+
+func TestReadMFTTruncatedAttribute(t *testing.T) {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	times := ntfsTimes{base, base, base, base}
+	for _, tc := range []struct {
+		name   string
+		length uint32
+		flag   byte
+	}{
+		{"resident", 16, 0},
+		{"non-resident", 0x20, 1},
+	} {
+		record := buildTestMFTRecord(1, mftRecordInUse, testSI(times), testFN(mftRootRecord, mftRootRecord, "a.txt", mftNamespaceWin32DOS, times))
+		// 第一个属性从0x38开始，缩短到不足以容纳属性头
+		binary.LittleEndian.PutUint32(record[0x38+4:], tc.length)
+		record[0x38+8] = tc.flag
+		table, err := readMFT(context.Background(), strings.NewReader(string(buildTestMFT(map[int][]byte{0: record}))))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if table.Corrupt != 1 || len(table.Entries) != 0 {
+			t.Errorf("%s: corrupt = %d, entries = %d", tc.name, table.Corrupt, len(table.Entries))
+		}
+	}
+}
+
+func TestBuildFileTimeline(t *testing.T) {
+	data, base := testMFTData()
+	table, err := readMFT(context.Background(), strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	end := latestMFTTime(table.Entries, base.Add(24*time.Hour))
+	if want := base.Add(time.Hour); !end.Equal(want) {
+		t.Fatalf("latest = %v, want %v", end, want)
+	}
+	timeline := buildFileTimeline(table.Entries, base.Add(-30*time.Second), end)
+
+	var got []string
+	for _, e := range timeline {
+		got = append(got, e.Time.Sub(base).String()+" "+e.SI+" "+e.FN+" "+e.Path)
+	}
+	want := []string{
+		`0s MACB MACB \$OrphanFiles\orphan.txt`,
+		`0s MACB MACB \Users`,
+		`0s MACB MACB \Users\bob`,
+		`0s MACB MACB \Users\bob\note.ps1`,
+		`0s MAC. MACB \Users\report.docx`,
+		`0s MACB MACB \old`,
+		`0s MACB MACB \old\a.dll`,
+		`1h0m0s .AC. MACB \Users\bob\evil.exe`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("timeline:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if !timeline[3].Deleted || timeline[3].Directory || !timeline[5].Directory {
+		t.Errorf("deleted/directory flags: %+v %+v", timeline[3], timeline[5])
+	}
+}
+
+func TestAnalyzeMFT(t *testing.T) {
+	data, _ := testMFTData()
+	path := filepath.Join(t.TempDir(), "$MFT")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []CheckResult
+	analyzeMFT(context.Background(), &results, path)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Severity+" "+r.Description)
+	}
+	want := []string{
+		`warning 疑似时间戳篡改: \Users\bob\evil.exe`,
+		"info 其他疑似时间戳篡改的文件或目录: 1 个",
+		"info 已删除的可疑类型文件: 1 个",
+		"info MFT记录数: 10",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if details := results[0].Details; !strings.Contains(details, "下载来源: http://198.51.100.7/evil.exe") || !strings.Contains(details, ".0000000") {
+		t.Errorf("details:\n%s", details)
+	}
+	if deleted := results[2].Evidence; len(deleted) != 1 || !strings.Contains(deleted[0], `\Users\bob\note.ps1`) || !strings.Contains(deleted[0], "[内容驻留在MFT中]") {
+		t.Errorf("deleted = %q", deleted)
+	}
+	if !strings.Contains(results[3].Details, "损坏的记录: 1") {
+		t.Errorf("summary details:\n%s", results[3].Details)
+	}
+	if timeline, _ := results[3].Attachment.([]fileTimelineEntry); len(timeline) != 9 {
+		t.Errorf("timeline entries = %d, want 9", len(timeline))
+	}
+}
+
+func TestLoadMFTCached(t *testing.T) {
+	data, _ := testMFTData()
+	path := filepath.Join(t.TempDir(), "$MFT")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, err := loadMFT(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 第二次读取直接使用缓存，不再解析文件
+	if err := os.WriteFile(path, []byte("not an mft"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if second, err := loadMFT(context.Background(), path); err != nil || second != first {
+		t.Errorf("second load = %p, %v; want cached %p", second, err, first)
+	}
+
+	// 取消导致的失败不缓存
+	missing := filepath.Join(t.TempDir(), "$MFT")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	loadMFT(ctx, missing)
+	if err := os.WriteFile(missing, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadMFT(context.Background(), missing); err != nil {
+		t.Errorf("load after cancelled attempt: %v", err)
+	}
+}
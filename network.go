@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+// 网络连接分析结果
+type NetworkAnalysis struct {
+	LocalAddr     string
+	RemoteAddr    string
+	State         string
+	ProcessName   string
+	ProcessID     int32
+	ListeningPort int
+	Protocol      string
+}
+
+func init() {
+	registerCommonCheck("net.connections", "net", PrivilegeNone, analyzeNetworkConnections)
+	registerCommonCheck("net.interfaces", "net", PrivilegeNone, analyzeNetworkInterfaces)
+	registerCommonCheck("net.traffic", "net", PrivilegeNone, analyzeNetworkTraffic)
+}
+
+// 分析网络连接
+func analyzeNetworkConnections(ctx context.Context, results *[]CheckResult) {
+	category := tr("网络连接分析")
+
+	// 获取所有网络连接
+	conns, err := net.ConnectionsWithContext(ctx, "all")
+	if err != nil {
+		addErrorResult(results, category, tr("获取网络连接失败"), err)
+		return
+	}
+
+	// 分析每个连接
+	found := false
+	for _, conn := range conns {
+		if ctx.Err() != nil {
+			break
+		}
+		// 获取进程信息
+		proc, err := process.NewProcess(conn.Pid)
+		if err != nil {
+			continue
+		}
+
+		name, _ := proc.NameWithContext(ctx)
+		localPort := conn.Laddr.Port
+		remotePort := conn.Raddr.Port
+
+		// 检查可疑端口
+		if service, ok := config.SuspiciousPorts[int(localPort)]; ok {
+			found = true
+			addCheckResult(results, category, fmt.Sprintf(tr("发现可疑端口监听: %d (%s)"), localPort, service), SeverityWarning, StatusAbnormal,
+				fmt.Sprintf(tr("端口: %d (%s)\n进程: %s (PID: %d)\n状态: %s\n"), localPort, service, name, conn.Pid, conn.Status))
+		}
+
+		// 检查可疑远程连接
+		if service, ok := config.SuspiciousPorts[int(remotePort)]; ok {
+			found = true
+			addCheckResult(results, category, fmt.Sprintf(tr("发现可疑远程连接: %s:%d (%s)"), conn.Raddr.IP, remotePort, service), SeverityWarning, StatusAbnormal,
+				fmt.Sprintf(tr("远程地址: %s:%d (%s)\n本地地址: %s:%d\n进程: %s (PID: %d)\n状态: %s\n"),
+					conn.Raddr.IP, remotePort, service, conn.Laddr.IP, localPort, name, conn.Pid, conn.Status))
+		}
+	}
+
+	if !found {
+		addCheckResult(results, category, tr("未发现可疑端口或远程连接"), SeverityInfo, StatusOK, "")
+	}
+}
+
+// 分析网络接口
+func analyzeNetworkInterfaces(ctx context.Context, results *[]CheckResult) {
+	category := tr("网络接口分析")
+
+	// 获取所有网络接口
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		addErrorResult(results, category, tr("获取网络接口失败"), err)
+		return
+	}
+
+	for _, iface := range ifaces {
+		details := fmt.Sprintf(tr("MAC地址: %s\n状态: %v\n"), iface.HardwareAddr, iface.Flags)
+
+		// 获取IP地址
+		var addrs []string
+		for _, addr := range iface.Addrs {
+			addrs = append(addrs, fmt.Sprintf(tr("IP地址: %s"), addr.Addr))
+		}
+		addCheckResult(results, category, fmt.Sprintf(tr("接口: %s"), iface.Name), SeverityInfo, StatusOK, details, addrs...)
+	}
+}
+
+// 分析网络流量
+func analyzeNetworkTraffic(ctx context.Context, results *[]CheckResult) {
+	category := tr("网络流量分析")
+
+	// 获取网络IO计数器
+	ioStats, err := net.IOCounters(true)
+	if err != nil {
+		addErrorResult(results, category, tr("获取网络流量统计失败"), err)
+		return
+	}
+
+	for _, io := range ioStats {
+		var details strings.Builder
+		fmt.Fprintf(&details, tr("发送字节: %d\n"), io.BytesSent)
+		fmt.Fprintf(&details, tr("接收字节: %d\n"), io.BytesRecv)
+		fmt.Fprintf(&details, tr("发送包数: %d\n"), io.PacketsSent)
+		fmt.Fprintf(&details, tr("接收包数: %d\n"), io.PacketsRecv)
+		fmt.Fprintf(&details, tr("错误数: %d\n"), io.Errin+io.Errout)
+		fmt.Fprintf(&details, tr("丢包数: %d\n"), io.Dropin+io.Dropout)
+		addCheckResult(results, category, fmt.Sprintf(tr("接口: %s"), io.Name), SeverityInfo, StatusOK, details.String())
+	}
+}
@@ -0,0 +1,293 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+func init() {
+	// 正在使用的卷上无法直接读取$MFT，仅分析 -mft 指定的复制出的文件
+	registerCheck("ntfs.mft", "ntfs", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if mftFile != "" {
+			analyzeMFT(ctx, results, mftFile)
+		}
+	})
+	// 复制出的$UsnJrnl:$J，仅在指定了 -usn 时分析，同时指定 -mft 时解析完整路径
+	registerCheck("ntfs.usn", "ntfs", PrivilegeNone, func(ctx context.Context, results *[]CheckResult) {
+		if usnJournalFile != "" {
+			analyzeUSNJournal(ctx, results, usnJournalFile)
+		}
+	})
+}
+
+// maxFileTimelineEntries 报告中时间线的最大行数，超出时保留最新的部分
+const maxFileTimelineEntries = 5000
+
+// analyzeMFT 解析复制出的$MFT，报告疑似时间戳篡改和已删除的可疑类型文件，并生成文件系统时间线。
+// $MFT中的时间戳不受文件修改时间被伪造的影响，已删除但记录未被重用的文件同样可见
+func analyzeMFT(ctx context.Context, results *[]CheckResult, path string) {
+	category := tr("MFT检查")
+
+	table, err := loadMFT(ctx, path)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取$MFT失败: %s"), path), err)
+		return
+	}
+
+	var others, deleted []string
+	inUse := 0
+	for _, e := range table.Entries {
+		if e.InUse {
+			inUse++
+		}
+		if e.Record < mftFirstUserRecord {
+			continue
+		}
+		if reasons := e.timestompReasons(); len(reasons) > 0 {
+			if !e.Directory && hasSuspiciousExt(e.Name) {
+				addCheckResult(results, category, fmt.Sprintf(tr("疑似时间戳篡改: %s"), e.Path), SeverityWarning, StatusAbnormal,
+					e.details(), reasons...)
+			} else {
+				others = append(others, fmt.Sprintf("%s (%s)", e.Path, strings.Join(reasons, "; ")))
+			}
+		}
+		if !e.InUse && !e.Directory && hasSuspiciousExt(e.Name) {
+			deleted = append(deleted, e.deletedSummary())
+		}
+	}
+	if len(others) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("其他疑似时间戳篡改的文件或目录: %d 个"), len(others)), SeverityInfo, StatusAbnormal,
+			tr("文档等文件被程序以“写入临时文件再改名”的方式保存时，$SI创建时间也可能早于$FN\n"), elideLines(others, maxListItems)...)
+	}
+	if len(deleted) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("已删除的可疑类型文件: %d 个"), len(deleted)), SeverityInfo, StatusAbnormal,
+			tr("记录尚未被重用，内容驻留在MFT中的小文件可直接恢复\n"), elideLines(deleted, maxListItems)...)
+	}
+
+	end := latestMFTTime(table.Entries, time.Now())
+	start := end.Add(-config.MFT.TimelineWindow)
+	timeline := buildFileTimeline(table.Entries, start, end)
+	omitted := 0
+	if len(timeline) > maxFileTimelineEntries {
+		omitted = len(timeline) - maxFileTimelineEntries
+		timeline = timeline[omitted:]
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("$MFT文件: %s\n"), path)
+	fmt.Fprintf(&b, tr("记录大小: %d 字节\n"), table.RecordSize)
+	fmt.Fprintf(&b, tr("使用中: %d, 已删除: %d\n"), inUse, len(table.Entries)-inUse)
+	if table.Corrupt > 0 {
+		fmt.Fprintf(&b, tr("损坏的记录: %d\n"), table.Corrupt)
+	}
+	if !end.IsZero() {
+		fmt.Fprintf(&b, tr("时间线: %s 至 %s, %d 条\n"), formatReportTime(start), formatReportTime(end), len(timeline))
+	}
+	if omitted > 0 {
+		fmt.Fprintf(&b, tr("时间线过长，省略了较早的 %d 条\n"), omitted)
+	}
+	addAttachedResult(results, category, fmt.Sprintf(tr("MFT记录数: %d"), len(table.Entries)), SeverityInfo, StatusOK, b.String(), timeline)
+}
+
+// hasSuspiciousExt 文件扩展名属于配置中的可疑扩展名
+func hasSuspiciousExt(name string) bool {
+	ext := filepath.Ext(name)
+	for _, suspicious := range config.Files.SuspiciousExts {
+		if strings.EqualFold(ext, suspicious) {
+			return true
+		}
+	}
+	return false
+}
+
+// details 记录的两组时间戳和下载来源
+func (e mftEntry) details() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("记录号: %d, 序列号: %d\n"), e.Record, e.Sequence)
+	if !e.InUse {
+		b.WriteString(tr("文件已删除\n"))
+	}
+	for _, t := range []struct {
+		label string
+		times ntfsTimes
+	}{{"$SI", e.SI}, {"$FN", e.FN}} {
+		fmt.Fprintf(&b, tr("%s 创建: %s, 修改: %s, 记录修改: %s, 访问: %s\n"), t.label,
+			formatNTFSTime(t.times.Created), formatNTFSTime(t.times.Modified), formatNTFSTime(t.times.Changed), formatNTFSTime(t.times.Accessed))
+	}
+	if zone := e.zoneIdentifier(); zone != "" {
+		fmt.Fprintf(&b, tr("下载来源: %s\n"), zone)
+	}
+	return b.String()
+}
+
+// deletedSummary 已删除文件列表中的一行
+func (e mftEntry) deletedSummary() string {
+	line := fmt.Sprintf(tr("%s (大小: %d 字节, 修改时间: %s)"), e.Path, e.Size, formatNTFSTime(e.SI.Modified))
+	if e.Resident {
+		line += tr(" [内容驻留在MFT中]")
+	}
+	if zone := e.zoneIdentifier(); zone != "" {
+		line += fmt.Sprintf(tr(" [下载来源: %s]"), zone)
+	}
+	return line
+}
+
+// formatNTFSTime 精确到100纳秒，便于看出秒以下部分是否为0
+func formatNTFSTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05.0000000")
+}
+
+// analyzeUSNJournal 解析复制出的$UsnJrnl:$J，还原入侵期间新建、改名和删除的文件，即使文件已被清除。
+// 同时指定了 -mft 时用其中的目录结构补全路径
+func analyzeUSNJournal(ctx context.Context, results *[]CheckResult, path string) {
+	category := tr("USN日志检查")
+
+	records, corrupt, err := loadUSNJournal(ctx, path)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取USN日志失败: %s"), path), err)
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("USN日志文件: %s\n"), path)
+	if mftFile == "" {
+		b.WriteString(tr("未指定 -mft，路径只包含文件名\n"))
+	} else if table, err := loadMFT(ctx, mftFile); err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		fmt.Fprintf(&b, tr("无法读取$MFT，路径只包含文件名: %v\n"), err)
+	} else {
+		fmt.Fprintf(&b, tr("通过$MFT解析出路径: %d / %d 条\n"), resolveUSNPaths(records, table), len(records))
+	}
+
+	// 每次操作在文件关闭时有一条带CLOSE的记录，汇总了期间的全部变更原因，检测和时间线只使用这些记录
+	var closed, deleted, dropped []usnRecord
+	var created, renamed int
+	for _, r := range records {
+		if r.Reason&usnReasonClose == 0 {
+			continue
+		}
+		closed = append(closed, r)
+		if r.Reason&usnReasonFileCreate != 0 {
+			created++
+		}
+		if r.Reason&usnReasonRenameNewName != 0 {
+			renamed++
+		}
+		if !hasSuspiciousExt(r.Name) {
+			continue
+		}
+		if r.Reason&usnReasonFileDelete != 0 {
+			deleted = append(deleted, r)
+		} else if r.Reason&(usnReasonFileCreate|usnReasonRenameNewName) != 0 && isUserWritablePath(r.Path) {
+			dropped = append(dropped, r)
+		}
+	}
+
+	detectUSNBursts(results, category, closed)
+	if len(deleted) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("删除的可执行文件或脚本: %d 个"), len(deleted)), SeverityWarning, StatusAbnormal,
+			tr("攻击者常在离开前删除投放的工具\n"), elideLines(usnLines(deleted), maxListItems)...)
+	}
+	if len(dropped) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("用户可写目录中新建或改名的可执行文件或脚本: %d 个"), len(dropped)), SeverityWarning, StatusAbnormal,
+			"", elideLines(usnLines(dropped), maxListItems)...)
+	}
+
+	var timeline []usnRecord
+	if len(closed) > 0 {
+		fmt.Fprintf(&b, tr("时间范围: %s 至 %s\n"), formatReportTime(records[0].Time), formatReportTime(records[len(records)-1].Time))
+		end := closed[len(closed)-1].Time
+		for _, r := range closed {
+			if !r.Time.Before(end.Add(-config.USN.TimelineWindow)) {
+				timeline = append(timeline, r)
+			}
+		}
+	}
+	if len(timeline) > maxFileTimelineEntries {
+		fmt.Fprintf(&b, tr("时间线过长，省略了较早的 %d 条\n"), len(timeline)-maxFileTimelineEntries)
+		timeline = timeline[len(timeline)-maxFileTimelineEntries:]
+	}
+
+	fmt.Fprintf(&b, tr("新建: %d, 改名: %d, 删除的可疑类型文件: %d\n"), created, renamed, len(deleted))
+	if corrupt > 0 {
+		fmt.Fprintf(&b, tr("损坏的记录: %d\n"), corrupt)
+	}
+	addAttachedResult(results, category, fmt.Sprintf(tr("USN记录数: %d"), len(records)), SeverityInfo, StatusOK, b.String(), timeline)
+}
+
+// detectUSNBursts 时间窗口内新建或改名的文件数达到阈值，勒索软件加密时会在短时间内改写大量文件。
+// 只报告最密集的一个窗口，并统计新文件名的扩展名
+func detectUSNBursts(results *[]CheckResult, category string, closed []usnRecord) {
+	var changes []usnRecord
+	for _, r := range closed {
+		if r.Reason&(usnReasonFileCreate|usnReasonRenameNewName) != 0 {
+			changes = append(changes, r)
+		}
+	}
+	window := config.USN.Window
+	start, end := 0, 0
+	for i, j := 0, 0; j < len(changes); j++ {
+		for changes[j].Time.Sub(changes[i].Time) > window {
+			i++
+		}
+		if j+1-i > end-start {
+			start, end = i, j+1
+		}
+	}
+	if end-start < config.USN.BurstThreshold {
+		return
+	}
+	burst := changes[start:end]
+
+	exts := make(map[string]int)
+	for _, r := range burst {
+		exts[strings.ToLower(filepath.Ext(r.Name))]++
+	}
+	// 按出现次数从多到少排列，次数相同时按名称排列
+	ranked := slices.Sorted(maps.Keys(exts))
+	slices.SortStableFunc(ranked, func(a, b string) int { return cmp.Compare(exts[b], exts[a]) })
+	var top []string
+	for _, ext := range ranked {
+		if len(top) == 5 {
+			break
+		}
+		name := ext
+		if name == "" {
+			name = tr("(无)")
+		}
+		top = append(top, fmt.Sprintf("%s×%d", name, exts[ext]))
+	}
+	details := fmt.Sprintf(tr("时间范围: %s 至 %s\n"), formatReportTime(burst[0].Time), formatReportTime(burst[len(burst)-1].Time)) +
+		fmt.Sprintf(tr("新文件名的扩展名: %s\n"), strings.Join(top, ", "))
+	addCheckResult(results, category,
+		fmt.Sprintf(tr("大量新建或改名: %v内 %d 个文件"), window, len(burst)),
+		SeverityWarning, StatusAbnormal, details, elideLines(usnLines(burst), maxEventEvidence)...)
+}
+
+func usnLines(records []usnRecord) []string {
+	lines := make([]string, len(records))
+	for i, r := range records {
+		lines[i] = fmt.Sprintf("%s %s [%s]", formatReportTime(r.Time), r.Path, r.ReasonText())
+	}
+	return lines
+}
@@ -0,0 +1,315 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// prefetchDir Prefetch文件所在的目录，由 -prefetch-dir 指定，Windows上为空时读取 %SystemRoot%\Prefetch
+var prefetchDir string
+
+// Prefetch文件格式（SCCA），版本17为XP/2003，23为Vista/7，26为8.1，30为10/11
+const (
+	prefetchSignature     = "SCCA"
+	prefetchHeaderSize    = 84
+	prefetchNameSize      = 60      // 可执行文件名，UTF-16，最多29个字符
+	prefetchMaxSize       = 8 << 20 // 解压后长度的上限
+	prefetchCompressedSig = "MAM"
+	prefetchXpressHuffman = 4 // MAM头中的压缩算法
+)
+
+// prefetchLayout 各版本中最后运行时间、运行次数和卷信息条目的位置与大小
+type prefetchLayout struct {
+	lastRun    int
+	runTimes   int
+	runCount   int
+	volumeSize int
+}
+
+var prefetchLayouts = map[uint32]prefetchLayout{
+	17: {lastRun: 0x78, runTimes: 1, runCount: 0x90, volumeSize: 40},
+	23: {lastRun: 0x80, runTimes: 1, runCount: 0x98, volumeSize: 104},
+	26: {lastRun: 0x80, runTimes: 8, runCount: 0xd0, volumeSize: 104},
+	30: {lastRun: 0x80, runTimes: 8, runCount: 0xd0, volumeSize: 96},
+}
+
+// prefetchVolume Prefetch文件引用的卷
+type prefetchVolume struct {
+	Device  string
+	Serial  uint32
+	Created time.Time
+}
+
+// prefetchFile 从Prefetch文件中提取的执行记录
+type prefetchFile struct {
+	Version    uint32
+	Executable string // 文件头中的可执行文件名，超过29个字符时被截断
+	Hash       uint32
+	Path       string // 引用文件中与可执行文件名对应的完整路径，找不到时为空
+	RunCount   uint32
+	RunTimes   []time.Time // 最近的运行时间，从新到旧
+	Files      []string
+	Volumes    []prefetchVolume
+}
+
+// LastRun 最近一次运行的时间
+func (p prefetchFile) LastRun() time.Time {
+	if len(p.RunTimes) == 0 {
+		return time.Time{}
+	}
+	return p.RunTimes[0]
+}
+
+// decompressPrefetch Windows 10起的Prefetch文件以MAM头开始，内容经过LZXPRESS Huffman压缩
+func decompressPrefetch(data []byte) ([]byte, error) {
+	if len(data) < 8 || string(data[:3]) != prefetchCompressedSig {
+		return data, nil
+	}
+	if algorithm := data[3] & 0x0f; algorithm != prefetchXpressHuffman {
+		return nil, fmt.Errorf("unsupported prefetch compression %d", algorithm)
+	}
+	size := binary.LittleEndian.Uint32(data[4:])
+	if size > prefetchMaxSize {
+		return nil, fmt.Errorf("prefetch too large: %d bytes", size)
+	}
+	header := 8
+	if data[3]&0xf0 != 0 {
+		header += 4 // 带CRC32校验值
+	}
+	if len(data) < header {
+		return nil, errors.New("prefetch: truncated MAM header")
+	}
+	return decompressXpressHuffman(data[header:], int(size))
+}
+
+// parsePrefetch 解析Prefetch文件，压缩的文件先解压
+func parsePrefetch(data []byte) (prefetchFile, error) {
+	data, err := decompressPrefetch(data)
+	if err != nil {
+		return prefetchFile{}, err
+	}
+	if len(data) < prefetchHeaderSize || string(data[4:8]) != prefetchSignature {
+		return prefetchFile{}, errors.New("not a prefetch file")
+	}
+	version := binary.LittleEndian.Uint32(data)
+	layout, ok := prefetchLayouts[version]
+	if !ok {
+		return prefetchFile{}, fmt.Errorf("unsupported prefetch version %d", version)
+	}
+	u32 := func(off int) uint32 {
+		if off+4 > len(data) {
+			return 0
+		}
+		return binary.LittleEndian.Uint32(data[off:])
+	}
+	// 30版的另一种文件信息布局少8字节，文件度量数组从0x128开始，运行次数位于0xc8
+	if version == 30 && u32(0x54) == 0x128 {
+		layout.runCount = 0xc8
+	}
+	if layout.runCount+4 > len(data) || layout.lastRun+8*layout.runTimes > len(data) {
+		return prefetchFile{}, errors.New("prefetch: truncated file information")
+	}
+	section := func(off, size uint32) ([]byte, error) {
+		if uint64(off)+uint64(size) > uint64(len(data)) {
+			return nil, fmt.Errorf("prefetch: section at 0x%x exceeds file", off)
+		}
+		return data[off : off+size], nil
+	}
+
+	p := prefetchFile{
+		Version:    version,
+		Executable: nulTerminatedUTF16(data[16 : 16+prefetchNameSize]),
+		Hash:       u32(76),
+		RunCount:   u32(layout.runCount),
+	}
+	for i := 0; i < layout.runTimes; i++ {
+		if t := filetimeToTime(binary.LittleEndian.Uint64(data[layout.lastRun+8*i:])); !t.IsZero() {
+			p.RunTimes = append(p.RunTimes, t)
+		}
+	}
+
+	names, err := section(u32(0x64), u32(0x68))
+	if err != nil {
+		return prefetchFile{}, err
+	}
+	p.Files = splitUTF16Strings(names)
+	p.Path = prefetchExecutablePath(p.Executable, p.Files)
+
+	volumes, err := section(u32(0x6c), u32(0x74))
+	if err != nil {
+		return prefetchFile{}, err
+	}
+	for i := 0; i < int(u32(0x70)); i++ {
+		entry := i * layout.volumeSize
+		if entry+layout.volumeSize > len(volumes) {
+			return prefetchFile{}, errors.New("prefetch: truncated volume information")
+		}
+		offset := binary.LittleEndian.Uint32(volumes[entry:])
+		chars := binary.LittleEndian.Uint32(volumes[entry+4:])
+		if uint64(offset)+2*uint64(chars) > uint64(len(volumes)) {
+			return prefetchFile{}, errors.New("prefetch: volume path exceeds volume information")
+		}
+		volume := prefetchVolume{
+			Device: utf16String(volumes[offset : offset+2*chars]),
+			Serial: binary.LittleEndian.Uint32(volumes[entry+16:]),
+		}
+		volume.Created = filetimeToTime(binary.LittleEndian.Uint64(volumes[entry+8:]))
+		p.Volumes = append(p.Volumes, volume)
+	}
+	return p, nil
+}
+
+// nulTerminatedUTF16 解码以0结尾的UTF-16LE字符串
+func nulTerminatedUTF16(b []byte) string {
+	for i := 0; i+1 < len(b); i += 2 {
+		if b[i] == 0 && b[i+1] == 0 {
+			return utf16String(b[:i])
+		}
+	}
+	return utf16String(b)
+}
+
+// splitUTF16Strings 拆分以0分隔的UTF-16LE字符串序列，忽略空串
+func splitUTF16Strings(b []byte) []string {
+	var strs []string
+	var u []uint16
+	for i := 0; i+1 < len(b); i += 2 {
+		c := binary.LittleEndian.Uint16(b[i:])
+		if c != 0 {
+			u = append(u, c)
+			continue
+		}
+		if len(u) > 0 {
+			strs = append(strs, string(utf16.Decode(u)))
+			u = u[:0]
+		}
+	}
+	if len(u) > 0 {
+		strs = append(strs, string(utf16.Decode(u)))
+	}
+	return strs
+}
+
+// prefetchExecutablePath 在引用文件中查找可执行文件本身，文件头中的名称可能被截断
+func prefetchExecutablePath(name string, files []string) string {
+	if name == "" {
+		return ""
+	}
+	truncated := len(utf16.Encode([]rune(name))) >= prefetchNameSize/2-1
+	for _, file := range files {
+		base := file[strings.LastIndex(file, `\`)+1:]
+		if strings.EqualFold(base, name) || truncated && strings.HasPrefix(strings.ToUpper(base), strings.ToUpper(name)) {
+			return file
+		}
+	}
+	return ""
+}
+
+// loadPrefetch 解析目录中的所有Prefetch文件，无法解析的文件在problems中列出
+func loadPrefetch(ctx context.Context, dir string) (files []prefetchFile, problems []string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return files, problems, ctx.Err()
+		}
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".pf") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err == nil {
+			var p prefetchFile
+			if p, err = parsePrefetch(data); err == nil {
+				files = append(files, p)
+				continue
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s: %v", entry.Name(), err))
+	}
+	return files, problems, nil
+}
+
+// analyzePrefetch 解析目录中的Prefetch文件，列出执行过的程序并报告从用户可写目录运行的程序
+func analyzePrefetch(ctx context.Context, results *[]CheckResult, dir string) {
+	category := tr("Prefetch检查")
+
+	files, problems, err := loadPrefetch(ctx, dir)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取Prefetch目录失败: %s"), dir), err)
+		return
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].LastRun().After(files[j].LastRun()) })
+
+	var listing []string
+	for _, p := range files {
+		listing = append(listing, p.summary())
+		if p.Path != "" && isUserWritablePath(p.Path) {
+			reportPrefetch(results, category, p)
+		}
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("Prefetch文件数: %d"), len(files)), SeverityInfo, StatusOK,
+		fmt.Sprintf(tr("Prefetch目录: %s\n"), dir), listing...)
+
+	if len(problems) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("无法解析的Prefetch文件: %d 个"), len(problems)), SeverityInfo, StatusFailed, "", problems...)
+	}
+}
+
+// summary 程序列表中的一行
+func (p prefetchFile) summary() string {
+	last := "-"
+	if !p.LastRun().IsZero() {
+		last = formatReportTime(p.LastRun())
+	}
+	path := p.Path
+	if path == "" {
+		path = p.Executable
+	}
+	return fmt.Sprintf(tr("%s %s (运行 %d 次)"), last, path, p.RunCount)
+}
+
+// reportPrefetch 记录从用户可写目录运行的程序，证据中列出运行时间和同样位于用户可写目录的引用文件
+func reportPrefetch(results *[]CheckResult, category string, p prefetchFile) {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("路径: %s\n"), p.Path)
+	fmt.Fprintf(&b, tr("运行次数: %d\n"), p.RunCount)
+	fmt.Fprintf(&b, tr("Prefetch版本: %d, 哈希: %08X\n"), p.Version, p.Hash)
+	for _, v := range p.Volumes {
+		created := "-"
+		if !v.Created.IsZero() {
+			created = formatReportTime(v.Created)
+		}
+		fmt.Fprintf(&b, tr("卷: %s (序列号 %08X, 创建于 %s)\n"), v.Device, v.Serial, created)
+	}
+
+	var evidence []string
+	for _, t := range p.RunTimes {
+		evidence = append(evidence, fmt.Sprintf(tr("运行时间: %s"), formatReportTime(t)))
+	}
+	var loaded []string
+	for _, file := range p.Files {
+		if file != p.Path && isUserWritablePath(file) {
+			loaded = append(loaded, fmt.Sprintf(tr("引用文件: %s"), file))
+		}
+	}
+	evidence = append(evidence, elideLines(loaded, maxListItems)...)
+
+	addCheckResult(results, category, fmt.Sprintf(tr("从用户可写目录运行的程序: %s"), p.Executable),
+		SeverityWarning, StatusAbnormal, b.String(), evidence...)
+}
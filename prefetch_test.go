@@ -0,0 +1,200 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试用Prefetch文件中各版本文件信息结束的位置，即文件度量数组的偏移
+var testPrefetchInfoEnd = map[uint32]int{17: 0x98, 23: 0xf0, 26: 0x130, 30: 0x130}
+
+// buildTestPrefetch 构造未压缩的Prefetch文件，只包含引用文件名和一个卷
+func buildTestPrefetch(version uint32, variant2 bool, name string, runCount uint32, runTimes []time.Time, files []string) []byte {
+	layout := prefetchLayouts[version]
+	infoEnd := testPrefetchInfoEnd[version]
+	if variant2 {
+		infoEnd, layout.runCount = 0x128, 0xc8
+	}
+	data := make([]byte, infoEnd)
+	binary.LittleEndian.PutUint32(data, version)
+	copy(data[4:], prefetchSignature)
+	copy(data[16:], utf16LE(name))
+	binary.LittleEndian.PutUint32(data[76:], 0xdeadbeef)
+	binary.LittleEndian.PutUint32(data[0x54:], uint32(infoEnd))
+	for i, t := range runTimes {
+		binary.LittleEndian.PutUint64(data[layout.lastRun+8*i:], timeToFiletime(t))
+	}
+	binary.LittleEndian.PutUint32(data[layout.runCount:], runCount)
+
+	var names []byte
+	for _, file := range files {
+		names = append(names, utf16LE(file+"\x00")...)
+	}
+	binary.LittleEndian.PutUint32(data[0x64:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[0x68:], uint32(len(names)))
+	data = append(data, names...)
+
+	device := utf16LE(`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}`)
+	volume := make([]byte, layout.volumeSize)
+	binary.LittleEndian.PutUint32(volume, uint32(layout.volumeSize))
+	binary.LittleEndian.PutUint32(volume[4:], uint32(len(device)/2))
+	binary.LittleEndian.PutUint64(volume[8:], timeToFiletime(time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)))
+	binary.LittleEndian.PutUint32(volume[16:], 0x4a3b2c1d)
+	volume = append(volume, device...)
+	binary.LittleEndian.PutUint32(data[0x6c:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(data[0x70:], 1)
+	binary.LittleEndian.PutUint32(data[0x74:], uint32(len(volume)))
+	data = append(data, volume...)
+
+	binary.LittleEndian.PutUint32(data[12:], uint32(len(data)))
+	return data
+}
+
+// compressTestPrefetch 按Windows 10的格式加上MAM头并压缩
+func compressTestPrefetch(data []byte) []byte {
+	header := []byte{'M', 'A', 'M', prefetchXpressHuffman, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(data)))
+	return append(header, xpressTestCompress(data)...)
+}
+
+func TestParsePrefetch(t *testing.T) {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	runTimes := []time.Time{base.Add(2 * time.Hour), base.Add(time.Hour), base}
+	files := []string{
+		`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\WINDOWS\SYSTEM32\NTDLL.DLL`,
+		`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\USERS\BOB\APPDATA\LOCAL\TEMP\A-VERY-LONG-INSTALLER-NAME-V2.EXE`,
+		`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\USERS\BOB\APPDATA\LOCAL\TEMP\HELPER.DLL`,
+	}
+	const name = "A-VERY-LONG-INSTALLER-NAME-V2" // 29个字符，截断了扩展名
+
+	tests := []struct {
+		name     string
+		data     []byte
+		version  uint32
+		runTimes []time.Time
+	}{
+		{"v17", buildTestPrefetch(17, false, name, 7, runTimes[:1], files), 17, runTimes[:1]},
+		{"v23", buildTestPrefetch(23, false, name, 7, runTimes[:1], files), 23, runTimes[:1]},
+		{"v26", buildTestPrefetch(26, false, name, 7, runTimes, files), 26, runTimes},
+		{"v30", buildTestPrefetch(30, false, name, 7, runTimes, files), 30, runTimes},
+		{"v30 variant 2", buildTestPrefetch(30, true, name, 7, runTimes, files), 30, runTimes},
+		{"v30 compressed", compressTestPrefetch(buildTestPrefetch(30, false, name, 7, runTimes, files)), 30, runTimes},
+	}
+	for _, tt := range tests {
+		p, err := parsePrefetch(tt.data)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		want := prefetchFile{
+			Version:    tt.version,
+			Executable: name,
+			Hash:       0xdeadbeef,
+			Path:       files[1],
+			RunCount:   7,
+			RunTimes:   tt.runTimes,
+			Files:      files,
+			Volumes: []prefetchVolume{{
+				Device:  `\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}`,
+				Serial:  0x4a3b2c1d,
+				Created: time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC),
+			}},
+		}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("%s:\n%+v\nwant\n%+v", tt.name, p, want)
+		}
+	}
+
+	for _, data := range [][]byte{[]byte("not a prefetch file at all"), buildTestPrefetch(30, false, name, 1, nil, files)[:0x100]} {
+		if _, err := parsePrefetch(data); err == nil {
+			t.Errorf("parsePrefetch(%d bytes): expected error", len(data))
+		}
+	}
+}
+
+// testdata中的Prefetch文件使用变长的范式Huffman码压缩（Windows生成的文件也是如此），
+// 而compressTestPrefetch中所有符号的码长相同
+func TestParsePrefetchFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("testdata", "prefetch", "NOTEPAD.EXE-D8414F97.pf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := parsePrefetch(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const volume = `\VOLUME{01d6f3a2b4c5d6e7-a1b2c3d4}`
+	if p.Version != 30 || p.Executable != "NOTEPAD.EXE" || p.Hash != 0xd8414f97 || p.RunCount != 23 {
+		t.Errorf("header = %d %q 0x%x, run count %d", p.Version, p.Executable, p.Hash, p.RunCount)
+	}
+	if p.Path != volume+`\WINDOWS\SYSTEM32\NOTEPAD.EXE` || len(p.Files) != 31 || p.Files[30] != volume+`\USERS\BOB\DESKTOP\NOTES.TXT` {
+		t.Errorf("path = %q, files = %d", p.Path, len(p.Files))
+	}
+	last := time.Date(2024, 3, 1, 9, 15, 42, 123456000, time.UTC)
+	var want []time.Time
+	for i := 0; i < 8; i++ {
+		want = append(want, last.Add(-time.Duration(i)*(5*time.Hour+7*time.Minute)))
+	}
+	if !reflect.DeepEqual(p.RunTimes, want) {
+		t.Errorf("run times = %v", p.RunTimes)
+	}
+	wantVolumes := []prefetchVolume{{Device: volume, Serial: 0xa1b2c3d4, Created: time.Date(2020, 11, 3, 14, 22, 5, 0, time.UTC)}}
+	if !reflect.DeepEqual(p.Volumes, wantVolumes) {
+		t.Errorf("volumes = %+v", p.Volumes)
+	}
+}
+
+func TestAnalyzePrefetch(t *testing.T) {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	files := map[string][]byte{
+		"EVIL.EXE-1A2B3C4D.pf": compressTestPrefetch(buildTestPrefetch(30, false, "EVIL.EXE", 3, []time.Time{base.Add(time.Hour), base}, []string{
+			`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\WINDOWS\SYSTEM32\NTDLL.DLL`,
+			`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\USERS\BOB\APPDATA\LOCAL\TEMP\EVIL.EXE`,
+			`\VOLUME{01d8a1b2c3d4e5f6-4a3b2c1d}\USERS\BOB\APPDATA\LOCAL\TEMP\PAYLOAD.DLL`,
+		})),
+		"CMD.EXE-4A81B364.pf": buildTestPrefetch(23, false, "CMD.EXE", 12, []time.Time{base}, []string{
+			`\DEVICE\HARDDISKVOLUME2\WINDOWS\SYSTEM32\CMD.EXE`,
+		}),
+		"BROKEN.EXE-00000000.pf": []byte("MAM\x04"),
+		"Layout.ini":             []byte("ignored"),
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var results []CheckResult
+	analyzePrefetch(context.Background(), &results, dir)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Severity+" "+r.Description)
+	}
+	want := []string{
+		"warning 从用户可写目录运行的程序: EVIL.EXE",
+		"info Prefetch文件数: 2",
+		"info 无法解析的Prefetch文件: 1 个",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	evidence := strings.Join(results[0].Evidence, "\n")
+	if !strings.Contains(evidence, "运行时间: "+formatReportTime(base)) || !strings.Contains(evidence, `TEMP\PAYLOAD.DLL`) || strings.Contains(evidence, "NTDLL") {
+		t.Errorf("evidence = %q", evidence)
+	}
+	// 按最后运行时间从新到旧排列
+	if listing := results[1].Evidence; len(listing) != 2 || !strings.Contains(listing[0], "EVIL.EXE (运行 3 次)") {
+		t.Errorf("listing = %q", listing)
+	}
+}
@@ -0,0 +1,591 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 注册表hive文件（regf）由4096字节的基本块和若干hbin组成，hbin中为单元（cell）。
+// 单元偏移相对于第一个hbin，单元以int32长度开头，负数表示已分配
+const (
+	regfBaseBlockSize = 4096
+	// 事务日志中的基本块只占一个扇区，之后为脏页
+	regfLogBaseBlockSize = 512
+	regfSectorSize       = 512
+	regfLogEntryHeader   = 40
+	// 超过该长度的值数据分段存放在db单元中
+	regfBigDataSegment = 16344
+	// 子键列表ri的最大嵌套层数，防止损坏的数据导致无限递归
+	regfMaxListDepth = 8
+)
+
+// 基本块中的文件类型
+const (
+	regfFileTypePrimary = 0
+	regfFileTypeLogOld  = 1
+	regfFileTypeLogNew  = 6
+)
+
+// 注册表值类型
+const (
+	regNone                     = 0
+	regSZ                       = 1
+	regExpandSZ                 = 2
+	regBinary                   = 3
+	regDword                    = 4
+	regDwordBigEndian           = 5
+	regLink                     = 6
+	regMultiSZ                  = 7
+	regResourceList             = 8
+	regFullResourceDescriptor   = 9
+	regResourceRequirementsList = 10
+	regQword                    = 11
+)
+
+// 键和值的标志
+const (
+	regfKeyCompName   = 0x0020
+	regfValueCompName = 0x0001
+	regfDataResident  = 0x80000000
+)
+
+var (
+	regfMagic     = []byte("regf")
+	regfLogMagic  = []byte("HvLE")
+	regfDirtMagic = []byte("DIRT")
+)
+
+// regfBaseBlock 基本块中的字段。两个序号不一致说明hive未完整写入，需要用事务日志恢复
+type regfBaseBlock struct {
+	primarySeq   uint32
+	secondarySeq uint32
+	lastWrite    time.Time
+	minor        uint32
+	fileType     uint32
+	root         uint32
+	dataSize     uint32
+}
+
+func parseRegfBaseBlock(b []byte) (regfBaseBlock, error) {
+	if len(b) < regfLogBaseBlockSize || !bytes.HasPrefix(b, regfMagic) {
+		return regfBaseBlock{}, errors.New(tr("不是有效的注册表hive文件"))
+	}
+	return regfBaseBlock{
+		primarySeq:   binary.LittleEndian.Uint32(b[4:]),
+		secondarySeq: binary.LittleEndian.Uint32(b[8:]),
+		lastWrite:    filetimeToTime(binary.LittleEndian.Uint64(b[12:])),
+		minor:        binary.LittleEndian.Uint32(b[24:]),
+		fileType:     binary.LittleEndian.Uint32(b[28:]),
+		root:         binary.LittleEndian.Uint32(b[36:]),
+		dataSize:     binary.LittleEndian.Uint32(b[40:]),
+	}, nil
+}
+
+// regfHive 解析后的hive，data为所有hbin的数据
+type regfHive struct {
+	data      []byte
+	root      uint32
+	minor     uint32
+	LastWrite time.Time
+	// Dirty 主文件未完整写入，Recovered为从事务日志重放的脏页数
+	Dirty     bool
+	Recovered int
+}
+
+// openRegfHive 读取hive文件，hive未完整写入时用同目录下的 .LOG1/.LOG2 事务日志恢复
+func openRegfHive(path string) (*regfHive, error) {
+	primary, err := readFileFold(path)
+	if err != nil {
+		return nil, err
+	}
+	var logs [][]byte
+	for _, suffix := range []string{".LOG1", ".LOG2", ".LOG"} {
+		if log, err := readFileFold(path + suffix); err == nil {
+			logs = append(logs, log)
+		}
+	}
+	hive, err := parseRegfHive(primary, logs...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return hive, nil
+}
+
+// readFileFold 读取文件，文件名不区分大小写，用于从Linux上挂载或复制的Windows目录中查找文件
+func readFileFold(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if !os.IsNotExist(err) {
+		return data, err
+	}
+	dir := filepath.Dir(path)
+	entries, dirErr := os.ReadDir(dir)
+	if dirErr != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), filepath.Base(path)) {
+			return os.ReadFile(filepath.Join(dir, entry.Name()))
+		}
+	}
+	return nil, err
+}
+
+// parseRegfHive 解析hive文件的内容，logs为事务日志，仅在主文件未完整写入时使用
+func parseRegfHive(primary []byte, logs ...[]byte) (*regfHive, error) {
+	base, err := parseRegfBaseBlock(primary)
+	if err != nil || len(primary) < regfBaseBlockSize {
+		return nil, errors.New(tr("不是有效的注册表hive文件"))
+	}
+	if base.fileType != regfFileTypePrimary {
+		return nil, errors.New(tr("这是事务日志文件，不是hive文件"))
+	}
+
+	data := primary[regfBaseBlockSize:]
+	if int(base.dataSize) < len(data) {
+		data = data[:base.dataSize]
+	}
+	h := &regfHive{data: data, root: base.root, minor: base.minor, LastWrite: base.lastWrite}
+	if base.primarySeq != base.secondarySeq {
+		h.Dirty = true
+		h.replayLogs(base, logs)
+	}
+	if _, err := h.Root(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// regfLogEntry 事务日志中的一组脏页，offset相对于hbin数据的起始位置
+type regfLogEntry struct {
+	seq      uint32
+	dataSize uint32
+	pages    []regfDirtyPage
+}
+
+type regfDirtyPage struct {
+	offset uint32
+	data   []byte
+}
+
+// replayLogs 按序号重放事务日志中不早于主文件最后一次完整写入的日志条目。
+// 新格式（Windows 8.1及以后）的日志由HvLE条目组成，旧格式为DIRT位图和脏扇区。
+// 同一序号在两个日志中都存在时只重放一次，序号不连续时停止
+func (h *regfHive) replayLogs(base regfBaseBlock, logs [][]byte) {
+	entries := make(map[uint32]regfLogEntry)
+	for _, log := range logs {
+		logBase, err := parseRegfBaseBlock(log)
+		if err != nil {
+			continue
+		}
+		var parsed []regfLogEntry
+		switch logBase.fileType {
+		case regfFileTypeLogNew:
+			parsed = parseRegfLogEntries(log[regfLogBaseBlockSize:])
+		case regfFileTypeLogOld:
+			if entry, ok := parseRegfDirtyVector(log, logBase); ok {
+				parsed = append(parsed, entry)
+			}
+		}
+		for _, entry := range parsed {
+			if entry.seq >= base.secondarySeq {
+				entries[entry.seq] = entry
+			}
+		}
+	}
+
+	seqs := make([]uint32, 0, len(entries))
+	for seq := range entries {
+		seqs = append(seqs, seq)
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+
+	var data []byte
+	for i, seq := range seqs {
+		if i > 0 && seq != seqs[i-1]+1 {
+			break
+		}
+		if data == nil {
+			data = append([]byte(nil), h.data...)
+		}
+		entry := entries[seq]
+		if size := int(entry.dataSize); size > len(data) {
+			data = append(data, make([]byte, size-len(data))...)
+		} else if size > 0 {
+			data = data[:size]
+		}
+		for _, page := range entry.pages {
+			if end := int(page.offset) + len(page.data); end <= len(data) {
+				copy(data[page.offset:], page.data)
+				h.Recovered++
+			}
+		}
+	}
+	if data != nil {
+		h.data = data
+	}
+}
+
+// parseRegfLogEntries 解析新格式事务日志中的条目，遇到无效条目时停止
+func parseRegfLogEntries(b []byte) []regfLogEntry {
+	var entries []regfLogEntry
+	for off := 0; off+regfLogEntryHeader <= len(b) && bytes.Equal(b[off:off+4], regfLogMagic); {
+		size := int(binary.LittleEndian.Uint32(b[off+4:]))
+		if size < regfLogEntryHeader || size%regfSectorSize != 0 || off+size > len(b) {
+			break
+		}
+		e := b[off : off+size]
+		entry := regfLogEntry{
+			seq:      binary.LittleEndian.Uint32(e[12:]),
+			dataSize: binary.LittleEndian.Uint32(e[16:]),
+		}
+		count := int(binary.LittleEndian.Uint32(e[20:]))
+		pos := regfLogEntryHeader + count*8
+		if count < 0 || pos > size {
+			break
+		}
+		ok := true
+		for i := 0; i < count; i++ {
+			ref := e[regfLogEntryHeader+i*8:]
+			pageSize := int(binary.LittleEndian.Uint32(ref[4:]))
+			if pos+pageSize > size {
+				ok = false
+				break
+			}
+			entry.pages = append(entry.pages, regfDirtyPage{offset: binary.LittleEndian.Uint32(ref), data: e[pos : pos+pageSize]})
+			pos += pageSize
+		}
+		if !ok {
+			break
+		}
+		entries = append(entries, entry)
+		off += size
+	}
+	return entries
+}
+
+// parseRegfDirtyVector 解析旧格式事务日志：DIRT之后的位图中每一位对应hbin数据中的一个扇区，
+// 置位的扇区按顺序存放在位图之后
+func parseRegfDirtyVector(log []byte, base regfBaseBlock) (regfLogEntry, bool) {
+	b := log[regfLogBaseBlockSize:]
+	if !bytes.HasPrefix(b, regfDirtMagic) {
+		return regfLogEntry{}, false
+	}
+	sectors := int(base.dataSize) / regfSectorSize
+	bitmap := b[4:]
+	if len(bitmap) < (sectors+7)/8 {
+		return regfLogEntry{}, false
+	}
+	pos := regfLogBaseBlockSize + 4 + (sectors+7)/8
+	pos = (pos + regfSectorSize - 1) / regfSectorSize * regfSectorSize
+
+	entry := regfLogEntry{seq: base.primarySeq, dataSize: base.dataSize}
+	for i := 0; i < sectors; i++ {
+		if bitmap[i/8]&(1<<(i%8)) == 0 {
+			continue
+		}
+		if pos+regfSectorSize > len(log) {
+			break
+		}
+		entry.pages = append(entry.pages, regfDirtyPage{offset: uint32(i * regfSectorSize), data: log[pos : pos+regfSectorSize]})
+		pos += regfSectorSize
+	}
+	return entry, true
+}
+
+// cell 返回偏移处单元的内容（不含长度）
+func (h *regfHive) cell(offset uint32) ([]byte, error) {
+	off := int(offset)
+	if offset == 0xffffffff || off+4 > len(h.data) {
+		return nil, fmt.Errorf(tr("无效的单元偏移 %#x"), offset)
+	}
+	size := int(int32(binary.LittleEndian.Uint32(h.data[off:])))
+	if size < 0 {
+		size = -size
+	}
+	if size < 4 || off+size > len(h.data) {
+		return nil, fmt.Errorf(tr("无效的单元偏移 %#x"), offset)
+	}
+	return h.data[off+4 : off+size], nil
+}
+
+// regfKey 注册表键（nk单元）
+type regfKey struct {
+	hive        *regfHive
+	Name        string
+	LastWrite   time.Time
+	subkeyCount uint32
+	subkeyList  uint32
+	valueCount  uint32
+	valueList   uint32
+}
+
+// regfValue 注册表值（vk单元），Data为原始数据
+type regfValue struct {
+	Name string
+	Type uint32
+	Data []byte
+}
+
+// Root 返回hive的根键
+func (h *regfHive) Root() (*regfKey, error) {
+	return h.key(h.root)
+}
+
+func (h *regfHive) key(offset uint32) (*regfKey, error) {
+	b, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 76 || b[0] != 'n' || b[1] != 'k' {
+		return nil, fmt.Errorf(tr("偏移 %#x 处不是注册表键"), offset)
+	}
+	nameLen := int(binary.LittleEndian.Uint16(b[72:]))
+	if 76+nameLen > len(b) {
+		return nil, fmt.Errorf(tr("偏移 %#x 处不是注册表键"), offset)
+	}
+	return &regfKey{
+		hive:        h,
+		Name:        regfName(b[76:76+nameLen], binary.LittleEndian.Uint16(b[2:])&regfKeyCompName != 0),
+		LastWrite:   filetimeToTime(binary.LittleEndian.Uint64(b[4:])),
+		subkeyCount: binary.LittleEndian.Uint32(b[20:]),
+		subkeyList:  binary.LittleEndian.Uint32(b[28:]),
+		valueCount:  binary.LittleEndian.Uint32(b[36:]),
+		valueList:   binary.LittleEndian.Uint32(b[40:]),
+	}, nil
+}
+
+// regfName 解码键名或值名，压缩的名称为单字节的Latin-1字符，否则为UTF-16LE
+func regfName(b []byte, compressed bool) string {
+	if !compressed {
+		return utf16String(b)
+	}
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// Subkeys 返回所有子键
+func (k *regfKey) Subkeys() ([]*regfKey, error) {
+	if k.subkeyCount == 0 {
+		return nil, nil
+	}
+	var offsets []uint32
+	if err := k.hive.subkeyOffsets(k.subkeyList, &offsets, 0); err != nil {
+		return nil, err
+	}
+	keys := make([]*regfKey, 0, len(offsets))
+	for _, off := range offsets {
+		key, err := k.hive.key(off)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// subkeyOffsets 读取子键列表：lf、lh每项为偏移和4字节哈希，li每项为偏移，ri为子列表的偏移
+func (h *regfHive) subkeyOffsets(list uint32, offsets *[]uint32, depth int) error {
+	b, err := h.cell(list)
+	if err != nil {
+		return err
+	}
+	if len(b) < 4 || depth > regfMaxListDepth {
+		return fmt.Errorf(tr("偏移 %#x 处的子键列表损坏"), list)
+	}
+	sig, count := string(b[:2]), int(binary.LittleEndian.Uint16(b[2:]))
+	stride := 4
+	if sig == "lf" || sig == "lh" {
+		stride = 8
+	} else if sig != "li" && sig != "ri" {
+		return fmt.Errorf(tr("偏移 %#x 处的子键列表损坏"), list)
+	}
+	if 4+count*stride > len(b) {
+		return fmt.Errorf(tr("偏移 %#x 处的子键列表损坏"), list)
+	}
+	for i := 0; i < count; i++ {
+		off := binary.LittleEndian.Uint32(b[4+i*stride:])
+		if sig == "ri" {
+			if err := h.subkeyOffsets(off, offsets, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+		*offsets = append(*offsets, off)
+	}
+	return nil
+}
+
+// Subkey 返回名称匹配的子键，名称不区分大小写
+func (k *regfKey) Subkey(name string) (*regfKey, error) {
+	subkeys, err := k.Subkeys()
+	if err != nil {
+		return nil, err
+	}
+	for _, subkey := range subkeys {
+		if strings.EqualFold(subkey.Name, name) {
+			return subkey, nil
+		}
+	}
+	return nil, fmt.Errorf(tr("注册表项不存在: %s"), name)
+}
+
+// OpenKey 按"\"分隔的相对路径打开子键
+func (k *regfKey) OpenKey(path string) (*regfKey, error) {
+	key := k
+	for _, name := range strings.Split(path, "\\") {
+		if name == "" {
+			continue
+		}
+		subkey, err := key.Subkey(name)
+		if err != nil {
+			return nil, err
+		}
+		key = subkey
+	}
+	return key, nil
+}
+
+// Values 返回键的所有值
+func (k *regfKey) Values() ([]regfValue, error) {
+	if k.valueCount == 0 {
+		return nil, nil
+	}
+	b, err := k.hive.cell(k.valueList)
+	if err != nil {
+		return nil, err
+	}
+	if int(k.valueCount) > len(b)/4 {
+		return nil, fmt.Errorf(tr("偏移 %#x 处的值列表损坏"), k.valueList)
+	}
+	values := make([]regfValue, 0, k.valueCount)
+	for i := 0; i < int(k.valueCount); i++ {
+		value, err := k.hive.value(binary.LittleEndian.Uint32(b[i*4:]))
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// Value 返回名称匹配的值，名称不区分大小写，空字符串为默认值
+func (k *regfKey) Value(name string) (regfValue, error) {
+	values, err := k.Values()
+	if err != nil {
+		return regfValue{}, err
+	}
+	for _, value := range values {
+		if strings.EqualFold(value.Name, name) {
+			return value, nil
+		}
+	}
+	return regfValue{}, fmt.Errorf(tr("注册表值不存在: %s"), name)
+}
+
+// value 解析vk单元。不超过4字节的数据直接存放在数据偏移字段中，
+// 版本1.4以后超过16344字节的数据分段存放，由db单元列出各段
+func (h *regfHive) value(offset uint32) (regfValue, error) {
+	b, err := h.cell(offset)
+	if err != nil {
+		return regfValue{}, err
+	}
+	if len(b) < 20 || b[0] != 'v' || b[1] != 'k' {
+		return regfValue{}, fmt.Errorf(tr("偏移 %#x 处不是注册表值"), offset)
+	}
+	nameLen := int(binary.LittleEndian.Uint16(b[2:]))
+	size := binary.LittleEndian.Uint32(b[4:])
+	dataOffset := binary.LittleEndian.Uint32(b[8:])
+	if 20+nameLen > len(b) {
+		return regfValue{}, fmt.Errorf(tr("偏移 %#x 处不是注册表值"), offset)
+	}
+	value := regfValue{
+		Name: regfName(b[20:20+nameLen], binary.LittleEndian.Uint16(b[16:])&regfValueCompName != 0),
+		Type: binary.LittleEndian.Uint32(b[12:]),
+	}
+
+	switch {
+	case size&regfDataResident != 0:
+		size &^= regfDataResident
+		if size > 4 {
+			size = 4
+		}
+		value.Data = append([]byte(nil), b[8:8+size]...)
+	case size > regfBigDataSegment && h.minor >= 4:
+		value.Data, err = h.bigData(dataOffset, int(size))
+	case size > 0:
+		var data []byte
+		if data, err = h.cell(dataOffset); err == nil {
+			if int(size) > len(data) {
+				err = fmt.Errorf(tr("值 %s 的数据损坏"), value.Name)
+			} else {
+				value.Data = data[:size]
+			}
+		}
+	}
+	return value, err
+}
+
+// bigData 拼接db单元列出的数据段
+func (h *regfHive) bigData(offset uint32, size int) ([]byte, error) {
+	b, err := h.cell(offset)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) < 8 || b[0] != 'd' || b[1] != 'b' {
+		return nil, fmt.Errorf(tr("偏移 %#x 处的分段数据损坏"), offset)
+	}
+	count := int(binary.LittleEndian.Uint16(b[2:]))
+	list, err := h.cell(binary.LittleEndian.Uint32(b[4:]))
+	if err != nil {
+		return nil, err
+	}
+	if count*4 > len(list) {
+		return nil, fmt.Errorf(tr("偏移 %#x 处的分段数据损坏"), offset)
+	}
+	data := make([]byte, 0, size)
+	for i := 0; i < count && len(data) < size; i++ {
+		segment, err := h.cell(binary.LittleEndian.Uint32(list[i*4:]))
+		if err != nil {
+			return nil, err
+		}
+		if n := size - len(data); len(segment) > n {
+			segment = segment[:n]
+		} else if len(segment) > regfBigDataSegment {
+			segment = segment[:regfBigDataSegment]
+		}
+		data = append(data, segment...)
+	}
+	if len(data) < size {
+		return nil, fmt.Errorf(tr("偏移 %#x 处的分段数据损坏"), offset)
+	}
+	return data, nil
+}
+
+// registryValue 注册表中的一个值，Path为所在的键，LastWrite为键的最后写入时间
+type registryValue struct {
+	Path      string
+	Name      string
+	Type      string
+	Data      string
+	LastWrite time.Time
+}
+
+func (v registryValue) LastWriteText() string { return formatReportTime(v.LastWrite) }
+
+// String 按"名称 [类型] = 值"的格式输出，读取失败的值没有类型
+func (v registryValue) String() string {
+	if v.Type == "" {
+		return v.Name + ": " + v.Data
+	}
+	return fmt.Sprintf("%s [%s] = %s", v.Name, v.Type, v.Data)
+}
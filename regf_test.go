@@ -0,0 +1,325 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+// regfBuilder 按hive格式依次写入单元，偏移相对于第一个hbin
+type regfBuilder struct {
+	data []byte
+}
+
+func newRegfBuilder() *regfBuilder {
+	// 32字节的hbin头
+	b := &regfBuilder{data: make([]byte, 32)}
+	copy(b.data, "hbin")
+	return b
+}
+
+// cell 写入已分配的单元，长度按8字节对齐
+func (b *regfBuilder) cell(content []byte) uint32 {
+	off := uint32(len(b.data))
+	size := (4 + len(content) + 7) &^ 7
+	b.data = binary.LittleEndian.AppendUint32(b.data, uint32(-int32(size)))
+	b.data = append(b.data, content...)
+	b.data = append(b.data, make([]byte, size-4-len(content))...)
+	return off
+}
+
+// name 返回名称的编码和是否为压缩名称
+func regfTestName(name string) ([]byte, bool) {
+	for _, r := range name {
+		if r > 0xff {
+			var out []byte
+			for _, c := range utf16.Encode([]rune(name)) {
+				out = binary.LittleEndian.AppendUint16(out, c)
+			}
+			return out, false
+		}
+	}
+	out := make([]byte, 0, len(name))
+	for _, r := range name {
+		out = append(out, byte(r))
+	}
+	return out, true
+}
+
+func (b *regfBuilder) value(name string, typ uint32, data []byte) uint32 {
+	encoded, compressed := regfTestName(name)
+	vk := make([]byte, 20)
+	copy(vk, "vk")
+	binary.LittleEndian.PutUint16(vk[2:], uint16(len(encoded)))
+	binary.LittleEndian.PutUint32(vk[12:], typ)
+	if compressed {
+		binary.LittleEndian.PutUint16(vk[16:], regfValueCompName)
+	}
+	switch {
+	case len(data) <= 4:
+		binary.LittleEndian.PutUint32(vk[4:], uint32(len(data))|regfDataResident)
+		copy(vk[8:12], data)
+	case len(data) > regfBigDataSegment:
+		var segments []byte
+		count := 0
+		for rest := data; len(rest) > 0; count++ {
+			n := min(len(rest), regfBigDataSegment)
+			segments = binary.LittleEndian.AppendUint32(segments, b.cell(rest[:n]))
+			rest = rest[n:]
+		}
+		list := b.cell(segments)
+		db := []byte("db")
+		db = binary.LittleEndian.AppendUint16(db, uint16(count))
+		db = binary.LittleEndian.AppendUint32(db, list)
+		binary.LittleEndian.PutUint32(vk[4:], uint32(len(data)))
+		binary.LittleEndian.PutUint32(vk[8:], b.cell(db))
+	default:
+		binary.LittleEndian.PutUint32(vk[4:], uint32(len(data)))
+		binary.LittleEndian.PutUint32(vk[8:], b.cell(data))
+	}
+	return b.cell(append(vk, encoded...))
+}
+
+// list 写入子键列表，sig为lh、lf、li或ri
+func (b *regfBuilder) list(sig string, offsets ...uint32) uint32 {
+	content := []byte(sig)
+	content = binary.LittleEndian.AppendUint16(content, uint16(len(offsets)))
+	for _, off := range offsets {
+		content = binary.LittleEndian.AppendUint32(content, off)
+		if sig == "lh" || sig == "lf" {
+			content = binary.LittleEndian.AppendUint32(content, 0)
+		}
+	}
+	return b.cell(content)
+}
+
+// key 写入键，subkeys为子键列表的偏移，count为子键数
+func (b *regfBuilder) key(name string, written time.Time, subkeys uint32, count int, values ...uint32) uint32 {
+	encoded, compressed := regfTestName(name)
+	nk := make([]byte, 76)
+	copy(nk, "nk")
+	if compressed {
+		binary.LittleEndian.PutUint16(nk[2:], regfKeyCompName)
+	}
+	binary.LittleEndian.PutUint64(nk[4:], timeToFiletime(written))
+	binary.LittleEndian.PutUint32(nk[20:], uint32(count))
+	binary.LittleEndian.PutUint32(nk[28:], subkeys)
+	binary.LittleEndian.PutUint32(nk[36:], uint32(len(values)))
+	binary.LittleEndian.PutUint32(nk[40:], 0xffffffff)
+	if len(values) > 0 {
+		var list []byte
+		for _, v := range values {
+			list = binary.LittleEndian.AppendUint32(list, v)
+		}
+		binary.LittleEndian.PutUint32(nk[40:], b.cell(list))
+	}
+	binary.LittleEndian.PutUint16(nk[72:], uint16(len(encoded)))
+	return b.cell(append(nk, encoded...))
+}
+
+// leaf 写入没有子键的键
+func (b *regfBuilder) leaf(name string, written time.Time, values ...uint32) uint32 {
+	return b.key(name, written, 0xffffffff, 0, values...)
+}
+
+// hbins 返回补齐到4096字节的hbin数据
+func (b *regfBuilder) hbins() []byte {
+	data := append([]byte(nil), b.data...)
+	if n := len(data) % 4096; n != 0 {
+		data = append(data, make([]byte, 4096-n)...)
+	}
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(data)))
+	return data
+}
+
+// regfBaseBlockBytes 返回基本块，size为基本块的长度（主文件4096，事务日志512）
+func regfBaseBlockBytes(size int, fileType, seq1, seq2, root uint32, dataSize int, written time.Time) []byte {
+	block := make([]byte, size)
+	copy(block, regfMagic)
+	binary.LittleEndian.PutUint32(block[4:], seq1)
+	binary.LittleEndian.PutUint32(block[8:], seq2)
+	binary.LittleEndian.PutUint64(block[12:], timeToFiletime(written))
+	binary.LittleEndian.PutUint32(block[20:], 1)
+	binary.LittleEndian.PutUint32(block[24:], 5)
+	binary.LittleEndian.PutUint32(block[28:], fileType)
+	binary.LittleEndian.PutUint32(block[32:], 1)
+	binary.LittleEndian.PutUint32(block[36:], root)
+	binary.LittleEndian.PutUint32(block[40:], uint32(dataSize))
+	return block
+}
+
+func regfTestString(s string) []byte {
+	var out []byte
+	for _, c := range utf16.Encode([]rune(s + "\x00")) {
+		out = binary.LittleEndian.AppendUint16(out, c)
+	}
+	return out
+}
+
+func regfTestDword(v uint32) []byte {
+	return binary.LittleEndian.AppendUint32(nil, v)
+}
+
+// buildTestSystemHive 构造包含Select、ControlSet002\Services和多种值类型的SYSTEM hive，
+// imagePath为Evil服务的ImagePath
+func buildTestSystemHive(written time.Time, imagePath string) ([]byte, uint32) {
+	b := newRegfBuilder()
+	selectKey := b.leaf("Select", written, b.value("Current", regDword, regfTestDword(2)))
+
+	evil := b.leaf("Evil", written,
+		b.value("ImagePath", regExpandSZ, regfTestString(imagePath)),
+		b.value("Start", regDword, regfTestDword(2)),
+		b.value("Blob", regBinary, bytes.Repeat([]byte{0xab}, 20000)))
+	spooler := b.leaf("Spooler", written, b.value("", regSZ, regfTestString("default")))
+	unicode := b.leaf("服务", written)
+	// ri列表由两个li列表组成
+	services := b.key("Services", written, b.list("ri", b.list("li", evil), b.list("li", spooler, unicode)), 3)
+	controlSet := b.key("ControlSet002", written, b.list("lf", services), 1)
+	root := b.key("CMI-CreateHive{2A7FB991-7BBE-4F9D-B91E-7CB51D4737F5}", written, b.list("lh", selectKey, controlSet), 2)
+	return b.hbins(), root
+}
+
+func TestParseRegfHive(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	hbins, root := buildTestSystemHive(written, `%SystemRoot%\Temp\evil.exe`)
+	data := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 7, 7, root, len(hbins), written), hbins...)
+
+	hive, err := parseRegfHive(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hive.Dirty || !hive.LastWrite.Equal(written) {
+		t.Errorf("Dirty = %v, LastWrite = %v", hive.Dirty, hive.LastWrite)
+	}
+	top, err := hive.Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	services, err := top.OpenKey(`controlset002\SERVICES`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subkeys, err := services.Subkeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, k := range subkeys {
+		names = append(names, k.Name)
+	}
+	if got := strings.Join(names, ","); got != "Evil,Spooler,服务" {
+		t.Errorf("subkeys = %s", got)
+	}
+
+	evil, err := services.Subkey("evil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !evil.LastWrite.Equal(written) {
+		t.Errorf("LastWrite = %v, want %v", evil.LastWrite, written)
+	}
+	values, err := evil.Values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 3 {
+		t.Fatalf("got %d values, want 3", len(values))
+	}
+	if values[0].Name != "ImagePath" || values[0].Type != regExpandSZ ||
+		strings.TrimRight(utf16String(values[0].Data), "\x00") != `%SystemRoot%\Temp\evil.exe` {
+		t.Errorf("ImagePath = %+v", values[0])
+	}
+	if values[1].Type != regDword || binary.LittleEndian.Uint32(values[1].Data) != 2 {
+		t.Errorf("Start = %+v", values[1])
+	}
+	if !bytes.Equal(values[2].Data, bytes.Repeat([]byte{0xab}, 20000)) {
+		t.Errorf("Blob: got %d bytes", len(values[2].Data))
+	}
+
+	spooler, err := top.OpenKey(`ControlSet002\Services\Spooler`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := spooler.Value(""); err != nil || v.Type != regSZ {
+		t.Errorf("default value = %+v, %v", v, err)
+	}
+	if _, err := top.OpenKey(`ControlSet002\Services\Missing`); err == nil {
+		t.Error("missing key: expected error")
+	}
+}
+
+func TestRegfLogReplay(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	oldHbins, root := buildTestSystemHive(written, `C:\Windows\system32\old.exe`)
+	newHbins, _ := buildTestSystemHive(written, `C:\Users\Public\Mus\new.exe`)
+	if len(oldHbins) != len(newHbins) {
+		t.Fatal("test hives differ in size")
+	}
+	// 主文件的序号不一致，说明最后一次写入未完成
+	primary := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 5, 4, root, len(oldHbins), written), oldHbins...)
+
+	// 新格式：HvLE条目，序号3的条目早于主文件最后一次完整写入，不应重放
+	logEntry := func(seq uint32, page []byte) []byte {
+		e := []byte("HvLE")
+		size := (regfLogEntryHeader + 8 + len(page) + regfSectorSize - 1) / regfSectorSize * regfSectorSize
+		e = binary.LittleEndian.AppendUint32(e, uint32(size))
+		e = binary.LittleEndian.AppendUint32(e, 0)
+		e = binary.LittleEndian.AppendUint32(e, seq)
+		e = binary.LittleEndian.AppendUint32(e, uint32(len(newHbins)))
+		e = binary.LittleEndian.AppendUint32(e, 1)
+		e = append(e, make([]byte, 16)...)
+		e = binary.LittleEndian.AppendUint32(e, 0)
+		e = binary.LittleEndian.AppendUint32(e, uint32(len(page)))
+		e = append(e, page...)
+		return append(e, make([]byte, size-len(e))...)
+	}
+	newLog := regfBaseBlockBytes(regfLogBaseBlockSize, regfFileTypeLogNew, 5, 5, root, len(newHbins), written)
+	newLog = append(newLog, logEntry(3, make([]byte, len(newHbins)))...)
+	newLog = append(newLog, logEntry(4, newHbins)...)
+
+	// 旧格式：DIRT位图中标记所有扇区为脏，脏扇区从扇区边界开始
+	sectors := len(newHbins) / regfSectorSize
+	oldLog := regfBaseBlockBytes(regfLogBaseBlockSize, regfFileTypeLogOld, 5, 5, root, len(newHbins), written)
+	oldLog = append(oldLog, regfDirtMagic...)
+	oldLog = append(oldLog, bytes.Repeat([]byte{0xff}, sectors/8)...)
+	oldLog = append(oldLog, make([]byte, regfSectorSize-len(oldLog)%regfSectorSize)...)
+	oldLog = append(oldLog, newHbins...)
+
+	tests := []struct {
+		name      string
+		logs      [][]byte
+		want      string
+		recovered bool
+	}{
+		{"新格式日志", [][]byte{newLog}, `C:\Users\Public\Mus\new.exe`, true},
+		{"旧格式日志", [][]byte{oldLog}, `C:\Users\Public\Mus\new.exe`, true},
+		{"没有日志", nil, `C:\Windows\system32\old.exe`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hive, err := parseRegfHive(primary, tt.logs...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !hive.Dirty || (hive.Recovered > 0) != tt.recovered {
+				t.Errorf("Dirty = %v, Recovered = %d", hive.Dirty, hive.Recovered)
+			}
+			top, _ := hive.Root()
+			key, err := top.OpenKey(`ControlSet002\Services\Evil`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			v, err := key.Value("ImagePath")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.TrimRight(utf16String(v.Data), "\x00"); got != tt.want {
+				t.Errorf("ImagePath = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,481 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 根键名称
+const (
+	hkeyLocalMachine = "HKEY_LOCAL_MACHINE"
+	hkeyCurrentUser  = "HKEY_CURRENT_USER"
+)
+
+// registryHiveDir 离线注册表hive文件所在的目录，由 -hive-dir 指定，为空时读取本机注册表
+var registryHiveDir string
+
+// registryKey 注册表键的只读接口，由本机注册表或离线hive文件实现
+type registryKey interface {
+	// OpenKey 打开"\"分隔的相对路径下的子键
+	OpenKey(path string) (registryKey, error)
+	ReadSubKeyNames() ([]string, error)
+	ReadValueNames() ([]string, error)
+	// GetValue 返回值的类型和原始数据
+	GetValue(name string) (uint32, []byte, error)
+	LastWriteTime() (time.Time, error)
+	Close() error
+}
+
+// openRegistryKey 打开根键下的路径，指定了 -hive-dir 时从离线hive文件中读取
+func openRegistryKey(root, path string) (registryKey, error) {
+	if registryHiveDir == "" {
+		return openLiveRegistryKey(root, path)
+	}
+	return openHiveKey(root, path)
+}
+
+// getStringValue 读取REG_SZ或REG_EXPAND_SZ类型的值
+func getStringValue(key registryKey, name string) (string, error) {
+	typ, data, err := key.GetValue(name)
+	if err != nil {
+		return "", err
+	}
+	if typ != regSZ && typ != regExpandSZ {
+		return "", fmt.Errorf(tr("值 %s 不是字符串类型"), name)
+	}
+	return registryString(data), nil
+}
+
+// registryString 解码以UTF-16LE存储的字符串，去掉结尾的空字符
+func registryString(data []byte) string {
+	return strings.TrimRight(utf16String(data), "\x00")
+}
+
+// 离线hive文件与注册表路径的对应关系，按前缀从长到短排列
+var hiveFiles = []struct {
+	root   string
+	prefix string
+	file   string
+}{
+	{hkeyCurrentUser, `SOFTWARE\Classes`, "UsrClass.dat"},
+	{hkeyLocalMachine, "SOFTWARE", "SOFTWARE"},
+	{hkeyLocalMachine, "SYSTEM", "SYSTEM"},
+	{hkeyLocalMachine, "SAM", "SAM"},
+	{hkeyLocalMachine, "SECURITY", "SECURITY"},
+	{hkeyCurrentUser, "", "NTUSER.DAT"},
+}
+
+// 已加载的离线hive，以路径为键，每个文件在一次运行中只解析一次
+var hiveCache = struct {
+	sync.Mutex
+	hives map[string]*regfHive
+	errs  map[string]error
+}{hives: make(map[string]*regfHive), errs: make(map[string]error)}
+
+// loadHive 解析 registryHiveDir 中的hive文件并缓存结果
+func loadHive(file string) (*regfHive, error) {
+	path := filepath.Join(registryHiveDir, file)
+	hiveCache.Lock()
+	defer hiveCache.Unlock()
+	if hive, ok := hiveCache.hives[path]; ok {
+		return hive, hiveCache.errs[path]
+	}
+	hive, err := openRegfHive(path)
+	hiveCache.hives[path], hiveCache.errs[path] = hive, err
+	return hive, err
+}
+
+// openHiveKey 在对应的离线hive中打开路径。hive中没有CurrentControlSet，按Select\Current换成实际的ControlSet
+func openHiveKey(root, path string) (registryKey, error) {
+	path = strings.Trim(path, `\`)
+	for _, h := range hiveFiles {
+		rel, ok := cutPathPrefix(path, h.prefix)
+		if h.root != root || !ok {
+			continue
+		}
+		hive, err := loadHive(h.file)
+		if err != nil {
+			return nil, err
+		}
+		top, err := hive.Root()
+		if err != nil {
+			return nil, err
+		}
+		if h.file == "SYSTEM" {
+			rel = resolveControlSet(top, rel)
+		}
+		key, err := top.OpenKey(rel)
+		if err != nil {
+			return nil, err
+		}
+		return hiveKey{key}, nil
+	}
+	return nil, fmt.Errorf(tr("离线hive中没有对应的注册表项: %s\\%s"), root, path)
+}
+
+// cutPathPrefix 去掉路径开头不区分大小写的prefix，prefix必须是完整的路径段
+func cutPathPrefix(path, prefix string) (string, bool) {
+	if prefix == "" {
+		return path, true
+	}
+	if len(path) < len(prefix) || !strings.EqualFold(path[:len(prefix)], prefix) {
+		return "", false
+	}
+	rest := path[len(prefix):]
+	if rest != "" && rest[0] != '\\' {
+		return "", false
+	}
+	return strings.TrimPrefix(rest, `\`), true
+}
+
+func resolveControlSet(root *regfKey, path string) string {
+	rest, ok := cutPathPrefix(path, "CurrentControlSet")
+	if !ok {
+		return path
+	}
+	current := uint32(1)
+	if key, err := root.OpenKey("Select"); err == nil {
+		if value, err := key.Value("Current"); err == nil && len(value.Data) >= 4 {
+			current = uint32(leUint(value.Data[:4]))
+		}
+	}
+	return strings.TrimSuffix(fmt.Sprintf(`ControlSet%03d\%s`, current, rest), `\`)
+}
+
+// hiveKey 离线hive中的键
+type hiveKey struct {
+	key *regfKey
+}
+
+func (k hiveKey) OpenKey(path string) (registryKey, error) {
+	key, err := k.key.OpenKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return hiveKey{key}, nil
+}
+
+func (k hiveKey) ReadSubKeyNames() ([]string, error) {
+	subkeys, err := k.key.Subkeys()
+	names := make([]string, len(subkeys))
+	for i, subkey := range subkeys {
+		names[i] = subkey.Name
+	}
+	return names, err
+}
+
+func (k hiveKey) ReadValueNames() ([]string, error) {
+	values, err := k.key.Values()
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = value.Name
+	}
+	return names, err
+}
+
+func (k hiveKey) GetValue(name string) (uint32, []byte, error) {
+	value, err := k.key.Value(name)
+	return value.Type, value.Data, err
+}
+
+func (k hiveKey) LastWriteTime() (time.Time, error) { return k.key.LastWrite, nil }
+func (k hiveKey) Close() error                      { return nil }
+
+// reportOfflineHives 列出已加载的离线hive文件，注明最后写入时间及是否从事务日志恢复
+func reportOfflineHives(results *[]CheckResult, category string) {
+	hiveCache.Lock()
+	defer hiveCache.Unlock()
+	for _, h := range hiveFiles {
+		hive := hiveCache.hives[filepath.Join(registryHiveDir, h.file)]
+		if hive == nil {
+			continue
+		}
+		details := fmt.Sprintf(tr("最后写入时间: %s\n"), hive.LastWrite.Local().Format("2006-01-02 15:04:05"))
+		switch {
+		case hive.Dirty && hive.Recovered > 0:
+			details += fmt.Sprintf(tr("hive未完整写入，已从事务日志恢复 %d 个脏页\n"), hive.Recovered)
+		case hive.Dirty:
+			details += tr("hive未完整写入，且没有可用的事务日志 (.LOG1/.LOG2)，最近的修改可能缺失\n")
+		}
+		addCheckResult(results, category, fmt.Sprintf(tr("离线hive: %s"), h.file), SeverityInfo, StatusOK, details)
+	}
+}
+
+// 检查注册表项
+func checkRegistry(ctx context.Context, results *[]CheckResult) {
+	category := tr("注册表检查")
+
+	hives := []string{hkeyLocalMachine, hkeyCurrentUser}
+
+	for _, hive := range hives {
+		for _, path := range config.Registry.CriticalPaths {
+			if ctx.Err() != nil {
+				return
+			}
+			fullPath := hive + "\\" + path
+			key, err := openRegistryKey(hive, path)
+			if err != nil {
+				// HKEY_CURRENT_USER下大多数路径不存在，仅记录HKLM的失败
+				if hive == hkeyLocalMachine {
+					addErrorResult(results, category, fmt.Sprintf(tr("无法打开注册表项 %s"), fullPath), err)
+				}
+				continue
+			}
+			walkRegistryKey(ctx, results, category, fullPath, key, config.Registry.depth(path))
+			key.Close()
+		}
+	}
+
+	if registryHiveDir != "" {
+		reportOfflineHives(results, category)
+	}
+}
+
+// walkRegistryKey 为一个检查路径输出一条结果，列出键中的所有值，并向下读取共depth层子键作为证据。
+// 路径及各层子键中的每个值随结果返回，在报告中逐个列出。
+// 子键中ImagePath或ServiceDll不在System32中的另行报告，位于用户可写目录时为警告
+func walkRegistryKey(ctx context.Context, results *[]CheckResult, category, fullPath string, key registryKey, depth int) {
+	var b strings.Builder
+	lastWrite, err := key.LastWriteTime()
+	if err == nil {
+		fmt.Fprintf(&b, tr("最后写入时间: %s\n"), formatReportTime(lastWrite))
+	}
+	var evidence []string
+	values, err := readRegistryValues(key, fullPath, lastWrite)
+	if err != nil {
+		fmt.Fprintf(&b, tr("无法读取值: %v\n"), err)
+	} else {
+		fmt.Fprintf(&b, tr("值: %d 个\n"), len(values))
+		for _, v := range values {
+			evidence = append(evidence, v.String())
+		}
+	}
+	if depth <= 1 {
+		addAttachedResult(results, category, fullPath, SeverityInfo, StatusOK, b.String(), values, evidence...)
+		return
+	}
+
+	var subkeys []registrySubkey
+	if err := readRegistrySubkeys(ctx, key, "", depth-1, &subkeys); err != nil {
+		fmt.Fprintf(&b, tr("无法读取子键: %v\n"), err)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+	fmt.Fprintf(&b, tr("子键: %d 个\n"), len(subkeys))
+	var lines, images []string
+	var writable []registrySubkey
+	for _, s := range subkeys {
+		lines = append(lines, s.String())
+		for _, v := range s.values {
+			v.Path = fullPath + `\` + v.Path
+			values = append(values, v)
+		}
+		switch {
+		case len(s.images) == 0:
+		case isUserWritablePath(strings.Join(s.images, " ")):
+			writable = append(writable, s)
+		default:
+			images = append(images, s.path+": "+strings.Join(s.images, "; "))
+		}
+	}
+	addAttachedResult(results, category, fullPath, SeverityInfo, StatusOK, b.String(), values,
+		append(evidence, elideLines(lines, maxListItems)...)...)
+
+	for _, s := range writable {
+		addCheckResult(results, category, fmt.Sprintf(tr("映像路径位于用户可写目录: %s"), fullPath+`\`+s.path),
+			SeverityWarning, StatusAbnormal, fmt.Sprintf(tr("最后写入时间: %s\n"), formatReportTime(s.lastWrite)), s.images...)
+	}
+	if len(images) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("映像路径不在System32中的子键: %s (%d 个)"), fullPath, len(images)),
+			SeverityInfo, StatusAbnormal, tr("第三方软件的服务通常位于Program Files中\n"), elideLines(images, maxListItems)...)
+	}
+}
+
+// registryImageValues 指向服务或驱动映像的值
+var registryImageValues = []string{"ImagePath", "ServiceDll"}
+
+// registrySubkey 检查路径下的一个子键
+type registrySubkey struct {
+	path      string // 相对于检查路径
+	lastWrite time.Time
+	values    []registryValue
+	err       error
+	// images 不在System32中的映像路径，按"名称 = 值"列出
+	images []string
+}
+
+func (s registrySubkey) String() string {
+	if s.err != nil {
+		return fmt.Sprintf(tr("%s: 读取失败 (%v)"), s.path, s.err)
+	}
+	return fmt.Sprintf(tr("%s (最后写入时间: %s, 值: %d 个)"), s.path, formatReportTime(s.lastWrite), len(s.values))
+}
+
+// readRegistrySubkeys 按名称顺序读取共depth层子键及其中的值，值的路径相对于检查路径，
+// 返回列出key的子键时的错误。子键用完立即关闭
+func readRegistrySubkeys(ctx context.Context, key registryKey, prefix string, depth int, subkeys *[]registrySubkey) error {
+	names, err := key.ReadSubKeyNames()
+	if err != nil {
+		return err
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return nil
+		}
+		path := name
+		if prefix != "" {
+			path = prefix + `\` + name
+		}
+		subkey, err := key.OpenKey(name)
+		if err != nil {
+			*subkeys = append(*subkeys, registrySubkey{path: path, err: err})
+			continue
+		}
+		s := registrySubkey{path: path}
+		s.lastWrite, _ = subkey.LastWriteTime()
+		s.values, s.err = readRegistryValues(subkey, path, s.lastWrite)
+		for _, name := range registryImageValues {
+			if image, err := getStringValue(subkey, name); err == nil && !isSystem32Path(image) {
+				s.images = append(s.images, name+" = "+image)
+			}
+		}
+		*subkeys = append(*subkeys, s)
+		if depth > 1 {
+			// 列出子键失败时没有追加任何下层子键，s仍是最后一项
+			if err := readRegistrySubkeys(ctx, subkey, path, depth-1, subkeys); err != nil && s.err == nil {
+				(*subkeys)[len(*subkeys)-1].err = err
+			}
+		}
+		subkey.Close()
+	}
+	return nil
+}
+
+// readRegistryValues 读取键中的所有值，按类型转成可读的文本，默认值的名称为(默认)
+func readRegistryValues(key registryKey, path string, lastWrite time.Time) ([]registryValue, error) {
+	names, err := key.ReadValueNames()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]registryValue, 0, len(names))
+	for _, name := range names {
+		v := registryValue{Path: path, Name: name, LastWrite: lastWrite}
+		if name == "" {
+			v.Name = tr("(默认)")
+		}
+		if typ, data, err := key.GetValue(name); err != nil {
+			v.Data = fmt.Sprintf(tr("读取失败 (%v)"), err)
+		} else {
+			v.Type, v.Data = registryTypeName(typ), formatRegistryValue(typ, data)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// isSystem32Path 映像路径位于System32下，包括\SystemRoot\System32和system32\drivers这样的相对形式
+func isSystem32Path(path string) bool {
+	p := strings.ToLower(strings.Trim(strings.TrimSpace(path), `"`))
+	return strings.HasPrefix(p, `system32\`) || strings.Contains(p, `\system32\`)
+}
+
+// 注册表值类型名称
+var registryTypeNames = map[uint32]string{
+	regNone:                     "REG_NONE",
+	regSZ:                       "REG_SZ",
+	regExpandSZ:                 "REG_EXPAND_SZ",
+	regBinary:                   "REG_BINARY",
+	regDword:                    "REG_DWORD",
+	regDwordBigEndian:           "REG_DWORD_BIG_ENDIAN",
+	regLink:                     "REG_LINK",
+	regMultiSZ:                  "REG_MULTI_SZ",
+	regResourceList:             "REG_RESOURCE_LIST",
+	regFullResourceDescriptor:   "REG_FULL_RESOURCE_DESCRIPTOR",
+	regResourceRequirementsList: "REG_RESOURCE_REQUIREMENTS_LIST",
+	regQword:                    "REG_QWORD",
+}
+
+func registryTypeName(typ uint32) string {
+	if name, ok := registryTypeNames[typ]; ok {
+		return name
+	}
+	return fmt.Sprintf("0x%x", typ)
+}
+
+// registryBinaryLimit 二进制值最多显示的字节数
+const registryBinaryLimit = 64
+
+// formatRegistryValue 按类型把值转成可读的文本。长度与类型不符的数值按二进制显示
+func formatRegistryValue(typ uint32, data []byte) string {
+	switch {
+	case typ == regSZ || typ == regExpandSZ || typ == regLink:
+		return registryString(data)
+	case typ == regMultiSZ:
+		var parts []string
+		for _, part := range strings.Split(registryString(data), "\x00") {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case typ == regDword && len(data) == 4:
+		n := binary.LittleEndian.Uint32(data)
+		return fmt.Sprintf("%d (0x%08x)", n, n)
+	case typ == regDwordBigEndian && len(data) == 4:
+		n := binary.BigEndian.Uint32(data)
+		return fmt.Sprintf("%d (0x%08x)", n, n)
+	case typ == regQword && len(data) == 8:
+		n := binary.LittleEndian.Uint64(data)
+		return fmt.Sprintf("%d (0x%016x)", n, n)
+	}
+	if len(data) <= registryBinaryLimit {
+		return hex.EncodeToString(data)
+	}
+	return hex.EncodeToString(data[:registryBinaryLimit]) + fmt.Sprintf(tr("... (共 %d 字节)"), len(data))
+}
+
+// getRegistryAutoRuns 列出Run键中的自启动项
+func getRegistryAutoRuns(ctx context.Context, results *[]CheckResult) {
+	category := tr("自启动项检查")
+	runKeys := []struct {
+		description string
+		root        string
+	}{
+		{tr("系统自启动项"), hkeyLocalMachine},
+		{tr("用户自启动项"), hkeyCurrentUser},
+	}
+
+	const runPath = `SOFTWARE\Microsoft\Windows\CurrentVersion\Run`
+	for _, runKey := range runKeys {
+		fullPath := runKey.root + "\\" + runPath
+		key, err := openRegistryKey(runKey.root, runPath)
+		if err == nil {
+			var names []string
+			if names, err = key.ReadValueNames(); err == nil {
+				var values []string
+				for _, name := range names {
+					if val, err := getStringValue(key, name); err == nil {
+						values = append(values, fmt.Sprintf("%s = %s", name, val))
+					}
+				}
+				addCheckResult(results, category, runKey.description, SeverityInfo, StatusOK, fullPath, values...)
+			}
+			key.Close()
+		}
+		if err != nil {
+			addErrorResult(results, category, fmt.Sprintf(tr("%s读取失败"), runKey.description), err)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useTestHiveDir 把测试用的SYSTEM hive写入临时目录并设为 registryHiveDir，测试结束后恢复
+func useTestHiveDir(t *testing.T, written time.Time) {
+	t.Helper()
+	hbins, root := buildTestSystemHive(written, `C:\ProgramData\evil.exe`)
+	dir := t.TempDir()
+	// 从Linux上挂载的分区中复制出的文件名可能是小写
+	data := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 1, 1, root, len(hbins), written), hbins...)
+	if err := os.WriteFile(filepath.Join(dir, "system"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := registryHiveDir
+	registryHiveDir = dir
+	t.Cleanup(func() { registryHiveDir = saved })
+}
+
+func TestOpenHiveKey(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	useTestHiveDir(t, written)
+
+	// CurrentControlSet按Select\Current对应到ControlSet002
+	key, err := openRegistryKey(hkeyLocalMachine, `SYSTEM\CurrentControlSet\Services\Evil`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := getStringValue(key, "ImagePath"); err != nil || got != `C:\ProgramData\evil.exe` {
+		t.Errorf("ImagePath = %q, %v", got, err)
+	}
+	if _, err := getStringValue(key, "Start"); err == nil {
+		t.Error("Start is a DWORD: expected error")
+	}
+	if modTime, _ := key.LastWriteTime(); !modTime.Equal(written) {
+		t.Errorf("LastWriteTime = %v", modTime)
+	}
+
+	if _, err := openRegistryKey(hkeyLocalMachine, `SOFTWARE\Microsoft`); err == nil {
+		t.Error("missing SOFTWARE hive: expected error")
+	}
+	if _, err := openRegistryKey(hkeyLocalMachine, `HARDWARE\DESCRIPTION`); err == nil {
+		t.Error("HARDWARE has no hive file: expected error")
+	}
+}
+
+func TestFormatRegistryValue(t *testing.T) {
+	tests := []struct {
+		name string
+		typ  uint32
+		data []byte
+		want string
+	}{
+		{"REG_SZ", regSZ, regfTestString("abc"), "abc"},
+		{"REG_EXPAND_SZ不展开", regExpandSZ, regfTestString(`%SystemRoot%\a.exe`), `%SystemRoot%\a.exe`},
+		{"REG_MULTI_SZ", regMultiSZ, regfTestString("a\x00b\x00"), "[a, b]"},
+		{"REG_DWORD", regDword, regfTestDword(16), "16 (0x00000010)"},
+		{"REG_DWORD_BIG_ENDIAN", regDwordBigEndian, []byte{0, 0, 1, 0}, "256 (0x00000100)"},
+		{"REG_QWORD", regQword, []byte{1, 0, 0, 0, 1, 0, 0, 0}, "4294967297 (0x0000000100000001)"},
+		{"长度不符的DWORD", regDword, []byte{1, 2}, "0102"},
+		{"REG_BINARY", regBinary, []byte{0xde, 0xad}, "dead"},
+		{"REG_NONE", regNone, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatRegistryValue(tt.typ, tt.data); got != tt.want {
+				t.Errorf("formatRegistryValue = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	long := formatRegistryValue(regBinary, make([]byte, 100))
+	if !strings.HasPrefix(long, strings.Repeat("00", registryBinaryLimit)+"...") || !strings.Contains(long, "100") {
+		t.Errorf("long binary = %q", long)
+	}
+	if got := registryTypeName(0x20); got != "0x20" {
+		t.Errorf("registryTypeName(0x20) = %q", got)
+	}
+}
+
+func TestCheckRegistryDepth(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	useTestHiveDir(t, written)
+	saved := config.Registry
+	defer func() { config.Registry = saved }()
+
+	const services = hkeyLocalMachine + `\SYSTEM\CurrentControlSet\Services`
+	config.Registry.CriticalPaths = []string{`SYSTEM\CurrentControlSet\Services`}
+	evilResult := "映像路径位于用户可写目录: " + services + `\Evil`
+	for _, tt := range []struct {
+		depth      int
+		pathDepths RegistryDepths
+		want       []string
+	}{
+		{1, nil, []string{services}},
+		{2, nil, []string{services, evilResult}},
+		{2, RegistryDepths{`system\currentcontrolset\services`: 1}, []string{services}},
+	} {
+		config.Registry.MaxDepth, config.Registry.PathDepths = tt.depth, tt.pathDepths
+		var results []CheckResult
+		checkRegistry(context.Background(), &results)
+
+		var keys []string
+		byKey := make(map[string]CheckResult)
+		for _, r := range results {
+			if r.Category == "注册表检查" && !strings.HasPrefix(r.Description, "离线hive") {
+				keys = append(keys, r.Description)
+				byKey[r.Description] = r
+			}
+		}
+		if !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("depth %d %v: results = %q, want %q", tt.depth, tt.pathDepths, keys, tt.want)
+			continue
+		}
+		if len(keys) < 2 {
+			continue
+		}
+
+		// 每个检查路径只有一条结果，子键作为证据列出
+		summary := byKey[services]
+		if !strings.Contains(summary.Details, "子键: 3 个") {
+			t.Errorf("summary details = %q", summary.Details)
+		}
+		stamp := written.Local().Format("2006-01-02 15:04:05")
+		wantEvidence := []string{
+			"Evil (最后写入时间: " + stamp + ", 值: 3 个)",
+			"Spooler (最后写入时间: " + stamp + ", 值: 1 个)",
+			"服务 (最后写入时间: " + stamp + ", 值: 0 个)",
+		}
+		if !reflect.DeepEqual(summary.Evidence, wantEvidence) {
+			t.Errorf("summary evidence = %q", summary.Evidence)
+		}
+
+		// 层数内每个子键中的每个值都随结果返回，路径为值所在的键
+		values, _ := summary.Attachment.([]registryValue)
+		var got []string
+		for _, v := range values {
+			if !v.LastWrite.Equal(written) {
+				t.Errorf("%s\\%s LastWrite = %v", v.Path, v.Name, v.LastWrite)
+			}
+			got = append(got, v.Path+" | "+v.Name+" | "+v.Type)
+		}
+		wantValues := []string{
+			services + `\Evil | ImagePath | REG_EXPAND_SZ`,
+			services + `\Evil | Start | REG_DWORD`,
+			services + `\Evil | Blob | REG_BINARY`,
+			services + `\Spooler | (默认) | REG_SZ`,
+		}
+		if !reflect.DeepEqual(got, wantValues) {
+			t.Errorf("values = %q, want %q", got, wantValues)
+		}
+
+		evil := byKey[evilResult]
+		if evil.Severity != SeverityWarning || evil.Status != StatusAbnormal || !strings.Contains(evil.Details, stamp) ||
+			!reflect.DeepEqual(evil.Evidence, []string{`ImagePath = C:\ProgramData\evil.exe`}) {
+			t.Errorf("Evil = %+v", evil)
+		}
+	}
+}
+
+func TestCheckRegistryValues(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	useTestHiveDir(t, written)
+	saved := config.Registry
+	defer func() { config.Registry = saved }()
+
+	config.Registry.CriticalPaths = []string{`SYSTEM\CurrentControlSet\Services\Evil`}
+	config.Registry.MaxDepth = 1
+	var results []CheckResult
+	checkRegistry(context.Background(), &results)
+	if len(results) == 0 {
+		t.Fatal("no results")
+	}
+	evil := results[0]
+	if !strings.Contains(evil.Details, written.Local().Format("2006-01-02 15:04:05")) {
+		t.Errorf("Evil details = %q", evil.Details)
+	}
+	if len(evil.Evidence) != 3 ||
+		evil.Evidence[0] != `ImagePath [REG_EXPAND_SZ] = C:\ProgramData\evil.exe` ||
+		evil.Evidence[1] != "Start [REG_DWORD] = 2 (0x00000002)" ||
+		!strings.HasPrefix(evil.Evidence[2], "Blob [REG_BINARY] = abab") {
+		t.Errorf("Evil evidence = %q", evil.Evidence)
+	}
+	values, _ := evil.Attachment.([]registryValue)
+	want := registryValue{
+		Path:      hkeyLocalMachine + `\SYSTEM\CurrentControlSet\Services\Evil`,
+		Name:      "Start",
+		Type:      "REG_DWORD",
+		Data:      "2 (0x00000002)",
+		LastWrite: written,
+	}
+	if len(values) != 3 || values[1] != want {
+		t.Errorf("Evil values = %+v", values)
+	}
+}
+
+func TestIsSystem32Path(t *testing.T) {
+	for path, want := range map[string]bool{
+		`%SystemRoot%\System32\svchost.exe -k netsvcs`: true,
+		`\SystemRoot\System32\drivers\acpi.sys`:        true,
+		`system32\DRIVERS\disk.sys`:                    true,
+		`"C:\Windows\system32\spoolsv.exe"`:            true,
+		`"C:\Program Files\Vendor\agent.exe"`:          false,
+		`C:\ProgramData\evil.exe`:                      false,
+		`C:\Windows\System32Evil\a.exe`:                false,
+	} {
+		if got := isSystem32Path(path); got != want {
+			t.Errorf("isSystem32Path(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestReadMountedDevices(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	b := newRegfBuilder()
+	system := []byte("DMIO:ID:\x01\x02\x03\x04\x05\x06\x07\x08\x09\x0a\x0b\x0c\x0d\x0e\x0f\x10")
+	data := []byte{0x78, 0x56, 0x34, 0x12, 0, 0x10, 0, 0, 0, 0, 0, 0}
+	mounted := b.leaf("MountedDevices", written,
+		b.value(`\DosDevices\C:`, regBinary, system),
+		b.value(`\??\Volume{6f1e2d3c-0000-0000-0000-100000000000}`, regBinary, system),
+		b.value(`\DosDevices\D:`, regBinary, data),
+		b.value(`\??\Volume{7a7a7a7a-0000-0000-0000-100000000000}`, regBinary, data),
+		// 没有盘符的卷
+		b.value(`\??\Volume{8b8b8b8b-0000-0000-0000-100000000000}`, regBinary, []byte{1, 2, 3}))
+	root := b.key("ROOT", written, b.list("lf", mounted), 1)
+	hbins := b.hbins()
+	dir := t.TempDir()
+	image := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 1, 1, root, len(hbins), written), hbins...)
+	if err := os.WriteFile(filepath.Join(dir, "SYSTEM"), image, 0644); err != nil {
+		t.Fatal(err)
+	}
+	saved := registryHiveDir
+	registryHiveDir = dir
+	defer func() { registryHiveDir = saved }()
+
+	volumes := newVolumeMap()
+	readMountedDevices(volumes)
+	want := map[string]string{
+		"{6f1e2d3c-0000-0000-0000-100000000000}": "C:",
+		"{7a7a7a7a-0000-0000-0000-100000000000}": "D:",
+	}
+	if !reflect.DeepEqual(volumes.guids, want) {
+		t.Errorf("guids = %v, want %v", volumes.guids, want)
+	}
+	if got := volumes.resolvePath(`\\?\Volume{7A7A7A7A-0000-0000-0000-100000000000}\Tools\a.exe`); got != `D:\Tools\a.exe` {
+		t.Errorf("resolvePath = %q", got)
+	}
+}
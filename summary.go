@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 进程退出码，供自动化流程判断是否需要升级处置
+const (
+	ExitClean      = 0 // 未发现问题
+	ExitError      = 1 // 工具自身错误，如参数或配置无效、报告写入失败
+	ExitWarning    = 2 // 发现警告
+	ExitCritical   = 3 // 发现严重问题
+	ExitIncomplete = 4 // 未发现严重或警告级别的问题，但部分检查因中断、超时或采集失败未完成
+)
+
+// ExitCode 根据检查结果中最高的严重程度返回退出码。发现的问题优先于运行是否完整，
+// 部分检查未完成时由摘要中的 incomplete、failed 反映
+func (r *Report) ExitCode() int {
+	switch {
+	case r.CriticalCount > 0:
+		return ExitCritical
+	case r.WarningCount > 0:
+		return ExitWarning
+	case r.IncompleteCount > 0 || r.FailedCount > 0:
+		return ExitIncomplete
+	}
+	return ExitClean
+}
+
+// Summary 运行结果摘要，以JSON格式输出供SOAR等自动化流程使用
+type Summary struct {
+	Title           string        `json:"title"`
+	Hostname        string        `json:"hostname"`
+	Timestamp       string        `json:"timestamp"`
+	ExitCode        int           `json:"exit_code"`
+	Checks          int           `json:"checks"`
+	TotalIssues     int           `json:"total_issues"`
+	CriticalCount   int           `json:"critical"`
+	WarningCount    int           `json:"warning"`
+	InfoCount       int           `json:"info"`
+	IncompleteCount int           `json:"incomplete"`
+	FailedCount     int           `json:"failed"`
+	SkippedCount    int           `json:"skipped"`
+	ReportPath      string        `json:"report,omitempty"`
+	Findings        []CheckResult `json:"findings"`
+}
+
+// summaryStatuses 摘要中使用的状态值。报告中的状态是显示用的中文，自动化流程需要稳定的ASCII值
+var summaryStatuses = map[string]string{
+	StatusOK:         "ok",
+	StatusAbnormal:   "abnormal",
+	StatusFailed:     "failed",
+	StatusIncomplete: "incomplete",
+	StatusSkipped:    "skipped",
+}
+
+// newSummary 根据报告生成摘要，Findings只包含严重和警告级别的结果
+func newSummary(report Report, hostname string, checks int, exitCode int, reportPath string) Summary {
+	summary := Summary{
+		Title:           report.Title,
+		Hostname:        hostname,
+		Timestamp:       report.Timestamp,
+		ExitCode:        exitCode,
+		Checks:          checks,
+		TotalIssues:     report.TotalIssues,
+		CriticalCount:   report.CriticalCount,
+		WarningCount:    report.WarningCount,
+		InfoCount:       report.InfoCount,
+		IncompleteCount: report.IncompleteCount,
+		FailedCount:     report.FailedCount,
+		SkippedCount:    report.SkippedCount,
+		ReportPath:      reportPath,
+		Findings:        []CheckResult{},
+	}
+	for _, result := range report.CheckResults {
+		if result.Severity == SeverityCritical || result.Severity == SeverityWarning {
+			if status, ok := summaryStatuses[result.Status]; ok {
+				result.Status = status
+			}
+			summary.Findings = append(summary.Findings, result)
+		}
+	}
+	return summary
+}
+
+// writeSummary 将摘要以JSON格式写入文件，path为"-"时写入标准输出
+func writeSummary(path string, summary Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf(tr("生成运行摘要失败: %v"), err)
+	}
+	data = append(data, '\n')
+
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		return fmt.Errorf(tr("写入运行摘要失败: %v"), err)
+	}
+	return nil
+}
+
+// printSummary 在控制台输出运行结果统计
+func printSummary(w io.Writer, report Report, exitCode int) {
+	fmt.Fprintf(w, tr("\n检查完成: 严重 %d, 警告 %d, 信息 %d, 未完成 %d, 采集失败 %d, 已跳过 %d (退出码 %d)\n"),
+		report.CriticalCount, report.WarningCount, report.InfoCount, report.IncompleteCount, report.FailedCount,
+		report.SkippedCount, exitCode)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReportExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		report Report
+		want   int
+	}{
+		{"clean", Report{InfoCount: 3, SkippedCount: 1}, ExitClean},
+		{"warning", Report{WarningCount: 1}, ExitWarning},
+		{"warning with failed collection", Report{WarningCount: 2, FailedCount: 3}, ExitWarning},
+		{"failed collection", Report{InfoCount: 1, FailedCount: 1}, ExitIncomplete},
+		{"incomplete", Report{IncompleteCount: 1}, ExitIncomplete},
+		{"skipped is not incomplete", Report{SkippedCount: 4}, ExitClean},
+		{"critical", Report{CriticalCount: 1, IncompleteCount: 1, FailedCount: 1}, ExitCritical},
+	} {
+		if got := tt.report.ExitCode(); got != tt.want {
+			t.Errorf("%s: exit code = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSummaryStatuses(t *testing.T) {
+	var results []CheckResult
+	addCheckResult(&results, "c", "abnormal", SeverityCritical, StatusAbnormal, "")
+	addCheckResult(&results, "c", "ok", SeverityWarning, StatusOK, "")
+	addCheckResult(&results, "c", "info", SeverityInfo, StatusFailed, "")
+	report := Report{CheckResults: results}
+
+	summary := newSummary(report, "host", 1, report.ExitCode(), "")
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); !strings.Contains(s, `"status":"abnormal"`) || !strings.Contains(s, `"status":"ok"`) || len(summary.Findings) != 2 {
+		t.Errorf("summary = %s", s)
+	}
+	// 报告中的结果不受影响
+	if report.CheckResults[0].Status != StatusAbnormal {
+		t.Errorf("report status changed to %q", report.CheckResults[0].Status)
+	}
+}
+
+func TestNewReportAttachments(t *testing.T) {
+	executions := []executionRecord{{Paths: []string{`C:\Tools\a.exe`}, RunCount: 1}}
+	timeline := []fileTimelineEntry{{Path: `\Tools\a.exe`}}
+	var results []CheckResult
+	addCheckResult(&results, "c", "plain", SeverityInfo, StatusOK, "")
+	addAttachedResult(&results, "c", "history", SeverityInfo, StatusOK, "", executions)
+	addAttachedResult(&results, "c", "timeline", SeverityInfo, StatusOK, "", timeline)
+	activity := []usnRecord{{Name: "a.exe", Reason: usnReasonFileCreate | usnReasonClose}}
+	addAttachedResult(&results, "c", "activity", SeverityInfo, StatusOK, "", activity)
+	// 注册表检查的每个路径各有一条结果，值依次合并
+	hklm := []registryValue{{Path: `HKEY_LOCAL_MACHINE\Run`, Name: "a"}}
+	hkcu := []registryValue{{Path: `HKEY_CURRENT_USER\Run`, Name: "b"}}
+	addAttachedResult(&results, "c", "hklm", SeverityInfo, StatusOK, "", hklm, "a [REG_SZ] = x")
+	addAttachedResult(&results, "c", "hkcu", SeverityInfo, StatusOK, "", hkcu)
+
+	report := newReport(results, "")
+	if !reflect.DeepEqual(report.Executions, executions) {
+		t.Errorf("executions = %+v", report.Executions)
+	}
+	if !reflect.DeepEqual(report.FileTimeline, timeline) {
+		t.Errorf("timeline = %+v", report.FileTimeline)
+	}
+	if !reflect.DeepEqual(report.FileActivity, activity) {
+		t.Errorf("activity = %+v", report.FileActivity)
+	}
+	if want := append(hklm, hkcu...); !reflect.DeepEqual(report.RegistryValues, want) {
+		t.Errorf("registry values = %+v", report.RegistryValues)
+	}
+	if !reflect.DeepEqual(results[4].Evidence, []string{"a [REG_SZ] = x"}) {
+		t.Errorf("evidence = %q", results[4].Evidence)
+	}
+	if data, err := json.Marshal(results[1]); err != nil || strings.Contains(string(data), "a.exe") {
+		t.Errorf("attachment leaked into JSON: %s (%v)", data, err)
+	}
+}
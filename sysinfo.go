@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/disk"
+	"github.com/shirou/gopsutil/v3/host"
+	"github.com/shirou/gopsutil/v3/mem"
+	"github.com/shirou/gopsutil/v3/net"
+	"github.com/shirou/gopsutil/v3/process"
+)
+
+func init() {
+	registerCommonCheck("ir.sysinfo", "ir", PrivilegeNone, getSystemInfo)
+	registerCommonCheck("ir.cpu", "ir", PrivilegeNone, getCPUInfo)
+	registerCommonCheck("ir.memory", "ir", PrivilegeNone, getMemoryInfo)
+	registerCommonCheck("ir.disk", "ir", PrivilegeNone, getDiskInfo)
+	registerCommonCheck("ir.network", "ir", PrivilegeNone, getNetworkInfo)
+	registerCommonCheck("ir.process", "ir", PrivilegeNone, getProcessInfo)
+}
+
+func getSystemInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("系统信息")
+	hostInfo, err := host.Info()
+	if err != nil {
+		addErrorResult(results, category, tr("获取系统信息失败"), err)
+		return
+	}
+
+	var details strings.Builder
+	fmt.Fprintf(&details, tr("主机名: %s\n"), hostInfo.Hostname)
+	fmt.Fprintf(&details, tr("操作系统: %s\n"), hostInfo.OS)
+	fmt.Fprintf(&details, tr("平台: %s\n"), hostInfo.Platform)
+	fmt.Fprintf(&details, tr("平台版本: %s\n"), hostInfo.PlatformVersion)
+	fmt.Fprintf(&details, tr("内核版本: %s\n"), hostInfo.KernelVersion)
+	fmt.Fprintf(&details, tr("启动时间: %s\n"), time.Unix(int64(hostInfo.BootTime), 0))
+	addCheckResult(results, category, tr("主机基本信息"), SeverityInfo, StatusOK, details.String())
+}
+
+func getCPUInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("CPU信息")
+	cpuInfo, err := cpu.Info()
+	if err != nil {
+		addErrorResult(results, category, tr("获取CPU信息失败"), err)
+	} else {
+		var details strings.Builder
+		for _, info := range cpuInfo {
+			fmt.Fprintf(&details, tr("CPU型号: %s\n"), info.ModelName)
+			fmt.Fprintf(&details, tr("核心数: %d\n"), info.Cores)
+			fmt.Fprintf(&details, tr("频率: %.2f MHz\n"), info.Mhz)
+		}
+		addCheckResult(results, category, tr("CPU型号"), SeverityInfo, StatusOK, details.String())
+	}
+
+	percentages, err := cpu.PercentWithContext(ctx, time.Second, true)
+	if err != nil {
+		addErrorResult(results, category, tr("获取CPU使用率失败"), err)
+		return
+	}
+	var details strings.Builder
+	for i, percentage := range percentages {
+		fmt.Fprintf(&details, tr("CPU%d使用率: %.2f%%\n"), i, percentage)
+	}
+	addCheckResult(results, category, tr("CPU使用率"), SeverityInfo, StatusOK, details.String())
+}
+
+func getMemoryInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("内存信息")
+	virtual, err := mem.VirtualMemory()
+	if err != nil {
+		addErrorResult(results, category, tr("获取内存信息失败"), err)
+		return
+	}
+
+	var details strings.Builder
+	fmt.Fprintf(&details, tr("总内存: %.2f GB\n"), float64(virtual.Total)/(1024*1024*1024))
+	fmt.Fprintf(&details, tr("可用内存: %.2f GB\n"), float64(virtual.Available)/(1024*1024*1024))
+	fmt.Fprintf(&details, tr("内存使用率: %.2f%%\n"), virtual.UsedPercent)
+	addCheckResult(results, category, tr("物理内存使用情况"), SeverityInfo, StatusOK, details.String())
+}
+
+func getDiskInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("磁盘信息")
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		addErrorResult(results, category, tr("获取磁盘分区失败"), err)
+		return
+	}
+
+	for _, partition := range partitions {
+		var details strings.Builder
+		fmt.Fprintf(&details, tr("挂载点: %s\n"), partition.Mountpoint)
+		fmt.Fprintf(&details, tr("文件系统: %s\n"), partition.Fstype)
+
+		usage, err := disk.Usage(partition.Mountpoint)
+		if err == nil {
+			fmt.Fprintf(&details, tr("总空间: %.2f GB\n"), float64(usage.Total)/(1024*1024*1024))
+			fmt.Fprintf(&details, tr("已用空间: %.2f GB\n"), float64(usage.Used)/(1024*1024*1024))
+			fmt.Fprintf(&details, tr("可用空间: %.2f GB\n"), float64(usage.Free)/(1024*1024*1024))
+			fmt.Fprintf(&details, tr("使用率: %.2f%%\n"), usage.UsedPercent)
+		}
+		addCheckResult(results, category, fmt.Sprintf(tr("分区: %s"), partition.Device), SeverityInfo, StatusOK, details.String())
+	}
+}
+
+func getNetworkInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("网络信息")
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		addErrorResult(results, category, tr("获取网卡信息失败"), err)
+	} else {
+		for _, iface := range interfaces {
+			var details strings.Builder
+			fmt.Fprintf(&details, tr("MAC地址: %s\n"), iface.HardwareAddr)
+			fmt.Fprintf(&details, tr("状态: %v\n"), iface.Flags)
+
+			var addrs []string
+			for _, addr := range iface.Addrs {
+				addrs = append(addrs, fmt.Sprintf(tr("IP地址: %s"), addr.Addr))
+			}
+			addCheckResult(results, category, fmt.Sprintf(tr("网卡名称: %s"), iface.Name), SeverityInfo, StatusOK, details.String(), addrs...)
+		}
+	}
+
+	conns, err := net.ConnectionsWithContext(ctx, "all")
+	if err != nil {
+		addErrorResult(results, category, tr("获取网络连接失败"), err)
+		return
+	}
+
+	var evidence []string
+	for i, conn := range conns {
+		if i >= 5 { // 只显示前5个连接
+			break
+		}
+		line := fmt.Sprintf(tr("本地地址: %s:%d"), conn.Laddr.IP, conn.Laddr.Port)
+		if conn.Raddr.IP != "" {
+			line += fmt.Sprintf(tr(" 远程地址: %s:%d"), conn.Raddr.IP, conn.Raddr.Port)
+		}
+		line += fmt.Sprintf(tr(" 状态: %s"), conn.Status)
+		evidence = append(evidence, line)
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("活动连接数: %d"), len(conns)), SeverityInfo, StatusOK, "", evidence...)
+}
+
+func getProcessInfo(ctx context.Context, results *[]CheckResult) {
+	category := tr("进程信息")
+	processes, err := process.ProcessesWithContext(ctx)
+	if err != nil {
+		addErrorResult(results, category, tr("获取进程列表失败"), err)
+		return
+	}
+
+	type ProcessInfo struct {
+		pid     int32
+		name    string
+		cpu     float64
+		memory  float32
+		cmdline string
+	}
+
+	var processInfos []ProcessInfo
+	for _, p := range processes {
+		if ctx.Err() != nil {
+			break
+		}
+		name, _ := p.NameWithContext(ctx)
+		cpu, _ := p.CPUPercentWithContext(ctx)
+		mem, _ := p.MemoryPercentWithContext(ctx)
+		cmd, _ := p.CmdlineWithContext(ctx)
+		processInfos = append(processInfos, ProcessInfo{
+			pid:     p.Pid,
+			name:    name,
+			cpu:     cpu,
+			memory:  mem,
+			cmdline: cmd,
+		})
+	}
+
+	// 按CPU使用率排序
+	sort.SliceStable(processInfos, func(i, j int) bool {
+		return processInfos[i].cpu > processInfos[j].cpu
+	})
+
+	// 记录前5个进程
+	var evidence []string
+	for i := 0; i < 5 && i < len(processInfos); i++ {
+		evidence = append(evidence, fmt.Sprintf(tr("PID: %d 名称: %s CPU使用率: %.2f%% 内存使用率: %.2f%% 命令行: %s"),
+			processInfos[i].pid, processInfos[i].name, processInfos[i].cpu, processInfos[i].memory, processInfos[i].cmdline))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("总进程数: %d"), len(processes)), SeverityInfo, StatusOK,
+		tr("CPU使用率最高的进程:"), evidence...)
+}
@@ -0,0 +1,419 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// scheduledTasksDir 计划任务定义文件所在的目录，由 -tasks-dir 指定，Windows上为空时读取 %SystemRoot%\System32\Tasks
+var scheduledTasksDir string
+
+// 计划任务在注册表中的缓存，Tree下按任务路径保存Id和安全描述符SD，Tasks下按Id保存任务定义
+const taskCachePath = `SOFTWARE\Microsoft\Windows NT\CurrentVersion\Schedule\TaskCache`
+
+// 编码的PowerShell命令：-EncodedCommand可以缩写为-e、-ec、-enc等
+var encodedPowerShell = regexp.MustCompile(`(?i)(?:^|\s)[-/]e(?:c|n[a-z]*)?\s+["']?([A-Za-z0-9+/]{16,}={0,2})`)
+
+// 常见的内置账户SID
+var wellKnownSIDs = map[string]string{
+	"S-1-5-18":     "SYSTEM",
+	"S-1-5-19":     "LOCAL SERVICE",
+	"S-1-5-20":     "NETWORK SERVICE",
+	"S-1-5-32-544": "Administrators",
+	"S-1-5-32-545": "Users",
+}
+
+// taskXML 任务定义文件的XML结构，只包含分析用到的元素
+type taskXML struct {
+	RegistrationInfo struct {
+		Date        string `xml:"Date"`
+		Author      string `xml:"Author"`
+		Description string `xml:"Description"`
+	} `xml:"RegistrationInfo"`
+	Triggers struct {
+		Items []taskTrigger `xml:",any"`
+	} `xml:"Triggers"`
+	Principals struct {
+		Items []struct {
+			ID       string `xml:"id,attr"`
+			UserID   string `xml:"UserId"`
+			GroupID  string `xml:"GroupId"`
+			RunLevel string `xml:"RunLevel"`
+		} `xml:"Principal"`
+	} `xml:"Principals"`
+	Settings struct {
+		Hidden  string `xml:"Hidden"`
+		Enabled string `xml:"Enabled"`
+	} `xml:"Settings"`
+	Actions struct {
+		Context string       `xml:"Context,attr"`
+		Exec    []taskAction `xml:"Exec"`
+		COM     []struct {
+			ClassID string `xml:"ClassId"`
+			Data    string `xml:"Data"`
+		} `xml:"ComHandler"`
+	} `xml:"Actions"`
+}
+
+// taskTrigger 触发器，元素名为LogonTrigger、BootTrigger、TimeTrigger、CalendarTrigger等
+type taskTrigger struct {
+	XMLName       xml.Name
+	Enabled       string `xml:"Enabled"`
+	StartBoundary string `xml:"StartBoundary"`
+	UserID        string `xml:"UserId"`
+	Delay         string `xml:"Delay"`
+	Interval      string `xml:"Repetition>Interval"`
+	Subscription  string `xml:"Subscription"`
+}
+
+func (t taskTrigger) String() string {
+	var parts []string
+	if t.StartBoundary != "" {
+		parts = append(parts, fmt.Sprintf(tr("开始 %s"), t.StartBoundary))
+	}
+	if t.Interval != "" {
+		parts = append(parts, fmt.Sprintf(tr("重复间隔 %s"), t.Interval))
+	}
+	if t.Delay != "" {
+		parts = append(parts, fmt.Sprintf(tr("延迟 %s"), t.Delay))
+	}
+	if t.UserID != "" {
+		parts = append(parts, fmt.Sprintf(tr("用户 %s"), t.UserID))
+	}
+	if t.Subscription != "" {
+		parts = append(parts, fmt.Sprintf(tr("事件 %s"), strings.Join(strings.Fields(t.Subscription), " ")))
+	}
+	if strings.EqualFold(t.Enabled, "false") {
+		parts = append(parts, tr("已禁用"))
+	}
+	name := strings.TrimSuffix(t.XMLName.Local, "Trigger")
+	if len(parts) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, strings.Join(parts, ", "))
+}
+
+// taskAction 运行程序的操作
+type taskAction struct {
+	Command          string `xml:"Command"`
+	Arguments        string `xml:"Arguments"`
+	WorkingDirectory string `xml:"WorkingDirectory"`
+}
+
+// CommandLine 程序和参数
+func (a taskAction) CommandLine() string {
+	return strings.TrimSpace(a.Command + " " + a.Arguments)
+}
+
+// scheduledTask 从任务定义文件中提取的信息
+type scheduledTask struct {
+	Path        string // 任务路径，如 \Microsoft\Windows\Defrag\ScheduledDefrag
+	Author      string
+	Date        string // 注册日期，为注册任务的主机的本地时间
+	Description string
+	RunAs       string
+	RunLevel    string
+	Hidden      bool
+	Enabled     bool
+	Triggers    []string
+	Actions     []taskAction
+	Handlers    []string // COM处理程序的CLSID
+}
+
+// parseScheduledTask 解析任务定义文件，文件通常为带BOM的UTF-16LE编码
+func parseScheduledTask(data []byte, path string) (scheduledTask, error) {
+	var text string
+	switch {
+	case bytes.HasPrefix(data, []byte{0xff, 0xfe}):
+		text = utf16String(data[2:])
+	case len(data) >= 2 && data[0] == '<' && data[1] == 0:
+		text = utf16String(data)
+	default:
+		text = string(bytes.TrimPrefix(data, []byte{0xef, 0xbb, 0xbf}))
+	}
+
+	var doc taskXML
+	dec := xml.NewDecoder(strings.NewReader(text))
+	// 内容已转换为UTF-8，忽略声明中的encoding="UTF-16"
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) { return input, nil }
+	if err := dec.Decode(&doc); err != nil {
+		return scheduledTask{}, err
+	}
+
+	task := scheduledTask{
+		Path:        path,
+		Author:      strings.TrimSpace(doc.RegistrationInfo.Author),
+		Date:        strings.TrimSpace(doc.RegistrationInfo.Date),
+		Description: strings.TrimSpace(doc.RegistrationInfo.Description),
+		Hidden:      strings.EqualFold(doc.Settings.Hidden, "true"),
+		Enabled:     !strings.EqualFold(doc.Settings.Enabled, "false"),
+	}
+	for _, trigger := range doc.Triggers.Items {
+		task.Triggers = append(task.Triggers, trigger.String())
+	}
+	for _, action := range doc.Actions.Exec {
+		action.Command = strings.TrimSpace(action.Command)
+		action.Arguments = strings.TrimSpace(action.Arguments)
+		task.Actions = append(task.Actions, action)
+	}
+	for _, handler := range doc.Actions.COM {
+		task.Handlers = append(task.Handlers, strings.TrimSpace(handler.ClassID))
+	}
+	// 操作以Actions的Context属性指定的主体运行，未指定时为第一个主体
+	for i, principal := range doc.Principals.Items {
+		if i == 0 || principal.ID == doc.Actions.Context {
+			task.RunAs = principal.UserID
+			if task.RunAs == "" {
+				task.RunAs = principal.GroupID
+			}
+			if name, ok := wellKnownSIDs[strings.ToUpper(task.RunAs)]; ok {
+				task.RunAs = name
+			}
+			task.RunLevel = principal.RunLevel
+		}
+	}
+	return task, nil
+}
+
+// encodedPowerShellCommand 返回命令行中以-EncodedCommand传入的PowerShell命令，能解码时返回解码后的脚本
+func encodedPowerShellCommand(commandLine string) (string, bool) {
+	lower := strings.ToLower(commandLine)
+	if !strings.Contains(lower, "powershell") && !strings.Contains(lower, "pwsh") {
+		return "", false
+	}
+	m := encodedPowerShell.FindStringSubmatch(commandLine)
+	if m == nil {
+		return "", false
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(m[1]); err == nil && len(decoded)%2 == 0 {
+		return utf16String(decoded), true
+	}
+	return m[1], true
+}
+
+// taskCacheEntry TaskCache\Tree中的任务
+type taskCacheEntry struct {
+	path    string
+	id      string
+	hasSD   bool // 删除SD值后schtasks和任务计划程序都无法列出该任务
+	defined bool // TaskCache\Tasks中存在该Id
+}
+
+// loadTaskCache 读取TaskCache，以小写的任务路径为键
+func loadTaskCache(ctx context.Context) (map[string]taskCacheEntry, error) {
+	tree, err := openRegistryKey(hkeyLocalMachine, taskCachePath+`\Tree`)
+	if err != nil {
+		return nil, err
+	}
+	defer tree.Close()
+	tasks, err := openRegistryKey(hkeyLocalMachine, taskCachePath+`\Tasks`)
+	if err != nil {
+		return nil, err
+	}
+	defer tasks.Close()
+
+	entries := make(map[string]taskCacheEntry)
+	err = walkTaskCacheTree(ctx, tree, "", func(path string, key registryKey) {
+		id, err := getStringValue(key, "Id")
+		if err != nil {
+			// 没有Id的是文件夹
+			return
+		}
+		entry := taskCacheEntry{path: path, id: id}
+		_, _, err = key.GetValue("SD")
+		entry.hasSD = err == nil
+		if definition, err := tasks.OpenKey(id); err == nil {
+			entry.defined = true
+			definition.Close()
+		}
+		entries[strings.ToLower(path)] = entry
+	})
+	return entries, err
+}
+
+// walkTaskCacheTree 递归访问Tree下的每个键，键用完立即关闭
+func walkTaskCacheTree(ctx context.Context, key registryKey, path string, visit func(string, registryKey)) error {
+	names, err := key.ReadSubKeyNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		subkey, err := key.OpenKey(name)
+		if err != nil {
+			continue
+		}
+		subPath := path + `\` + name
+		visit(subPath, subkey)
+		err = walkTaskCacheTree(ctx, subkey, subPath, visit)
+		subkey.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// analyzeScheduledTasks 解析目录中的任务定义文件，报告从用户可写目录运行、运行编码PowerShell命令
+// 以及与TaskCache不一致的任务
+func analyzeScheduledTasks(ctx context.Context, results *[]CheckResult, dir string) {
+	category := tr("计划任务检查")
+
+	var tasks []scheduledTask
+	var problems []string
+	err := filepath.WalkDir(dir, func(file string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, file)
+		path := `\` + strings.ReplaceAll(filepath.ToSlash(rel), "/", `\`)
+		data, err := os.ReadFile(file)
+		if err == nil {
+			var task scheduledTask
+			if task, err = parseScheduledTask(data, path); err == nil {
+				tasks = append(tasks, task)
+				return nil
+			}
+		}
+		problems = append(problems, fmt.Sprintf("%s: %v", path, err))
+		return nil
+	})
+	if err != nil {
+		addErrorResult(results, category, fmt.Sprintf(tr("读取计划任务目录失败: %s"), dir), err)
+		return
+	}
+	sort.Slice(tasks, func(i, j int) bool { return strings.ToLower(tasks[i].Path) < strings.ToLower(tasks[j].Path) })
+
+	// 任务文件和TaskCache来自同一系统时才比对：都读取本机，或分别指定了 -tasks-dir 和 -hive-dir
+	var cache map[string]taskCacheEntry
+	details := fmt.Sprintf(tr("任务目录: %s\n"), dir)
+	if (scheduledTasksDir == "") != (registryHiveDir == "") {
+		details += tr("未与TaskCache比对（离线分析时需同时指定 -hive-dir）\n")
+	} else if cache, err = loadTaskCache(ctx); err != nil {
+		addErrorResult(results, category, tr("读取TaskCache失败，无法比对注册表中的任务"), err)
+		cache = nil
+	}
+
+	var listing []string
+	for _, task := range tasks {
+		listing = append(listing, task.summary())
+		var entry *taskCacheEntry
+		if cache != nil {
+			if e, ok := cache[strings.ToLower(task.Path)]; ok {
+				entry = &e
+				delete(cache, strings.ToLower(task.Path))
+			}
+		}
+		reportScheduledTask(results, category, task, cache != nil || entry != nil, entry)
+	}
+
+	addCheckResult(results, category, fmt.Sprintf(tr("计划任务数: %d"), len(tasks)), SeverityInfo, StatusOK, details, listing...)
+
+	if len(problems) > 0 {
+		addCheckResult(results, category, fmt.Sprintf(tr("无法解析的任务文件: %d 个"), len(problems)), SeverityInfo, StatusFailed, "", problems...)
+	}
+
+	// TaskCache中有注册项但没有任务文件，任务仍可能按缓存中的定义运行
+	for _, key := range slices.Sorted(maps.Keys(cache)) {
+		entry := cache[key]
+		addCheckResult(results, category, fmt.Sprintf(tr("TaskCache中的任务没有对应的任务文件: %s"), entry.path),
+			SeverityWarning, StatusAbnormal, fmt.Sprintf("Id: %s\n", entry.id))
+	}
+}
+
+// summary 任务列表中的一行
+func (t scheduledTask) summary() string {
+	var commands []string
+	for _, action := range t.Actions {
+		commands = append(commands, action.CommandLine())
+	}
+	for _, handler := range t.Handlers {
+		commands = append(commands, "COM "+handler)
+	}
+	return fmt.Sprintf("%s -> %s", t.Path, strings.Join(commands, "; "))
+}
+
+// reportScheduledTask 检查单个任务，有可疑之处时记录一条结果。compared表示已与TaskCache比对，entry为空表示缓存中没有该任务
+func reportScheduledTask(results *[]CheckResult, category string, task scheduledTask, compared bool, entry *taskCacheEntry) {
+	severity := SeverityInfo
+	raise := func(s string) {
+		if s == SeverityCritical || severity == SeverityInfo {
+			severity = s
+		}
+	}
+	var reasons []string
+	for _, action := range task.Actions {
+		if isUserWritablePath(action.CommandLine()) {
+			reasons = append(reasons, fmt.Sprintf(tr("从用户可写目录运行: %s"), action.CommandLine()))
+			raise(SeverityWarning)
+		}
+		if script, ok := encodedPowerShellCommand(action.CommandLine()); ok {
+			reasons = append(reasons, fmt.Sprintf(tr("运行编码的PowerShell命令: %s"), script))
+			raise(SeverityCritical)
+		}
+	}
+	switch {
+	case compared && entry == nil:
+		reasons = append(reasons, tr("TaskCache中没有对应的注册项"))
+		raise(SeverityWarning)
+	case entry != nil && !entry.defined:
+		reasons = append(reasons, fmt.Sprintf(tr("TaskCache\\Tasks中缺少 %s"), entry.id))
+		raise(SeverityWarning)
+	case entry != nil && !entry.hasSD:
+		reasons = append(reasons, tr("TaskCache中缺少SD值，schtasks和任务计划程序无法列出该任务"))
+		raise(SeverityCritical)
+	}
+	if len(reasons) == 0 {
+		return
+	}
+
+	details := strings.Join(reasons, "\n") + "\n" + task.details()
+	var evidence []string
+	for _, action := range task.Actions {
+		evidence = append(evidence, fmt.Sprintf(tr("操作: %s"), action.CommandLine()))
+	}
+	for _, trigger := range task.Triggers {
+		evidence = append(evidence, fmt.Sprintf(tr("触发器: %s"), trigger))
+	}
+	addCheckResult(results, category, fmt.Sprintf(tr("可疑计划任务: %s"), task.Path), severity, StatusAbnormal, details, evidence...)
+}
+
+// details 任务的作者、运行身份等属性
+func (t scheduledTask) details() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, tr("作者: %s\n"), t.Author)
+	fmt.Fprintf(&b, tr("注册日期: %s\n"), t.Date)
+	runAs := t.RunAs
+	if t.RunLevel != "" {
+		runAs += " (" + t.RunLevel + ")"
+	}
+	fmt.Fprintf(&b, tr("运行身份: %s\n"), runAs)
+	fmt.Fprintf(&b, tr("隐藏: %v, 启用: %v\n"), t.Hidden, t.Enabled)
+	if t.Description != "" {
+		fmt.Fprintf(&b, tr("描述: %s\n"), t.Description)
+	}
+	return b.String()
+}
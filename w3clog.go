@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// logFileStats 读取日志文件的统计
+type logFileStats struct {
+	files   int
+	skipped int
+}
+
+// scanW3CLog 逐行读取W3C扩展格式的日志（IIS日志、Windows防火墙日志），字段顺序以最近的#Fields指令为准，
+// 程序重启或修改字段后会写入新的指令。每条记录以小写字段名为键传给record，"-"表示空值不放入。
+// record返回false或字段数与指令不符的行计为无法解析，返回其数量
+func scanW3CLog(r io.Reader, record func(map[string]string) bool) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var fields []string
+	skipped := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			if rest, ok := strings.CutPrefix(line, "#Fields:"); ok {
+				fields = strings.Fields(strings.ToLower(rest))
+			}
+			continue
+		}
+		values := strings.Fields(line)
+		if fields == nil || len(values) != len(fields) {
+			skipped++
+			continue
+		}
+		entry := make(map[string]string, len(fields))
+		for i, field := range fields {
+			if values[i] != "-" {
+				entry[field] = values[i]
+			}
+		}
+		if !record(entry) {
+			skipped++
+		}
+	}
+	return skipped, scanner.Err()
+}
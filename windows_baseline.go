@@ -4,15 +4,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
-	"strings"
 
 	"golang.org/x/sys/windows/registry"
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/transform"
-	"io"
-	"bytes"
 )
 
 // 安全基线检查项
@@ -24,143 +19,139 @@ type BaselineCheck struct {
 	Remediation string
 }
 
-// 将GBK编码转换为UTF-8
-func gbkToUTF8(data []byte) (string, error) {
-	reader := transform.NewReader(bytes.NewReader(data), simplifiedchinese.GBK.NewDecoder())
-	utf8Data, err := io.ReadAll(reader)
+func init() {
+	registerCheck("baseline.password", "baseline", PrivilegeNone, checkPasswordPolicy)
+	registerCheck("baseline.accounts", "baseline", PrivilegeNone, checkUserAccounts)
+	registerCheck("baseline.services", "baseline", PrivilegeNone, checkSystemServices)
+	registerCheck("baseline.patches", "baseline", PrivilegeNone, checkSystemPatches)
+	registerCheck("baseline.audit", "baseline", PrivilegeAdmin, checkAuditPolicy)
+	registerCheck("baseline.permissions", "baseline", PrivilegeAdmin, checkFileSystemPermissions)
+	registerCheck("baseline.shares", "baseline", PrivilegeNone, checkShareSettings)
+	registerCheck("baseline.uac", "baseline", PrivilegeNone, checkUACSettings)
+	registerCheck("baseline.defender", "baseline", PrivilegeAdmin, checkWindowsDefender)
+}
+
+// 执行命令并将其输出作为信息类检查结果记录
+func addCommandOutputResult(ctx context.Context, results *[]CheckResult, category, description string, name string, args ...string) {
+	cmd := runCommand(ctx, name, args...)
+	output, err := cmd.Text()
+	if isNotPresent(err) {
+		addAbsentResult(results, category, description, fmt.Sprintf(tr("未找到命令: %s\n"), name))
+		return
+	}
 	if err != nil {
-		return string(data), err // 如果转换失败，返回原始数据
+		addErrorResult(results, category, fmt.Sprintf(tr("%s获取失败"), description), err)
+		return
 	}
-	return string(utf8Data), nil
+
+	addCheckResult(results, category, description, SeverityInfo, StatusOK, output)
 }
 
 // 检查密码策略
-func checkPasswordPolicy() {
-	fmt.Println("=== 密码策略检查 ===")
+func checkPasswordPolicy(ctx context.Context, results *[]CheckResult) {
+	category := tr("密码策略检查")
 
-	cmd := exec.Command("net", "accounts")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("当前密码策略:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("当前密码策略:\n%s\n", string(output))
-		}
-	}
+	addCommandOutputResult(ctx, results, category, tr("当前密码策略"), "net", "accounts")
 
 	// 检查密码复杂度要求
 	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\Netlogon\Parameters`, registry.READ)
 	if err == nil {
 		defer key.Close()
 		if val, _, err := key.GetIntegerValue("RequireStrongKey"); err == nil && val == 0 {
-			fmt.Println("[警告] 未启用强密码要求")
+			addCheckResult(results, category, tr("未启用强密码要求"), SeverityWarning, StatusAbnormal,
+				`SYSTEM\CurrentControlSet\Services\Netlogon\Parameters\RequireStrongKey = 0`)
 		}
 	}
 }
 
 // 检查用户账户设置
-func checkUserAccounts() {
-	fmt.Println("\n=== 用户账户检查 ===")
+func checkUserAccounts(ctx context.Context, results *[]CheckResult) {
+	category := tr("用户账户检查")
 
 	// 检查管理员组成员
-	cmd := exec.Command("net", "localgroup", "Administrators")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("管理员组成员:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("管理员组成员:\n%s\n", string(output))
-		}
-	}
+	addCommandOutputResult(ctx, results, category, tr("管理员组成员"), "net", "localgroup", "Administrators")
 
 	// 检查来宾账户状态
-	cmd = exec.Command("net", "user", "Guest")
-	output, err = cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			if !strings.Contains(utf8Output, "Account active               No") {
-				fmt.Println("[警告] Guest账户未禁用")
-			}
-		} else {
-			if !strings.Contains(string(output), "Account active               No") {
-				fmt.Println("[警告] Guest账户未禁用")
-			}
-		}
+	cmd := runCommand(ctx, "net", "user", "Guest")
+	output, err := cmd.Text()
+	if err != nil {
+		addErrorResult(results, category, tr("查询Guest账户失败"), err)
+		return
+	}
+	account, ok := parseNetUser(output)
+	if !ok {
+		addCheckResult(results, category, tr("无法解析Guest账户状态"), SeverityInfo, StatusFailed, output)
+		return
+	}
+	if account.Active {
+		addCheckResult(results, category, tr("Guest账户未禁用"), SeverityWarning, StatusAbnormal, output)
+	} else {
+		addCheckResult(results, category, tr("Guest账户已禁用"), SeverityInfo, StatusOK, "")
 	}
 }
 
 // 检查系统服务
-func checkSystemServices() {
-	fmt.Println("\n=== 系统服务检查 ===")
-
-	// 检查关键服务状态
-	criticalServices := []string{
-		"Windows Defender",
-		"Windows Firewall",
-		"Windows Update",
-		"Remote Registry",
+func checkSystemServices(ctx context.Context, results *[]CheckResult) {
+	category := tr("系统服务检查")
+
+	// 检查关键服务状态，sc query 需要使用服务名而非显示名称
+	criticalServices := []struct {
+		name        string
+		displayName string
+		state       int // 期望的状态，0表示仅记录
+	}{
+		{"WinDefend", "Windows Defender", ServiceRunning},
+		{"MpsSvc", "Windows Firewall", ServiceRunning},
+		{"wuauserv", "Windows Update", 0},
+		{"RemoteRegistry", "Remote Registry", ServiceStopped},
 	}
 
 	for _, service := range criticalServices {
-		cmd := exec.Command("sc", "query", service)
-		output, err := cmd.Output()
-		if err == nil {
-			utf8Output, convErr := gbkToUTF8(output)
-			if convErr == nil {
-				if strings.Contains(utf8Output, "RUNNING") {
-					fmt.Printf("%s: 运行中\n", service)
-				} else {
-					fmt.Printf("[警告] %s: 未运行\n", service)
-				}
-			} else {
-				if strings.Contains(string(output), "RUNNING") {
-					fmt.Printf("%s: 运行中\n", service)
-				} else {
-					fmt.Printf("[警告] %s: 未运行\n", service)
-				}
-			}
+		if ctx.Err() != nil {
+			return
+		}
+		cmd := runCommand(ctx, "sc", "query", service.name)
+		output, err := cmd.Text()
+		if err != nil {
+			addErrorResult(results, category, fmt.Sprintf(tr("查询服务失败: %s"), service.displayName), err)
+			continue
+		}
+		status, ok := parseScQuery(output)
+		if !ok {
+			addCheckResult(results, category, fmt.Sprintf(tr("无法解析服务状态: %s"), service.displayName), SeverityInfo, StatusFailed, output)
+			continue
+		}
+
+		running := status.State == ServiceRunning
+		state := tr("未运行")
+		if running {
+			state = tr("运行中")
+		}
+		description := fmt.Sprintf("%s: %s", service.displayName, state)
+		switch {
+		case service.state == ServiceRunning && !running:
+			addCheckResult(results, category, description, SeverityWarning, StatusAbnormal, output)
+		case service.state == ServiceStopped && running:
+			addCheckResult(results, category, description, SeverityWarning, StatusAbnormal, tr("该服务应处于停止状态"))
+		default:
+			addCheckResult(results, category, description, SeverityInfo, StatusOK, "")
 		}
 	}
 }
 
 // 检查系统补丁
-func checkSystemPatches() {
-	fmt.Println("\n=== 系统补丁检查 ===")
-
-	cmd := exec.Command("wmic", "qfe", "list", "brief")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("已安装的补丁:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("已安装的补丁:\n%s\n", string(output))
-		}
-	}
+func checkSystemPatches(ctx context.Context, results *[]CheckResult) {
+	addCommandOutputResult(ctx, results, tr("系统补丁检查"), tr("已安装的补丁"), "wmic", "qfe", "list", "brief")
 }
 
 // 检查系统审计策略
-func checkAuditPolicy() {
-	fmt.Println("\n=== 审计策略检查 ===")
-
-	cmd := exec.Command("auditpol", "/get", "/category:*")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("当前审计策略:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("当前审计策略:\n%s\n", string(output))
-		}
-	}
+func checkAuditPolicy(ctx context.Context, results *[]CheckResult) {
+	addCommandOutputResult(ctx, results, tr("审计策略检查"), tr("当前审计策略"), "auditpol", "/get", "/category:*")
 }
 
 // 检查文件系统权限
-func checkFileSystemPermissions() {
-	fmt.Println("\n=== 文件系统权限检查 ===")
+func checkFileSystemPermissions(ctx context.Context, results *[]CheckResult) {
+	category := tr("文件系统权限检查")
 
 	// 检查系统关键目录权限
 	criticalPaths := []string{
@@ -171,76 +162,42 @@ func checkFileSystemPermissions() {
 	}
 
 	for _, path := range criticalPaths {
-		cmd := exec.Command("icacls", path)
-		output, err := cmd.Output()
-		if err == nil {
-			utf8Output, convErr := gbkToUTF8(output)
-			if convErr == nil {
-				fmt.Printf("%s 权限:\n%s\n", path, utf8Output)
-			} else {
-				fmt.Printf("%s 权限:\n%s\n", path, string(output))
-			}
+		if ctx.Err() != nil {
+			return
 		}
+		addCommandOutputResult(ctx, results, category, fmt.Sprintf(tr("%s 权限"), path), "icacls", path)
 	}
 }
 
 // 检查共享设置
-func checkShareSettings() {
-	fmt.Println("\n=== 共享设置检查 ===")
-
-	cmd := exec.Command("net", "share")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("当前共享:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("当前共享:\n%s\n", string(output))
-		}
-	}
+func checkShareSettings(ctx context.Context, results *[]CheckResult) {
+	addCommandOutputResult(ctx, results, tr("共享设置检查"), tr("当前共享"), "net", "share")
 }
 
 // 检查UAC设置
-func checkUACSettings() {
-	fmt.Println("\n=== UAC设置检查 ===")
+func checkUACSettings(ctx context.Context, results *[]CheckResult) {
+	category := tr("UAC设置检查")
 
 	key, err := registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Windows\CurrentVersion\Policies\System`, registry.READ)
-	if err == nil {
-		defer key.Close()
+	if err != nil {
+		addErrorResult(results, category, tr("读取UAC配置失败"), err)
+		return
+	}
+	defer key.Close()
 
-		if val, _, err := key.GetIntegerValue("EnableLUA"); err == nil {
-			if val == 0 {
-				fmt.Println("[警告] UAC已禁用")
-			} else {
-				fmt.Println("UAC已启用")
-			}
+	if val, _, err := key.GetIntegerValue("EnableLUA"); err == nil {
+		if val == 0 {
+			addCheckResult(results, category, tr("UAC已禁用"), SeverityWarning, StatusAbnormal, "EnableLUA = 0")
+		} else {
+			addCheckResult(results, category, tr("UAC已启用"), SeverityInfo, StatusOK, "")
 		}
 	}
 }
 
 // 检查Windows Defender设置
-func checkWindowsDefender() {
-	fmt.Println("\n=== Windows Defender检查 ===")
+func checkWindowsDefender(ctx context.Context, results *[]CheckResult) {
+	category := tr("Windows Defender检查")
 
-	cmd := exec.Command("powershell", "Get-MpPreference")
-	output, err := cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("Windows Defender配置:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("Windows Defender配置:\n%s\n", string(output))
-		}
-	}
-
-	cmd = exec.Command("powershell", "Get-MpComputerStatus")
-	output, err = cmd.Output()
-	if err == nil {
-		utf8Output, convErr := gbkToUTF8(output)
-		if convErr == nil {
-			fmt.Printf("Windows Defender状态:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("Windows Defender状态:\n%s\n", string(output))
-		}
-	}
-}
\ No newline at end of file
+	addCommandOutputResult(ctx, results, category, tr("Windows Defender配置"), "powershell", "Get-MpPreference")
+	addCommandOutputResult(ctx, results, category, tr("Windows Defender状态"), "powershell", "Get-MpComputerStatus")
+}
@@ -4,123 +4,64 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"io/fs"
 	"os"
 	"path/filepath"
-	"time"
 )
 
-// 日志类型定义
-const (
-	SystemLog    = "System"
-	ApplicationLog = "Application"
-	SecurityLog   = "Security"
-	PowerShellLog = "Windows PowerShell"
-)
-
-// 日志分析结果结构
-type LogAnalysis struct {
-	Source    string
-	EventID   uint32
-	Level     uint16
-	TimeStamp time.Time
-	Message   string
-}
-
-// 分析系统日志
-func analyzeSystemLogs() {
-	fmt.Println("=== 系统日志分析 ===")
-
-	// 分析系统启动和关机事件
-	fmt.Println("[*] 系统启动和关机事件:")
-	analyzeEventLog(SystemLog, []uint32{6005, 6006, 6008, 6013})
-
-	// 分析系统错误和警告
-	fmt.Println("\n[*] 系统错误和警告:")
-	analyzeEventLog(SystemLog, []uint32{1001, 1002, 1003, 1004, 1005, 1006})
-
-	// 分析驱动程序错误
-	fmt.Println("\n[*] 驱动程序错误:")
-	analyzeEventLog(SystemLog, []uint32{219, 7000, 7001, 7022, 7023, 7024, 7026, 7034, 7035, 7045})
-}
-
-// 分析安全日志
-func analyzeSecurityLogs() {
-	fmt.Println("\n=== 安全日志分析 ===")
-
-	// 分析登录事件
-	fmt.Println("[*] 登录事件分析:")
-	analyzeEventLog(SecurityLog, []uint32{4624, 4625, 4634, 4647, 4672})
-
-	// 分析账户管理
-	fmt.Println("\n[*] 账户管理事件:")
-	analyzeEventLog(SecurityLog, []uint32{4720, 4722, 4724, 4725, 4726, 4728, 4732, 4735, 4740, 4756})
-
-	// 分析策略更改
-	fmt.Println("\n[*] 策略更改事件:")
-	analyzeEventLog(SecurityLog, []uint32{4739, 4902, 4904, 4905, 4906, 4907, 4908, 4912})
+func init() {
+	registerCheck("log.system", "log", PrivilegeNone, analyzeSystemLogs)
+	registerCheck("log.security", "log", PrivilegeAdmin, analyzeSecurityLogs)
+	registerCheck("log.application", "log", PrivilegeNone, analyzeApplicationLogs)
+	registerCheck("log.powershell", "log", PrivilegeNone, analyzePowerShellLogs)
+	registerCheck("log.files", "log", PrivilegeAdmin, analyzeLogFiles)
+	registerCheck("log.sigma", "log", PrivilegeAdmin, analyzeSigmaRules)
 }
 
-// 分析应用程序日志
-func analyzeApplicationLogs() {
-	fmt.Println("\n=== 应用程序日志分析 ===")
+// wevtutil找不到日志通道时的退出码 (ERROR_EVT_CHANNEL_NOT_FOUND)
+const errEvtChannelNotFound = 15007
 
-	// 分析应用程序错误
-	fmt.Println("[*] 应用程序错误:")
-	analyzeEventLog(ApplicationLog, []uint32{1000, 1001, 1002})
-
-	// 分析服务启动失败
-	fmt.Println("\n[*] 服务启动失败:")
-	analyzeEventLog(ApplicationLog, []uint32{7000, 7001, 7022, 7023, 7024, 7026, 7031, 7034})
-}
-
-// 分析PowerShell日志
-func analyzePowerShellLogs() {
-	fmt.Println("\n=== PowerShell日志分析 ===")
-
-	// 分析PowerShell执行策略更改
-	fmt.Println("[*] 执行策略更改:")
-	analyzeEventLog(PowerShellLog, []uint32{400, 403, 800})
+// exportEventLog 通过wevtutil将本机日志导出到临时文件，避免直接读取被事件日志服务占用的文件
+func exportEventLog(ctx context.Context, channel string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "evtx")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
 
-	// 分析脚本执行
-	fmt.Println("\n[*] 脚本执行记录:")
-	analyzeEventLog(PowerShellLog, []uint32{4100, 4104})
+	path := filepath.Join(dir, eventLogFileName(channel))
+	result := runCommand(ctx, "wevtutil", "epl", channel, path)
+	if result.ExitCode == errEvtChannelNotFound {
+		cleanup()
+		return "", nil, &fs.PathError{Op: "wevtutil epl", Path: channel, Err: fs.ErrNotExist}
+	}
+	if result.Err != nil {
+		cleanup()
+		return "", nil, result.Err
+	}
+	return path, cleanup, nil
 }
 
-// 分析指定事件日志
-func analyzeEventLog(logName string, eventIDs []uint32) {
-	// 这里需要使用Windows API来读取事件日志
-	// 由于实现复杂度较高，这里仅作示例
-	fmt.Printf("正在分析 %s 日志中的事件: %v\n", logName, eventIDs)
-}
+// IIS默认的日志目录
+const defaultIISLogDir = `C:\inetpub\logs\LogFiles`
 
 // 分析日志文件
-func analyzeLogFiles() {
-	fmt.Println("\n=== 日志文件分析 ===")
-
-	// 分析IIS日志
-	iisLogPath := "C:\\inetpub\\logs\\LogFiles"
-	if _, err := os.Stat(iisLogPath); err == nil {
-		fmt.Println("[*] IIS日志分析:")
-		analyzeIISLogs(iisLogPath)
+func analyzeLogFiles(ctx context.Context, results *[]CheckResult) {
+	// 分析IIS日志，未指定 -iis-dir 时读取默认目录（未安装IIS时不存在）
+	if iisLogDir != "" {
+		analyzeIISLogs(ctx, results, iisLogDir)
+	} else if _, err := os.Stat(defaultIISLogDir); err == nil {
+		analyzeIISLogs(ctx, results, defaultIISLogDir)
 	}
 
-	// 分析防火墙日志
-	fwLogPath := filepath.Join(os.Getenv("SystemRoot"), "System32", "LogFiles", "Firewall")
-	if _, err := os.Stat(fwLogPath); err == nil {
-		fmt.Println("\n[*] 防火墙日志分析:")
-		analyzeFirewallLogs(fwLogPath)
+	// 分析防火墙日志，未指定 -firewall-dir 时读取默认目录
+	if firewallLogDir != "" {
+		analyzeFirewallLogs(ctx, results, firewallLogDir)
+	} else {
+		fwLogPath := filepath.Join(os.Getenv("SystemRoot"), "System32", "LogFiles", "Firewall")
+		if _, err := os.Stat(fwLogPath); err == nil {
+			analyzeFirewallLogs(ctx, results, fwLogPath)
+		}
 	}
 }
-
-// 分析IIS日志
-func analyzeIISLogs(path string) {
-	// 实现IIS日志分析逻辑
-	fmt.Printf("分析IIS日志目录: %s\n", path)
-}
-
-// 分析防火墙日志
-func analyzeFirewallLogs(path string) {
-	// 实现防火墙日志分析逻辑
-	fmt.Printf("分析防火墙日志目录: %s\n", path)
-}
\ No newline at end of file
@@ -4,174 +4,152 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"fmt"
-	"io"
+	"golang.org/x/sys/windows/registry"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
 	"time"
-	"golang.org/x/sys/windows/registry"
-	"golang.org/x/text/encoding/simplifiedchinese"
-	"golang.org/x/text/transform"
 )
 
-// 重要的注册表路径
-var criticalRegPaths = []string{
-	"SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Run",
-	"SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunOnce",
-	"SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\RunServices",
-	"SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Policies",
-	"SYSTEM\\CurrentControlSet\\Services",
-	"SYSTEM\\CurrentControlSet\\Control\\SafeBoot",
-	"SOFTWARE\\Microsoft\\Windows NT\\CurrentVersion\\Winlogon",
-	"SOFTWARE\\Microsoft\\Windows\\CurrentVersion\\Explorer\\Shell Folders",
+func init() {
+	registerCheck("reg.registry", "reg", PrivilegeNone, checkRegistry)
+	registerCheck("reg.integrity", "reg", PrivilegeNone, checkSystemFileIntegrity)
+	registerCheck("reg.files", "reg", PrivilegeAdmin, checkSuspiciousFiles)
+	registerCheck("reg.shimcache", "reg", PrivilegeAdmin, checkShimcache)
+	registerCheck("reg.amcache", "reg", PrivilegeAdmin, checkAmcache)
 }
 
-// 检查注册表项
-func checkRegistry() {
-	fmt.Println("=== 注册表检查 ===")
-
-	// 检查HKEY_LOCAL_MACHINE
-	fmt.Println("\n检查HKEY_LOCAL_MACHINE:")
-	for _, path := range criticalRegPaths {
-		key, err := registry.OpenKey(registry.LOCAL_MACHINE, path, registry.READ)
-		if err != nil {
-			fmt.Printf("无法打开注册表项 %s: %v\n", path, err)
-			continue
-		}
-		defer key.Close()
+// liveAmcachePath 本机的Amcache.hve，通常被系统占用而无法直接读取
+func liveAmcachePath() (string, error) {
+	return filepath.Join(os.Getenv("SystemRoot"), "AppCompat", "Programs", amcacheFile), nil
+}
 
-		// 获取所有值
-		values, err := key.ReadValueNames(0)
-		if err != nil {
-			fmt.Printf("无法读取值: %v\n", err)
-			continue
-		}
+// liveRegistryKey 本机注册表中的键
+type liveRegistryKey struct {
+	key registry.Key
+}
 
-		fmt.Printf("\n[%s]\n", path)
-		for _, name := range values {
-			val, _, err := key.GetStringValue(name)
-			if err == nil {
-				fmt.Printf("%s = %s\n", name, val)
-			}
-		}
+// openLiveRegistryKey 打开本机注册表中根键下的路径
+func openLiveRegistryKey(root, path string) (registryKey, error) {
+	k := registry.LOCAL_MACHINE
+	if root == hkeyCurrentUser {
+		k = registry.CURRENT_USER
 	}
+	key, err := registry.OpenKey(k, path, registry.READ)
+	if err != nil {
+		return nil, err
+	}
+	return liveRegistryKey{key}, nil
+}
 
-	// 检查HKEY_CURRENT_USER
-	fmt.Println("\n检查HKEY_CURRENT_USER:")
-	for _, path := range criticalRegPaths {
-		key, err := registry.OpenKey(registry.CURRENT_USER, path, registry.READ)
-		if err != nil {
-			continue
-		}
-		defer key.Close()
+func (k liveRegistryKey) OpenKey(path string) (registryKey, error) {
+	key, err := registry.OpenKey(k.key, path, registry.READ)
+	if err != nil {
+		return nil, err
+	}
+	return liveRegistryKey{key}, nil
+}
 
-		values, err := key.ReadValueNames(0)
-		if err != nil {
-			continue
-		}
+func (k liveRegistryKey) ReadSubKeyNames() ([]string, error) { return k.key.ReadSubKeyNames(0) }
+func (k liveRegistryKey) ReadValueNames() ([]string, error)  { return k.key.ReadValueNames(0) }
+func (k liveRegistryKey) Close() error                       { return k.key.Close() }
 
-		fmt.Printf("\n[%s]\n", path)
-		for _, name := range values {
-			val, _, err := key.GetStringValue(name)
-			if err == nil {
-				fmt.Printf("%s = %s\n", name, val)
-			}
-		}
+func (k liveRegistryKey) GetValue(name string) (uint32, []byte, error) {
+	size, _, err := k.key.GetValue(name, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	data := make([]byte, size)
+	size, typ, err := k.key.GetValue(name, data)
+	if err != nil {
+		return 0, nil, err
 	}
+	return typ, data[:size], nil
 }
 
-// 将GBK编码转换为UTF-8 (在windows_registry.go中重复定义以避免依赖)
-func gbkToUTF8Reg(data []byte) (string, error) {
-	reader := transform.NewReader(bytes.NewReader(data), simplifiedchinese.GBK.NewDecoder())
-	utf8Data, err := io.ReadAll(reader)
+func (k liveRegistryKey) LastWriteTime() (time.Time, error) {
+	info, err := k.key.Stat()
 	if err != nil {
-		return string(data), err // 如果转换失败，返回原始数据
+		return time.Time{}, err
 	}
-	return string(utf8Data), nil
+	return info.ModTime(), nil
 }
 
 // 检查系统文件完整性
-func checkSystemFileIntegrity() {
-	fmt.Println("\n=== 系统文件完整性检查 ===")
-
-	// 检查系统关键文件
-	criticalFiles := []string{
-		"C:\\Windows\\System32\\ntoskrnl.exe",
-		"C:\\Windows\\System32\\winlogon.exe",
-		"C:\\Windows\\System32\\services.exe",
-		"C:\\Windows\\System32\\lsass.exe",
-		"C:\\Windows\\System32\\svchost.exe",
-		"C:\\Windows\\System32\\csrss.exe",
-	}
+func checkSystemFileIntegrity(ctx context.Context, results *[]CheckResult) {
+	category := tr("系统文件完整性检查")
+
+	for _, file := range config.Files.CriticalFiles {
+		if ctx.Err() != nil {
+			return
+		}
 
-	for _, file := range criticalFiles {
 		// 检查文件是否存在
-		if _, err := os.Stat(file); os.IsNotExist(err) {
-			fmt.Printf("警告: 文件不存在 - %s\n", file)
+		fileInfo, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			addCheckResult(results, category, fmt.Sprintf(tr("文件不存在 - %s"), file), SeverityCritical, StatusAbnormal, "")
 			continue
 		}
 
-		// 获取文件数字签名信息
-		cmd := exec.Command("powershell", "-Command", fmt.Sprintf("Get-AuthenticodeSignature '%s' | Select-Object -Property Status,SignerCertificate", file))
-		output, err := cmd.Output()
+		// 获取文件数字签名状态和签名者，Status为枚举名称，不随系统语言变化
+		cmd := runCommand(ctx, "powershell", "-Command", fmt.Sprintf("$s = Get-AuthenticodeSignature '%s'; $s.Status; $s.SignerCertificate.Subject", file))
+		output, err := cmd.Text()
 		if err != nil {
-			fmt.Printf("无法验证文件签名 %s: %v\n", file, err)
+			addErrorResult(results, category, fmt.Sprintf(tr("无法验证文件签名 %s"), file), err)
 			continue
 		}
 
-		fmt.Printf("\n文件: %s\n", file)
-		// 转换编码
-		utf8Output, convErr := gbkToUTF8Reg(output)
-		if convErr == nil {
-			fmt.Printf("签名信息:\n%s\n", utf8Output)
-		} else {
-			fmt.Printf("签名信息:\n%s\n", string(output))
+		lines := strings.Split(strings.TrimSpace(output), "\n")
+		status := strings.TrimSpace(lines[0])
+
+		details := fmt.Sprintf(tr("签名状态: %s\n"), status)
+		if len(lines) > 1 {
+			details += fmt.Sprintf(tr("签名者: %s\n"), strings.TrimSpace(lines[1]))
+		}
+		if fileInfo != nil {
+			details += fmt.Sprintf(tr("大小: %d 字节\n修改时间: %v\n"), fileInfo.Size(), fileInfo.ModTime())
 		}
 
-		// 获取文件属性
-		fileInfo, err := os.Stat(file)
-		if err == nil {
-			fmt.Printf("大小: %d 字节\n", fileInfo.Size())
-			fmt.Printf("修改时间: %v\n", fileInfo.ModTime())
+		if status == "Valid" {
+			addCheckResult(results, category, fmt.Sprintf(tr("文件: %s"), file), SeverityInfo, StatusOK, details)
+		} else {
+			addCheckResult(results, category, fmt.Sprintf(tr("系统文件签名无效: %s"), file), SeverityCritical, StatusAbnormal, details)
 		}
 	}
 }
 
 // 检查可疑文件
-func checkSuspiciousFiles() {
-	fmt.Println("\n=== 可疑文件检查 ===")
-
-	// 检查常见的恶意软件位置
-	suspiciousPaths := []string{
-		os.Getenv("TEMP"),
-		os.Getenv("APPDATA"),
-		os.Getenv("LOCALAPPDATA"),
-		"C:\\Windows\\Temp",
-	}
+func checkSuspiciousFiles(ctx context.Context, results *[]CheckResult) {
+	category := tr("可疑文件检查")
 
-	// 可疑文件扩展名
-	suspiciousExts := []string{
-		".exe", ".dll", ".bat", ".cmd", ".ps1", ".vbs", ".js",
-	}
+	window := config.Files.RecentWindow
+	for _, dir := range config.Files.SuspiciousDirs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		// 环境变量未定义的目录（如以SYSTEM身份运行时的%APPDATA%）跳过
+		if dir = expandPath(dir); dir == "" {
+			continue
+		}
 
-	for _, path := range suspiciousPaths {
-		fmt.Printf("\n检查目录: %s\n", path)
-		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+		var evidence []string
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if ctx.Err() != nil {
+				return filepath.SkipAll
+			}
 			if err != nil {
 				return nil
 			}
 			if !info.IsDir() {
 				ext := strings.ToLower(filepath.Ext(path))
-				for _, suspiciousExt := range suspiciousExts {
-					if ext == suspiciousExt {
-						// 检查文件创建时间
-						if time.Since(info.ModTime()) < 24*time.Hour {
-							fmt.Printf("发现可疑文件: %s\n", path)
-							fmt.Printf("大小: %d 字节\n", info.Size())
-							fmt.Printf("修改时间: %v\n", info.ModTime())
+				for _, suspiciousExt := range config.Files.SuspiciousExts {
+					if strings.EqualFold(ext, suspiciousExt) {
+						// 检查文件修改时间
+						if time.Since(info.ModTime()) < window {
+							evidence = append(evidence, fmt.Sprintf(tr("%s (大小: %d 字节, 修改时间: %v)"), path, info.Size(), info.ModTime()))
 						}
 					}
 				}
@@ -179,7 +157,15 @@ func checkSuspiciousFiles() {
 			return nil
 		})
 		if err != nil {
-			fmt.Printf("检查目录出错 %s: %v\n", path, err)
+			addErrorResult(results, category, fmt.Sprintf(tr("检查目录出错 %s"), dir), err)
+			continue
+		}
+
+		if len(evidence) > 0 {
+			addCheckResult(results, category, fmt.Sprintf(tr("发现可疑文件: %s"), dir), SeverityWarning, StatusAbnormal,
+				fmt.Sprintf(tr("最近%v内修改的可执行文件或脚本"), window), evidence...)
+		} else {
+			addCheckResult(results, category, fmt.Sprintf(tr("检查目录: %s"), dir), SeverityInfo, StatusOK, tr("未发现最近修改的可疑文件"))
 		}
 	}
-}
\ No newline at end of file
+}
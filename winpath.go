@@ -0,0 +1,21 @@
+package main
+
+import "strings"
+
+// userWritableDirs 普通用户可以写入、恶意软件常用作落地点的Windows目录，按小写的路径片段匹配，
+// 同时匹配未展开的环境变量形式
+var userWritableDirs = []string{
+	`\users\`, `\programdata\`, `\windows\temp\`, `\temp\`, `\appdata\`, `\perflogs\`,
+	`%appdata%`, `%localappdata%`, `%temp%`, `%tmp%`, `%userprofile%`, `%public%`, `%programdata%`,
+}
+
+// isUserWritablePath 判断路径或命令行中是否出现用户可写的目录
+func isUserWritablePath(path string) bool {
+	p := strings.ToLower(strings.ReplaceAll(path, "/", `\`))
+	for _, dir := range userWritableDirs {
+		if strings.Contains(p, dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// LZXPRESS Huffman（MS-XCA 2.2）解压，Windows 10起的Prefetch文件使用该格式压缩
+
+const (
+	xpressChunkSize   = 65536 // 每个块输出的字节数，块开头为新的Huffman表
+	xpressTableSize   = 256   // 512个符号的码长，每个4位
+	xpressSymbols     = 512
+	xpressMaxCodeBits = 15
+)
+
+var errXpressCorrupt = errors.New("xpress huffman: corrupt data")
+
+// decompressXpressHuffman 解压in，size为解压后的长度
+func decompressXpressHuffman(in []byte, size int) ([]byte, error) {
+	out := make([]byte, 0, size)
+	pos := 0
+	var table [1 << xpressMaxCodeBits]uint16
+	var lengths [xpressSymbols]uint8
+
+	read16 := func(p int) uint32 {
+		if p+2 > len(in) {
+			return 0
+		}
+		return uint32(binary.LittleEndian.Uint16(in[p:]))
+	}
+
+	for len(out) < size {
+		if pos+xpressTableSize > len(in) {
+			return nil, errXpressCorrupt
+		}
+		for i, b := range in[pos : pos+xpressTableSize] {
+			lengths[2*i] = b & 0x0f
+			lengths[2*i+1] = b >> 4
+		}
+		if err := buildXpressTable(&table, &lengths); err != nil {
+			return nil, err
+		}
+		pos += xpressTableSize
+
+		bits := read16(pos)<<16 | read16(pos+2)
+		pos += 4
+		extra := 16
+		// refill 已消耗的位数超过预读的16位时读入下一个16位字
+		refill := func() {
+			if extra < 0 {
+				bits |= read16(pos) << uint(-extra)
+				extra += 16
+				pos += 2
+			}
+		}
+
+		blockEnd := len(out) + xpressChunkSize
+		for len(out) < size && len(out) < blockEnd {
+			symbol := table[bits>>(32-xpressMaxCodeBits)]
+			n := int(lengths[symbol])
+			bits <<= uint(n)
+			extra -= n
+			refill()
+
+			if symbol < 256 {
+				out = append(out, byte(symbol))
+				continue
+			}
+			symbol -= 256
+			length := int(symbol & 0x0f)
+			offsetBits := uint(symbol >> 4)
+			if length == 15 {
+				if pos >= len(in) {
+					return nil, errXpressCorrupt
+				}
+				length = int(in[pos])
+				pos++
+				if length == 255 {
+					if pos+2 > len(in) {
+						return nil, errXpressCorrupt
+					}
+					length = int(binary.LittleEndian.Uint16(in[pos:]))
+					pos += 2
+					if length < 15 {
+						return nil, errXpressCorrupt
+					}
+					length -= 15
+				}
+				length += 15
+			}
+			length += 3
+
+			// offsetBits为0时移位32位得到0，偏移为1
+			offset := int(bits>>(32-offsetBits)) | 1<<offsetBits
+			bits <<= offsetBits
+			extra -= int(offsetBits)
+			refill()
+
+			if offset > len(out) {
+				return nil, errXpressCorrupt
+			}
+			// 源和目标可能重叠，逐字节复制
+			start := len(out) - offset
+			for i := 0; i < length && len(out) < size; i++ {
+				out = append(out, out[start+i])
+			}
+		}
+	}
+	return out, nil
+}
+
+// buildXpressTable 按码长构造范式Huffman码的查找表，以15位前缀为下标
+func buildXpressTable(table *[1 << xpressMaxCodeBits]uint16, lengths *[xpressSymbols]uint8) error {
+	next := 0
+	for n := 1; n <= xpressMaxCodeBits; n++ {
+		span := 1 << (xpressMaxCodeBits - n)
+		for symbol, length := range lengths {
+			if int(length) != n {
+				continue
+			}
+			if next+span > len(table) {
+				return errXpressCorrupt
+			}
+			for i := next; i < next+span; i++ {
+				table[i] = uint16(symbol)
+			}
+			next += span
+		}
+	}
+	if next == 0 {
+		return errXpressCorrupt
+	}
+	// 不完整的码表中未使用的前缀
+	for i := next; i < len(table); i++ {
+		table[i] = 0
+	}
+	return nil
+}
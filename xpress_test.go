@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/bits"
+	"strings"
+	"testing"
+)
+
+// xpressTestCompress 以LZXPRESS Huffman格式压缩data。所有符号使用9位码长，范式码即符号值本身，
+// 匹配只在当前块内查找最近1024字节，足以覆盖解压时的各种分支
+func xpressTestCompress(data []byte) []byte {
+	var out []byte
+	for start := 0; start < len(data); start += xpressChunkSize {
+		end := start + xpressChunkSize
+		if end > len(data) {
+			end = len(data)
+		}
+		out = xpressTestBlock(out, data, start, end)
+	}
+	return out
+}
+
+func xpressTestBlock(out, data []byte, start, end int) []byte {
+	out = append(out, bytes.Repeat([]byte{0x99}, xpressTableSize)...)
+	// 位流按16位字写入，解压时预读两个字，之后每消耗16位读入下一个字，
+	// 因此新字的位置要在写入对应的位时预留，匹配长度的附加字节按当时的位置写入
+	slots := []int{len(out), len(out) + 2}
+	out = append(out, 0, 0, 0, 0)
+	var stream []uint8
+	put := func(v uint32, n int) {
+		for i := n - 1; i >= 0; i-- {
+			stream = append(stream, uint8(v>>uint(i)&1))
+		}
+		if len(stream) > 16*(len(slots)-1) {
+			slots = append(slots, len(out))
+			out = append(out, 0, 0)
+		}
+	}
+
+	for i := start; i < end; {
+		length, offset := 0, 0
+		for off := 1; off <= i-start && off <= 1024; off++ {
+			l := 0
+			for i+l < end && l < 300 && data[i+l] == data[i+l-off] {
+				l++
+			}
+			if l > length {
+				length, offset = l, off
+			}
+		}
+		if length < 3 {
+			put(uint32(data[i]), 9)
+			i++
+			continue
+		}
+		offsetBits := bits.Len(uint(offset)) - 1
+		code := length - 3
+		if code > 15 {
+			code = 15
+		}
+		put(uint32(256+offsetBits<<4+code), 9)
+		if code == 15 {
+			if length-18 < 255 {
+				out = append(out, byte(length-18))
+			} else {
+				out = append(out, 255, byte(length-3), byte((length-3)>>8))
+			}
+		}
+		put(uint32(offset-1<<uint(offsetBits)), offsetBits)
+		i += length
+	}
+
+	for k, slot := range slots {
+		var word uint16
+		for j := 0; j < 16; j++ {
+			word <<= 1
+			if idx := 16*k + j; idx < len(stream) {
+				word |= uint16(stream[idx])
+			}
+		}
+		binary.LittleEndian.PutUint16(out[slot:], word)
+	}
+	return out
+}
+
+func TestDecompressXpressHuffman(t *testing.T) {
+	var data []byte
+	data = append(data, strings.Repeat("SCCA prefetch \\VOLUME{01d9}\\WINDOWS\\SYSTEM32\\NTDLL.DLL ", 20)...)
+	data = append(data, bytes.Repeat([]byte{'a'}, 1000)...)
+	// 伪随机数据使输出跨越两个块
+	seed := uint32(1)
+	for len(data) < xpressChunkSize+5000 {
+		seed = seed*1103515245 + 12345
+		data = append(data, byte(seed>>16)%7)
+	}
+
+	compressed := xpressTestCompress(data)
+	got, err := decompressXpressHuffman(compressed, len(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("decompressed %d bytes, mismatch with original %d bytes", len(got), len(data))
+	}
+
+	if _, err := decompressXpressHuffman(compressed[:100], len(data)); err == nil {
+		t.Error("truncated input: expected error")
+	}
+	if _, err := decompressXpressHuffman(make([]byte, 300), 10); err == nil {
+		t.Error("empty Huffman table: expected error")
+	}
+}